@@ -1,23 +1,33 @@
 package shodan
 
+import "context"
+
 const (
 	profilePath = "/account/profile"
 )
 
+// AccountAPI is the part of the client looking up the account and the API plan of the key.
+type AccountAPI interface {
+	GetAccountProfile(ctx context.Context) (*Profile, error)
+	GetAPIInfo(ctx context.Context) (*APIInfo, error)
+}
+
+var _ AccountAPI = (*Client)(nil)
+
 // Profile holds account's information
 type Profile struct {
 	Member  bool   `json:"member"`
 	Credits int    `json:"credits"`
 	Name    string `json:"display_name"`
-	Created string `json:"created"`
+	Created Time   `json:"created"`
 }
 
 // GetAccountProfile returns information about the Shodan account linked to the API key
-func (c *Client) GetAccountProfile() (*Profile, error) {
+func (c *Client) GetAccountProfile(ctx context.Context) (*Profile, error) {
 	url := c.buildBaseURL(profilePath, nil)
 
 	var profile Profile
-	err := c.executeRequest("GET", url, &profile, nil)
+	err := c.executeRequest(ctx, "GET", url, &profile, nil)
 
 	return &profile, err
 }
@@ -1,8 +1,10 @@
 package shodan
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,12 +18,12 @@ func TestClient_GetAccountProfile(t *testing.T) {
 		w.Write(getStub(t, "profile"))
 	})
 
-	account, err := client.GetAccountProfile()
+	account, err := client.GetAccountProfile(context.Background())
 	accountExpected := &Profile{
 		Member:  true,
 		Name:    "",
 		Credits: 40,
-		Created: "2015-09-03T12:44:29.278000",
+		Created: Time{time.Date(2015, time.September, 3, 12, 44, 29, 278000000, time.UTC)},
 	}
 
 	assert.Nil(t, err)
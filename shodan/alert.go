@@ -1,9 +1,13 @@
 package shodan
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
 )
 
 const (
@@ -13,6 +17,16 @@ const (
 	alertCreatePath    = "/shodan/alert"
 )
 
+// AlertAPI is the part of the client managing the network alerts.
+type AlertAPI interface {
+	CreateAlert(ctx context.Context, name string, ip []string, expires int) (*Alert, error)
+	GetAlerts(ctx context.Context) ([]*Alert, error)
+	GetAlert(ctx context.Context, id string) (*Alert, error)
+	DeleteAlert(ctx context.Context, id string) (bool, error)
+}
+
+var _ AlertAPI = (*Client)(nil)
+
 // AlertFilters holds alert criteria (only ip for now).
 type AlertFilters struct {
 	IP []string `json:"ip"`
@@ -22,12 +36,95 @@ type AlertFilters struct {
 type Alert struct {
 	ID         string        `json:"id"`
 	Name       string        `json:"name"`
-	Created    string        `json:"created"`
-	Expiration string        `json:"expiration"`
+	Created    Time          `json:"created"`
+	Expiration Time          `json:"expiration"`
 	Expires    int           `json:"expires"`
 	Expired    bool          `json:"expired"`
 	Size       int           `json:"size"`
 	Filters    *AlertFilters `json:"filters"`
+
+	// Triggers are the triggers enabled on the alert keyed by name, i.e. "malware".
+	Triggers map[string]AlertTriggerState `json:"triggers"`
+	// Notifiers deliver the notifications of the alert, see AddAlertNotifier.
+	Notifiers []*Notifier `json:"notifiers"`
+}
+
+// AlertTriggerState is the state of a trigger of an alert. Shodan only lists the enabled triggers, so
+// every decoded trigger is enabled.
+type AlertTriggerState struct {
+	Enabled bool `json:"-"`
+
+	// Ignore holds the services the trigger doesn't notify about, i.e. "198.51.100.12:27017".
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// UnmarshalJSON decodes the trigger, it's marked enabled.
+func (s *AlertTriggerState) UnmarshalJSON(b []byte) error {
+	var state struct {
+		Ignore []string `json:"ignore"`
+	}
+
+	if err := json.Unmarshal(b, &state); err != nil {
+		return err
+	}
+
+	s.Enabled = true
+	s.Ignore = state.Ignore
+
+	return nil
+}
+
+// HasTrigger reports whether the trigger is enabled on the alert.
+func (a *Alert) HasTrigger(name string) bool {
+	return a.Triggers[name].Enabled
+}
+
+// String returns a one-line summary of the alert, i.e. "alert prod-edge (3 nets, expires 2d)".
+func (a *Alert) String() string {
+	var nets int
+	if a.Filters != nil {
+		nets = len(a.Filters.IP)
+	}
+
+	summary := fmt.Sprintf("alert %s (%d nets", a.Name, nets)
+	switch {
+	case a.Expired:
+		summary += ", expired"
+	case a.Expires > 0:
+		summary += ", expires " + formatExpiration(time.Duration(a.Expires)*time.Second)
+	}
+
+	return summary + ")"
+}
+
+// LogValue implements slog.LogValuer.
+func (a *Alert) LogValue() slog.Value {
+	var nets int
+	if a.Filters != nil {
+		nets = len(a.Filters.IP)
+	}
+
+	return slog.GroupValue(
+		slog.String("id", a.ID),
+		slog.String("name", a.Name),
+		slog.Int("nets", nets),
+		slog.Int("size", a.Size),
+		slog.Bool("expired", a.Expired),
+	)
+}
+
+// formatExpiration formats the duration with the largest whole unit, i.e. "2d" or "5h".
+func formatExpiration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	case d >= time.Hour:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	case d >= time.Minute:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	default:
+		return strconv.Itoa(int(d/time.Second)) + "s"
+	}
 }
 
 type alertCreateRequest struct {
@@ -36,61 +133,107 @@ type alertCreateRequest struct {
 	Filters *AlertFilters `json:"filters"`
 }
 
+// CreateAlertOptions is options for CreateAlertWithOptions and CreateAlertForNetworks.
+type CreateAlertOptions struct {
+	// Expires is the number of seconds the alert is active, 0 means it never expires.
+	Expires int
+
+	// PrecheckQuota checks the networks fit in the monitored IPs quota of the plan before creating
+	// the alert, a MonitoringQuotaError is returned if they don't.
+	PrecheckQuota bool
+}
+
 // CreateAlert creates a network alert for a defined IP/ netblock which can be used to
 // subscribe to changes/ events that are discovered within that range.
-func (c *Client) CreateAlert(name string, ip []string, expires int) (*Alert, error) {
+// It's a part of AlertAPI.
+func (c *Client) CreateAlert(ctx context.Context, name string, ip []string, expires int) (*Alert, error) {
+	return c.CreateAlertWithOptions(ctx, name, ip, &CreateAlertOptions{Expires: expires})
+}
+
+// CreateAlertWithOptions creates a network alert for the IPs and netblocks, just like CreateAlert.
+func (c *Client) CreateAlertWithOptions(ctx context.Context, name string, ip []string, options *CreateAlertOptions) (*Alert, error) {
+	return c.createAlert(ctx, name, ip, options)
+}
+
+// CreateAlertForNetworks creates a network alert monitoring the networks, just like CreateAlert.
+func (c *Client) CreateAlertForNetworks(ctx context.Context, name string, networks []*net.IPNet, options *CreateAlertOptions) (*Alert, error) {
+	ips := make([]string, len(networks))
+	for i, network := range networks {
+		ips[i] = network.String()
+	}
+
+	return c.createAlert(ctx, name, ips, options)
+}
+
+func (c *Client) createAlert(ctx context.Context, name string, ip []string, options *CreateAlertOptions) (*Alert, error) {
+	if options == nil {
+		options = new(CreateAlertOptions)
+	}
+
+	if options.PrecheckQuota {
+		if err := c.precheckQuota(ctx, ip); err != nil {
+			return nil, err
+		}
+	}
+
 	url := c.buildBaseURL(alertCreatePath, nil)
 
 	payload := &alertCreateRequest{
 		Name:    name,
-		Expires: expires,
+		Expires: options.Expires,
 		Filters: &AlertFilters{
 			IP: ip,
 		},
 	}
 
-	b, err := json.Marshal(payload)
+	body, err := newJSONBody(payload)
 	if err != nil {
 		return nil, err
 	}
 
 	var alert Alert
-	err = c.executeRequest("POST", url, &alert, bytes.NewReader(b))
+	err = c.executeRequest(ctx, "POST", url, &alert, body)
 
 	return &alert, err
 }
 
 // GetAlerts returns a listing of all the network alerts
 // that are currently active on the account.
-func (c *Client) GetAlerts() ([]*Alert, error) {
+// It's a part of AlertAPI.
+func (c *Client) GetAlerts(ctx context.Context) ([]*Alert, error) {
 	url := c.buildBaseURL(alertsInfoListPath, nil)
 
 	alerts := make([]*Alert, 0, 0)
-	err := c.executeRequest("GET", url, &alerts, nil)
+	err := c.executeRequest(ctx, "GET", url, &alerts, nil)
 
 	return alerts, err
 }
 
 // GetAlert returns the information about a specific network alert.
-func (c *Client) GetAlert(id string) (*Alert, error) {
+// It's a part of AlertAPI.
+func (c *Client) GetAlert(ctx context.Context, id string) (*Alert, error) {
 	path := fmt.Sprintf(alertInfoPath, id)
 	url := c.buildBaseURL(path, nil)
 
 	var alert Alert
-	err := c.executeRequest("GET", url, &alert, nil)
+	err := c.executeRequest(ctx, "GET", url, &alert, nil)
 
 	return &alert, err
 }
 
 // DeleteAlert removes the specified network alert.
-func (c *Client) DeleteAlert(id string) (bool, error) {
-	path := fmt.Sprintf(alertDeletePath, id)
-	url := c.buildBaseURL(path, nil)
-
-	err := c.executeRequest("DELETE", url, nil, nil)
-	if err != nil {
+// It's a part of AlertAPI.
+func (c *Client) DeleteAlert(ctx context.Context, id string) (bool, error) {
+	if err := c.deleteAlert(ctx, id); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
+
+func (c *Client) deleteAlert(ctx context.Context, id string) error {
+	path := fmt.Sprintf(alertDeletePath, id)
+	url := c.buildBaseURL(path, nil)
+
+	return c.executeRequest(ctx, "DELETE", url, nil, nil)
+}
@@ -1,52 +1,61 @@
-package shodan
+package shodan_test
 
 import (
-	"fmt"
-	"github.com/stretchr/testify/assert"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
 	"net/http"
 	"testing"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestClient_DeleteAlert(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
+	server := shodantest.NewServer()
+	defer server.Close()
 
+	client := server.Client()
 	id := "ZZ4TDUUORVE1DIIP"
-	path := fmt.Sprintf(alertDeletePath, id)
 
-	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "DELETE", r.Method)
-		fmt.Fprint(w, `{}`)
-	})
-
-	result, err := client.DeleteAlert(id)
+	result, err := client.DeleteAlert(context.Background(), id)
 
 	assert.Nil(t, err)
 	assert.True(t, result)
-}
 
-func TestClient_GetAlert(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
+	_, err = client.GetAlert(context.Background(), id)
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    "Invalid Alert ID",
+		Body:       []byte(`{"error":"Invalid Alert ID"}`),
+		Method:     "GET",
+		Endpoint:   "/shodan/alert/{id}/info",
+		Path:       "/shodan/alert/ZZ4TDUUORVE1DIIP/info",
+	}, err)
 
-	id := "ZZ4TDUUORVE1DIIP"
-	path := fmt.Sprintf(alertInfoPath, id)
+	result, err = client.DeleteAlert(context.Background(), id)
 
-	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "GET", r.Method)
-		w.Write(getStub(t, "alert/alert"))
-	})
+	assert.NotNil(t, err)
+	assert.False(t, result)
+}
 
-	alert, err := client.GetAlert(id)
-	alertExpected := &Alert{
-		ID:         "ZZ4TDUUORVE1DIIP",
-		Name:       "Test alert",
-		Created:    "2017-09-24T18:30:43.592000",
-		Expires:    0,
+func TestClient_GetAlert(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	alert, err := server.Client().GetAlert(context.Background(), "IU0CJDXNNEXBOPK3")
+	alertExpected := &shodan.Alert{
+		ID:         "IU0CJDXNNEXBOPK3",
+		Name:       "Test alert 2",
+		Created:    shodan.Time{Time: time.Date(2017, time.September, 24, 20, 8, 51, 815000000, time.UTC)},
+		Expires:    100,
 		Expired:    false,
-		Expiration: "",
-		Filters: &AlertFilters{
-			IP: []string{"198.20.22.0/24"},
+		Expiration: shodan.Time{Time: time.Date(2017, time.September, 24, 20, 10, 31, 815000000, time.UTC)},
+		Filters: &shodan.AlertFilters{
+			IP: []string{"198.20.88.0/24"},
 		},
 		Size: 256,
 	}
@@ -56,24 +65,19 @@ func TestClient_GetAlert(t *testing.T) {
 }
 
 func TestClient_GetAlerts(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
-
-	mux.HandleFunc(alertsInfoListPath, func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "GET", r.Method)
-		w.Write(getStub(t, "alert/alerts"))
-	})
+	server := shodantest.NewServer()
+	defer server.Close()
 
-	alerts, err := client.GetAlerts()
-	alertsExpected := []*Alert{
+	alerts, err := server.Client().GetAlerts(context.Background())
+	alertsExpected := []*shodan.Alert{
 		{
 			ID:         "ZZ4TDUUORVE1DIIP",
 			Expired:    true,
 			Name:       "Test alert",
-			Created:    "2017-09-24T18:30:43.592000",
+			Created:    shodan.Time{Time: time.Date(2017, time.September, 24, 18, 30, 43, 592000000, time.UTC)},
 			Expires:    0,
-			Expiration: "",
-			Filters: &AlertFilters{
+			Expiration: shodan.Time{},
+			Filters: &shodan.AlertFilters{
 				IP: []string{"198.20.22.0/24"},
 			},
 			Size: 256,
@@ -82,10 +86,10 @@ func TestClient_GetAlerts(t *testing.T) {
 			ID:         "IU0CJDXNNEXBOPK3",
 			Name:       "Test alert 2",
 			Expired:    false,
-			Created:    "2017-09-24T20:08:51.815000",
+			Created:    shodan.Time{Time: time.Date(2017, time.September, 24, 20, 8, 51, 815000000, time.UTC)},
 			Expires:    100,
-			Expiration: "2017-09-24T20:10:31.815000",
-			Filters: &AlertFilters{
+			Expiration: shodan.Time{Time: time.Date(2017, time.September, 24, 20, 10, 31, 815000000, time.UTC)},
+			Filters: &shodan.AlertFilters{
 				IP: []string{"198.20.88.0/24"},
 			},
 			Size: 256,
@@ -97,28 +101,118 @@ func TestClient_GetAlerts(t *testing.T) {
 }
 
 func TestClient_CreateAlert(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
+	server := shodantest.NewServer()
+	defer server.Close()
 
-	mux.HandleFunc(alertCreatePath, func(w http.ResponseWriter, r *http.Request) {
+	client := server.Client()
+	alert, err := client.CreateAlert(context.Background(), "Test alert API", []string{"198.20.88.0/24", "1.1.1.1"}, 0)
+
+	assert.Nil(t, err)
+	assert.Len(t, alert.ID, 16)
+	assert.Equal(t, "Test alert API", alert.Name)
+	assert.Equal(t, 257, alert.Size)
+	assert.Equal(t, &shodan.AlertFilters{IP: []string{"198.20.88.0/24", "1.1.1.1"}}, alert.Filters)
+
+	stored, err := client.GetAlert(context.Background(), alert.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, alert, stored)
+	assert.Equal(t, 1, server.Requests("/shodan/alert"))
+}
+
+func TestClient_CreateAlert_body(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	server.Handle("/shodan/alert", func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "POST", r.Method)
-		w.Write(getStub(t, "alert/create_alert"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"name": "edge", "expires": 3600, "filters": {"ip": ["198.20.88.0/24", "1.1.1.1"]}}`, string(body))
+
+		w.Write(shodantest.Fixture("alert/create_alert"))
 	})
 
-	alert, err := client.CreateAlert("Test alert API", []string{"198.20.88.0/24"}, 0)
-	alertExpected := &Alert{
-		ID:         "JZT8NVWEZWCY79OO",
-		Name:       "Test alert API",
-		Created:    "2017-09-24T23:08:43.434646",
-		Expires:    0,
-		Expired:    false,
-		Expiration: "",
-		Filters: &AlertFilters{
-			IP: []string{"198.20.88.0/24"},
+	_, err := server.Client().CreateAlert(context.Background(), "edge", []string{"198.20.88.0/24", "1.1.1.1"}, 3600)
+
+	assert.Nil(t, err)
+}
+
+func TestAlert_String(t *testing.T) {
+	testCases := []struct {
+		alert    *shodan.Alert
+		expected string
+	}{
+		{
+			&shodan.Alert{Name: "prod-edge", Expires: 2*24*3600 + 100, Filters: &shodan.AlertFilters{IP: []string{"a", "b", "c"}}},
+			"alert prod-edge (3 nets, expires 2d)",
+		},
+		{
+			&shodan.Alert{Name: "prod-edge", Expires: 5400, Filters: &shodan.AlertFilters{IP: []string{"a"}}},
+			"alert prod-edge (1 nets, expires 1h)",
+		},
+		{
+			&shodan.Alert{Name: "prod-edge", Expires: 100, Expired: true},
+			"alert prod-edge (0 nets, expired)",
+		},
+		{
+			&shodan.Alert{Name: "prod-edge", Filters: &shodan.AlertFilters{IP: []string{"a"}}},
+			"alert prod-edge (1 nets)",
 		},
-		Size: 256,
 	}
 
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.alert.String())
+	}
+}
+
+func TestAlert_LogValue(t *testing.T) {
+	alert := &shodan.Alert{ID: "ZZ4TDUUORVE1DIIP", Name: "Test alert", Size: 256, Filters: &shodan.AlertFilters{IP: []string{"a"}}}
+	value := alert.LogValue()
+
+	assert.Equal(t, slog.KindGroup, value.Kind())
+	assert.Equal(t, "[id=ZZ4TDUUORVE1DIIP name=Test alert nets=1 size=256 expired=false]", value.String())
+}
+
+func TestClient_GetAlert_triggers(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	id := "XBTOLTT4MZT8VJLE"
+	server.Handle("/shodan/alert/"+id+"/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(shodantest.Fixture("alert/alert_triggers"))
+	})
+
+	alert, err := server.Client().GetAlert(context.Background(), id)
+
 	assert.Nil(t, err)
-	assert.Equal(t, alertExpected, alert)
+	assert.Equal(t, map[string]shodan.AlertTriggerState{
+		"malware": {Enabled: true},
+		"open_database": {
+			Enabled: true,
+			Ignore:  []string{"198.51.100.12:27017", "198.51.100.13:9200"},
+		},
+	}, alert.Triggers)
+	assert.True(t, alert.HasTrigger("malware"))
+	assert.True(t, alert.HasTrigger("open_database"))
+	assert.False(t, alert.HasTrigger("ssl_expired"))
+	assert.Equal(t, []*shodan.Notifier{{
+		ID:          "default",
+		Provider:    "email",
+		Description: "Default notification",
+		Args:        map[string]string{"to": "jmath@shodan.io"},
+	}}, alert.Notifiers)
+
+	encoded, err := json.Marshal(alert)
+	assert.Nil(t, err)
+
+	decoded := new(shodan.Alert)
+	assert.Nil(t, json.Unmarshal(encoded, decoded))
+	assert.Equal(t, alert, decoded)
+}
+
+func TestAlert_HasTrigger_noTriggers(t *testing.T) {
+	assert.False(t, new(shodan.Alert).HasTrigger("malware"))
 }
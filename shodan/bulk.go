@@ -0,0 +1,139 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// HostResult is the result of a single lookup made by BulkHostLookup.
+type HostResult struct {
+	IP net.IP
+
+	// Host is nil when Shodan has no information about the IP, which isn't considered an error.
+	Host *Host
+
+	Err error
+}
+
+// BulkProgress counts the lookups made by BulkHostLookup. It's safe to read while the lookup is running.
+type BulkProgress struct {
+	completed int64
+	found     int64
+	notFound  int64
+	failed    int64
+}
+
+// Completed returns the number of finished lookups, whatever their result is.
+func (p *BulkProgress) Completed() int64 {
+	return atomic.LoadInt64(&p.completed)
+}
+
+// Found returns the number of lookups that returned a host.
+func (p *BulkProgress) Found() int64 {
+	return atomic.LoadInt64(&p.found)
+}
+
+// NotFound returns the number of lookups for IPs that Shodan has no information about.
+func (p *BulkProgress) NotFound() int64 {
+	return atomic.LoadInt64(&p.notFound)
+}
+
+// Failed returns the number of lookups that ended up with an error.
+func (p *BulkProgress) Failed() int64 {
+	return atomic.LoadInt64(&p.failed)
+}
+
+// BulkLookupOptions is options for BulkHostLookup.
+type BulkLookupOptions struct {
+	// Services is passed to every host lookup.
+	Services *HostServicesOptions
+
+	// Progress is updated as the lookups complete, if set.
+	Progress *BulkProgress
+}
+
+// BulkHostLookup looks up every IP received from ips using the given number of workers and delivers the results
+// as they complete, so they don't come in the same order as the IPs. All the workers share the rate limit of the
+// client set with SetRateLimit and rate limited lookups are retried after waiting as WaitForRateLimit does.
+// The results channel is closed once ips is closed and drained, or ctx is done.
+func (c *Client) BulkHostLookup(ctx context.Context, ips <-chan net.IP, options *BulkLookupOptions, workers int) <-chan HostResult {
+	if options == nil {
+		options = new(BulkLookupOptions)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make(chan HostResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.bulkLookupWorker(ctx, ips, options, results)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func (c *Client) bulkLookupWorker(ctx context.Context, ips <-chan net.IP, options *BulkLookupOptions, results chan<- HostResult) {
+	for {
+		var ip net.IP
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return
+		case ip, ok = <-ips:
+			if !ok {
+				return
+			}
+		}
+
+		result := HostResult{IP: ip}
+		result.Err = retryRateLimited(ctx, func() (err error) {
+			result.Host, err = c.GetServicesForHost(ctx, ip.String(), options.Services)
+			return err
+		})
+
+		var apiErr *APIError
+		if errors.As(result.Err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			result.Host, result.Err = nil, nil
+		}
+
+		if result.Err != nil {
+			result.Host = nil
+		}
+
+		if progress := options.Progress; progress != nil {
+			switch {
+			case result.Err != nil:
+				atomic.AddInt64(&progress.failed, 1)
+			case result.Host == nil:
+				atomic.AddInt64(&progress.notFound, 1)
+			default:
+				atomic.AddInt64(&progress.found, 1)
+			}
+
+			atomic.AddInt64(&progress.completed, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case results <- result:
+		}
+	}
+}
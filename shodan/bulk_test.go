@@ -0,0 +1,112 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_BulkHostLookup(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var requests, limited int64
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("minify"))
+
+		ip := strings.TrimPrefix(r.URL.Path, hostPath+"/")
+		switch {
+		case ip == "10.0.0.1":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error": "Internal error"}`)
+		case ip == "10.0.0.2" && atomic.AddInt64(&limited, 1) == 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": "Rate limit reached"}`)
+		case strings.HasPrefix(ip, "192.168."):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": "No information available for that IP."}`)
+		default:
+			fmt.Fprintf(w, `{"ip_str": "%s", "ports": [80], "data": [{"ip_str": "%s", "port": 80}]}`, ip, ip)
+		}
+
+		atomic.AddInt64(&requests, 1)
+	})
+
+	ips := make(chan net.IP)
+	go func() {
+		for _, ip := range []string{"8.8.8.8", "10.0.0.1", "192.168.0.1", "1.1.1.1", "192.168.0.2", "8.8.4.4", "10.0.0.2"} {
+			ips <- net.ParseIP(ip)
+		}
+
+		close(ips)
+	}()
+
+	progress := new(BulkProgress)
+	options := &BulkLookupOptions{
+		Services: &HostServicesOptions{Minify: true},
+		Progress: progress,
+	}
+
+	found := make(map[string]*Host)
+	failed := make(map[string]error)
+	notFound := make([]string, 0)
+
+	for result := range client.BulkHostLookup(context.Background(), ips, options, 3) {
+		switch {
+		case result.Err != nil:
+			assert.Nil(t, result.Host)
+			failed[result.IP.String()] = result.Err
+		case result.Host == nil:
+			notFound = append(notFound, result.IP.String())
+		default:
+			found[result.IP.String()] = result.Host
+		}
+	}
+
+	assert.Equal(t, int64(8), atomic.LoadInt64(&requests))
+	assert.Len(t, found, 4)
+	assert.Equal(t, "8.8.4.4", found["8.8.4.4"].IP)
+	assert.Equal(t, "10.0.0.2", found["10.0.0.2"].IP)
+	assert.ElementsMatch(t, []string{"192.168.0.1", "192.168.0.2"}, notFound)
+
+	var apiErr *APIError
+	assert.Len(t, failed, 1)
+	assert.True(t, errors.As(failed["10.0.0.1"], &apiErr))
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+
+	assert.Equal(t, int64(7), progress.Completed())
+	assert.Equal(t, int64(4), progress.Found())
+	assert.Equal(t, int64(2), progress.NotFound())
+	assert.Equal(t, int64(1), progress.Failed())
+}
+
+func TestClient_BulkHostLookup_cancelled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ip_str": "8.8.8.8"}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ips := make(chan net.IP, 1)
+	ips <- net.ParseIP("8.8.8.8")
+
+	results := client.BulkHostLookup(ctx, ips, nil, 2)
+	result := <-results
+	assert.Nil(t, result.Err)
+
+	cancel()
+
+	for range results {
+	}
+}
@@ -0,0 +1,152 @@
+package shodan
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings are the connection level timings of a request. The phases that didn't happen, i.e. the
+// DNS lookup and the connect of a reused connection, are 0.
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// TTFB is the time from asking for a connection to the first byte of the response.
+	TTFB time.Duration
+}
+
+// Response describes the HTTP response a call ended with.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// Timings is only set when the client is created with WithClientTrace.
+	Timings *Timings
+}
+
+type responseKey struct{}
+
+// WithResponse returns a context storing the response of the request sent with it in response,
+// i.e. to look at the Timings of a call. Deduplicated calls waiting for another one don't send a
+// request, their response is left untouched.
+func WithResponse(ctx context.Context, response *Response) context.Context {
+	return context.WithValue(ctx, responseKey{}, response)
+}
+
+// WithClientTrace makes the client attach the trace returned by fn to every outgoing request,
+// including the stream subscriptions. fn is called with the context of the request, it may return
+// nil to skip a request. The timings of the traced requests are reported in Response.Timings.
+func WithClientTrace(fn func(ctx context.Context) *httptrace.ClientTrace) ClientOption {
+	return func(c *Client) {
+		c.clientTrace = fn
+	}
+}
+
+// WithRequestHook makes the client call fn with every outgoing request right before it's sent,
+// including the stream subscriptions, i.e. to add headers. The URL of the request carries the API
+// key in the "key" parameter, it must not be logged as is. The hooks are called in the order they
+// were added and concurrently for concurrent requests.
+func WithRequestHook(fn func(req *http.Request)) ClientOption {
+	return func(c *Client) {
+		c.requestHooks = append(c.requestHooks, fn)
+	}
+}
+
+// WithResponseHook makes the client call fn once the response headers of every request have been
+// received, along with the time it took. res is nil when no response has been received, err is the
+// APIError of an unsuccessful status code. The body must be left alone, it's read by the client.
+// The hooks are called in the order they were added and concurrently for concurrent requests.
+func WithResponseHook(fn func(res *http.Response, elapsed time.Duration, err error)) ClientOption {
+	return func(c *Client) {
+		c.responseHooks = append(c.responseHooks, fn)
+	}
+}
+
+func (c *Client) callResponseHooks(res *http.Response, started time.Time, err error) {
+	if len(c.responseHooks) == 0 {
+		return
+	}
+
+	elapsed := time.Since(started)
+	for _, hook := range c.responseHooks {
+		hook(res, elapsed, err)
+	}
+}
+
+// timingsTrace collects the timings of a single request.
+type timingsTrace struct {
+	mu                             sync.Mutex
+	timings                        Timings
+	getConn, dns, connect, tlsInit time.Time
+}
+
+func (t *timingsTrace) clientTrace() *httptrace.ClientTrace {
+	since := func(start *time.Time, phase *time.Duration) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if !start.IsZero() {
+			*phase = time.Since(*start)
+		}
+	}
+
+	mark := func(start *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		*start = time.Now()
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn:              func(string) { mark(&t.getConn) },
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&t.dns) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { since(&t.dns, &t.timings.DNS) },
+		ConnectStart:         func(string, string) { mark(&t.connect) },
+		ConnectDone:          func(string, string, error) { since(&t.connect, &t.timings.Connect) },
+		TLSHandshakeStart:    func() { mark(&t.tlsInit) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { since(&t.tlsInit, &t.timings.TLS) },
+		GotFirstResponseByte: func() { since(&t.getConn, &t.timings.TTFB) },
+	}
+}
+
+func (t *timingsTrace) result() *Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := t.timings
+	return &timings
+}
+
+// traceRequest attaches the client trace of the user and the one collecting the timings to the context.
+func (c *Client) traceRequest(ctx context.Context) (context.Context, *timingsTrace) {
+	if c.clientTrace == nil {
+		return ctx, nil
+	}
+
+	if trace := c.clientTrace(ctx); trace != nil {
+		ctx = httptrace.WithClientTrace(ctx, trace)
+	}
+
+	timings := new(timingsTrace)
+
+	return httptrace.WithClientTrace(ctx, timings.clientTrace()), timings
+}
+
+// storeResponse fills the response requested with WithResponse, if any.
+func storeResponse(ctx context.Context, res *http.Response, timings *timingsTrace) {
+	response, ok := ctx.Value(responseKey{}).(*Response)
+	if !ok || response == nil {
+		return
+	}
+
+	response.StatusCode = res.StatusCode
+	response.Header = res.Header
+	response.Timings = nil
+
+	if timings != nil {
+		response.Timings = timings.result()
+	}
+}
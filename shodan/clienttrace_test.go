@@ -0,0 +1,165 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_WithClientTrace(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.Write(getStub(t, "host/search"))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var conns []bool
+	c := NewClient(server.Client(), testClientToken, WithClientTrace(func(ctx context.Context) *httptrace.ClientTrace {
+		return &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				conns = append(conns, info.Reused)
+			},
+		}
+	}))
+	c.BaseURL = server.URL
+
+	var responses [2]Response
+	for i := range responses {
+		ctx := WithResponse(context.Background(), &responses[i])
+		_, err := c.SearchHostsFunc(ctx, &HostQueryOptions{Query: "nginx"}, func(*HostData) error { return nil })
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, []bool{false, true}, conns)
+
+	first := responses[0]
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, "yes", first.Header.Get("X-Test"))
+	assert.NotNil(t, first.Timings)
+	assert.True(t, first.Timings.Connect > 0)
+	assert.True(t, first.Timings.TLS > 0)
+	assert.True(t, first.Timings.TTFB >= first.Timings.Connect+first.Timings.TLS)
+
+	second := responses[1]
+	assert.NotNil(t, second.Timings)
+	assert.Equal(t, Timings{TTFB: second.Timings.TTFB}, *second.Timings)
+	assert.True(t, second.Timings.TTFB > 0)
+}
+
+func TestClient_WithClientTrace_stream(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var mu sync.Mutex
+	fired := 0
+	client = NewClient(nil, testClientToken, WithClientTrace(func(ctx context.Context) *httptrace.ClientTrace {
+		return &httptrace.ClientTrace{
+			GotFirstResponseByte: func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				fired++
+			},
+		}
+	}))
+	client.StreamBaseURL = server.URL
+
+	mux.HandleFunc(bannersPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 22}`)
+	})
+
+	client.GetBanners(context.Background())
+	for range client.StreamChan {
+	}
+
+	assert.Equal(t, 1, fired)
+}
+
+func TestWithResponse_withoutClientTrace(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Invalid API key"}`, http.StatusUnauthorized)
+	})
+
+	var response Response
+	_, err := client.SearchHostsFunc(WithResponse(context.Background(), &response), &HostQueryOptions{Query: "nginx"},
+		func(*HostData) error { return nil })
+
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	assert.Nil(t, response.Timings)
+}
+
+func TestClient_WithRequestHook_WithResponseHook(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	type call struct {
+		status int
+		err    error
+	}
+
+	var mu sync.Mutex
+	var calls []call
+	client = NewClient(nil, testClientToken,
+		WithRequestHook(func(req *http.Request) {
+			req.Header.Set("X-Request-Id", "42")
+		}),
+		WithResponseHook(func(res *http.Response, elapsed time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Greater(t, elapsed, time.Duration(0))
+			calls = append(calls, call{status: res.StatusCode, err: err})
+		}),
+	)
+	client.BaseURL = server.URL
+
+	mux.HandleFunc(portsPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "42", r.Header.Get("X-Request-Id"))
+		w.Write(getStub(t, "ports"))
+	})
+
+	mux.HandleFunc(protocolsPath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Invalid API key"}`, http.StatusUnauthorized)
+	})
+
+	_, err := client.GetPorts(context.Background())
+	assert.Nil(t, err)
+
+	_, err = client.GetProtocols(context.Background())
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	assert.Len(t, calls, 2)
+	assert.Equal(t, call{status: http.StatusOK}, calls[0])
+	assert.Equal(t, http.StatusUnauthorized, calls[1].status)
+	assert.Equal(t, err, calls[1].err)
+}
+
+func TestClient_WithResponseHook_noResponse(t *testing.T) {
+	var received *http.Response
+	var hookErr error
+	client := NewClient(nil, testClientToken, WithResponseHook(func(res *http.Response, _ time.Duration, err error) {
+		received, hookErr = res, err
+	}))
+	client.BaseURL = "http://127.0.0.1:1"
+
+	_, err := client.GetPorts(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Nil(t, received)
+	assert.NotNil(t, hookErr)
+}
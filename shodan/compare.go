@@ -0,0 +1,204 @@
+package shodan
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VolatileFields are the field paths that change every time the same service is crawled, they are
+// ignored by Equal when CompareOptions.IgnoreVolatile is set.
+var VolatileFields = []string{
+	"timestamp",
+	"_shodan.crawler",
+	"_shodan.id",
+	"_shodan.ptr",
+	"_shodan.region",
+	"current_ptr",
+}
+
+// CompareOptions controls which fields Equal looks at.
+type CompareOptions struct {
+	// IgnoreVolatile ignores the VolatileFields.
+	IgnoreVolatile bool
+
+	// IgnoreFields are more field paths to ignore, i.e. "location" or "ssl.cipher".
+	IgnoreFields []string
+}
+
+func (o CompareOptions) ignored(path string) bool {
+	if o.IgnoreVolatile && matchesFieldPath(VolatileFields, path) {
+		return true
+	}
+
+	return matchesFieldPath(o.IgnoreFields, path)
+}
+
+// matchesFieldPath reports whether the path is one of the paths or nested in one of them.
+func matchesFieldPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Hash returns the identity of the service the banner was grabbed from. It's computed over the IP, the
+// port, the transport, the module, the product and the certificate fingerprint, so it stays the same
+// across crawls of the same service and across restarts of the process.
+func (h *HostData) Hash() uint64 {
+	ip := strings.TrimSpace(h.IP)
+	if parsed := net.ParseIP(ip); parsed != nil {
+		ip = parsed.String()
+	}
+
+	var module string
+	if h.ShodanData != nil {
+		module, _ = h.ShodanData["module"].(string)
+	}
+
+	var fingerprint string
+	if h.SSL != nil && h.SSL.Cert != nil {
+		fingerprint = h.SSL.Cert.Fingerprint.SHA256
+		if fingerprint == "" {
+			fingerprint = h.SSL.Cert.Fingerprint.SHA1
+		}
+	}
+
+	hash := fnv.New64a()
+	for _, field := range []string{ip, strconv.Itoa(h.Port), h.Transport, module, h.Product, fingerprint} {
+		hash.Write([]byte(strings.ToLower(strings.TrimSpace(field))))
+		hash.Write([]byte{0})
+	}
+
+	return hash.Sum64()
+}
+
+// Equal reports whether the banners hold the same data, the options tell which fields are ignored.
+// Missing and empty values are equal, but a nil banner only equals another nil banner.
+func (h *HostData) Equal(other *HostData, opts CompareOptions) bool {
+	if h == nil || other == nil {
+		return h == other
+	}
+
+	return len(changedFields(h, other, opts)) == 0
+}
+
+// ChangedFields returns the paths of the fields that differ between the banners, sorted. The paths are
+// made of the JSON names, i.e. "ssl.cert.fingerprint.sha256", lists are compared as a whole. Missing
+// and empty values are equal, so a nil banner is the same as an empty one.
+func ChangedFields(a, b *HostData) []string {
+	return changedFields(a, b, CompareOptions{})
+}
+
+func changedFields(a, b *HostData, opts CompareOptions) []string {
+	changed := make([]string, 0)
+	diffValues("", bannerFields(a), bannerFields(b), opts, &changed)
+	sort.Strings(changed)
+
+	return changed
+}
+
+// bannerFields returns the banner as generic JSON values with the empty values dropped.
+func bannerFields(banner *HostData) interface{} {
+	if banner == nil {
+		return nil
+	}
+
+	content, err := json.Marshal(banner)
+	if err != nil {
+		return nil
+	}
+
+	var fields interface{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil
+	}
+
+	return normalizeField(fields)
+}
+
+// normalizeField turns the empty values into nil, so they compare equal to the missing ones.
+func normalizeField(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+	case float64:
+		if v == 0 {
+			return nil
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	case map[string]interface{}:
+		for key, field := range v {
+			if v[key] = normalizeField(field); v[key] == nil {
+				delete(v, key)
+			}
+		}
+
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		for i, field := range v {
+			v[i] = normalizeField(field)
+		}
+
+		if len(v) == 0 {
+			return nil
+		}
+	}
+
+	return value
+}
+
+func diffValues(path string, a, b interface{}, opts CompareOptions, changed *[]string) {
+	if path != "" && opts.ignored(path) {
+		return
+	}
+
+	aFields, aIsMap := a.(map[string]interface{})
+	bFields, bIsMap := b.(map[string]interface{})
+
+	// Anything but two maps is compared as a whole, a missing banner is the same as an empty one.
+	if path == "" {
+		aIsMap = aIsMap || a == nil
+		bIsMap = bIsMap || b == nil
+	}
+
+	if !aIsMap || !bIsMap {
+		if !reflect.DeepEqual(a, b) {
+			*changed = append(*changed, path)
+		}
+
+		return
+	}
+
+	keys := make(map[string]bool)
+	for key := range aFields {
+		keys[key] = true
+	}
+
+	for key := range bFields {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		diffValues(fieldPath, aFields[key], bFields[key], opts, changed)
+	}
+}
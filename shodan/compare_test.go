@@ -0,0 +1,154 @@
+package shodan_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostData_Hash(t *testing.T) {
+	base := shodantest.NewBanner().WithSSL(shodan.HostSSL{}).Build()
+
+	cases := []struct {
+		name   string
+		modify func(*shodan.HostData)
+		same   bool
+	}{
+		{"identical", func(*shodan.HostData) {}, true},
+		{"recrawled", func(b *shodan.HostData) {
+			b.Timestamp = shodan.Time{Time: b.Timestamp.Add(time.Hour)}
+			b.ShodanData["crawler"] = "fedcba9876543210fedcba9876543210fedcba98"
+			b.Data = "HTTP/1.1 503 Service Unavailable\r\n\r\n"
+		}, true},
+		{"normalized", func(b *shodan.HostData) {
+			b.Transport = "TCP"
+			b.Product = " NGINX "
+		}, true},
+		{"ip", func(b *shodan.HostData) { b.IP = "192.0.2.2" }, false},
+		{"port", func(b *shodan.HostData) { b.Port = 8443 }, false},
+		{"transport", func(b *shodan.HostData) { b.Transport = "udp" }, false},
+		{"module", func(b *shodan.HostData) { b.ShodanData["module"] = "http-simple-new" }, false},
+		{"product", func(b *shodan.HostData) { b.Product = "Apache httpd" }, false},
+		{"certificate", func(b *shodan.HostData) { b.SSL.Cert.Fingerprint.SHA256 = "00" }, false},
+		{"no certificate", func(b *shodan.HostData) { b.SSL = nil }, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			banner := shodantest.NewBanner().WithSSL(shodan.HostSSL{}).Build()
+			c.modify(banner)
+
+			assert.Equal(t, c.same, banner.Hash() == base.Hash())
+		})
+	}
+}
+
+func TestHostData_Hash_stable(t *testing.T) {
+	banner := &shodan.HostData{IP: "2001:0db8::0001", Port: 22, Transport: "tcp", Product: "OpenSSH"}
+
+	assert.Equal(t, uint64(0x7f9763cb744a9fd0), banner.Hash())
+	assert.Equal(t, banner.Hash(), (&shodan.HostData{IP: "2001:db8::1", Port: 22, Transport: "tcp", Product: "OpenSSH"}).Hash())
+}
+
+func TestHostData_Equal(t *testing.T) {
+	cases := []struct {
+		name    string
+		modify  func(*shodan.HostData)
+		opts    shodan.CompareOptions
+		equal   bool
+		changed []string
+	}{
+		{
+			name:    "identical",
+			modify:  func(*shodan.HostData) {},
+			equal:   true,
+			changed: []string{},
+		},
+		{
+			name:    "empty lists",
+			modify:  func(b *shodan.HostData) { b.Tags = []string{}; b.Opts = nil },
+			equal:   true,
+			changed: []string{},
+		},
+		{
+			name: "volatile only",
+			modify: func(b *shodan.HostData) {
+				b.Timestamp = shodan.Time{Time: b.Timestamp.Add(time.Hour)}
+				b.ShodanData["crawler"] = "fedcba9876543210fedcba9876543210fedcba98"
+				b.ShodanData["id"] = "11111111-1111-4111-8111-111111111111"
+				b.ShodanData["region"] = "eu"
+				b.CurrentPTR = []string{"host.example.net"}
+			},
+			opts:    shodan.CompareOptions{IgnoreVolatile: true},
+			equal:   true,
+			changed: []string{"_shodan.crawler", "_shodan.id", "_shodan.region", "current_ptr", "timestamp"},
+		},
+		{
+			name:    "volatile compared",
+			modify:  func(b *shodan.HostData) { b.Timestamp = shodan.Time{Time: b.Timestamp.Add(time.Hour)} },
+			changed: []string{"timestamp"},
+		},
+		{
+			name:    "volatile and data",
+			modify:  func(b *shodan.HostData) { b.Timestamp = shodan.Time{}; b.Version = "1.20.0" },
+			opts:    shodan.CompareOptions{IgnoreVolatile: true},
+			changed: []string{"timestamp", "version"},
+		},
+		{
+			name:    "ignored fields",
+			modify:  func(b *shodan.HostData) { b.Location.City = "San Diego"; b.SSL.Cipher.Bits = 128 },
+			opts:    shodan.CompareOptions{IgnoreFields: []string{"location", "ssl.cipher"}},
+			equal:   true,
+			changed: []string{"location.city", "ssl.cipher.bits"},
+		},
+		{
+			name:    "nested",
+			modify:  func(b *shodan.HostData) { b.SSL.Cert.Fingerprint.SHA256 = "00"; b.Location = nil },
+			changed: []string{"location", "ssl.cert.fingerprint.sha256"},
+		},
+		{
+			name:    "lists",
+			modify:  func(b *shodan.HostData) { b.Hostnames = append(b.Hostnames, "example.com") },
+			changed: []string{"hostnames"},
+		},
+		{
+			name: "vulns",
+			modify: func(b *shodan.HostData) {
+				b.Vulns["CVE-2021-23017"].CVSS = 7.7
+				b.Vulns["CVE-2019-20372"] = &shodan.HostVuln{CVSS: 5.3}
+			},
+			changed: []string{"vulns.CVE-2019-20372", "vulns.CVE-2021-23017.cvss"},
+		},
+		{
+			name:    "module",
+			modify:  func(b *shodan.HostData) { b.ShodanData["module"] = "http-simple-new" },
+			opts:    shodan.CompareOptions{IgnoreVolatile: true},
+			changed: []string{"_shodan.module"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			builder := shodantest.NewBanner().WithSSL(shodan.HostSSL{}).WithVuln("CVE-2021-23017", 7.5)
+			a, b := builder.Build(), builder.Build()
+			c.modify(b)
+
+			assert.Equal(t, c.equal, a.Equal(b, c.opts))
+			assert.Equal(t, c.equal, b.Equal(a, c.opts))
+			assert.Equal(t, c.changed, shodan.ChangedFields(a, b))
+		})
+	}
+}
+
+func TestHostData_Equal_nil(t *testing.T) {
+	var banner *shodan.HostData
+
+	assert.True(t, banner.Equal(nil, shodan.CompareOptions{}))
+	assert.False(t, banner.Equal(&shodan.HostData{}, shodan.CompareOptions{}))
+	assert.False(t, (&shodan.HostData{}).Equal(nil, shodan.CompareOptions{}))
+	assert.Equal(t, []string{}, shodan.ChangedFields(nil, &shodan.HostData{}))
+	assert.Equal(t, []string{"ip_str", "port"}, shodan.ChangedFields(nil, &shodan.HostData{IP: "192.0.2.1", Port: 80}))
+}
@@ -0,0 +1,144 @@
+package shodan
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"net"
+	"strings"
+)
+
+// CreditEstimate is the amount of credits an operation consumes.
+type CreditEstimate struct {
+	QueryCredits int
+	ScanCredits  int
+}
+
+// EstimateSearchCredits estimates the query credits needed to fetch the given number of pages of a
+// host search. The 1st page is free unless the query contains a filter, every page past it costs
+// 1 query credit.
+func EstimateSearchCredits(pages int, query string) CreditEstimate {
+	var estimate CreditEstimate
+	if pages < 1 {
+		return estimate
+	}
+
+	estimate.QueryCredits = pages - 1
+	if hasQueryFilter(query) {
+		estimate.QueryCredits++
+	}
+
+	return estimate
+}
+
+// EstimateScanCredits estimates the scan credits needed to scan the given IPs and netblocks,
+// 1 IP consumes 1 scan credit.
+func EstimateScanCredits(ips []string) (CreditEstimate, error) {
+	count, err := countIPs(ips)
+	if err != nil {
+		return CreditEstimate{}, err
+	}
+
+	return CreditEstimate{ScanCredits: count}, nil
+}
+
+// countIPs counts the addresses of the IPs and netblocks, a /24 is 256 IPs. The count saturates at
+// math.MaxInt, an IPv6 /64 alone holds more addresses than an int can count.
+func countIPs(ips []string) (int, error) {
+	var count int
+	for _, ip := range ips {
+		if !strings.Contains(ip, "/") {
+			if parsedIP := net.ParseIP(ip); parsedIP == nil {
+				return 0, &net.ParseError{Type: "IP address", Text: ip}
+			}
+
+			count = addSaturated(count, 1)
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(ip)
+		if err != nil {
+			return 0, err
+		}
+
+		ones, size := network.Mask.Size()
+		if size-ones >= bits.UintSize-1 {
+			count = math.MaxInt
+			continue
+		}
+
+		count = addSaturated(count, 1<<uint(size-ones))
+	}
+
+	return count, nil
+}
+
+// addSaturated adds the non-negative counts, returning math.MaxInt instead of overflowing.
+func addSaturated(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+
+	return a + b
+}
+
+// searchPageCredits estimates the query credits of fetching a single page of a host search.
+func searchPageCredits(options *HostQueryOptions) int {
+	if options == nil {
+		return 0
+	}
+
+	if options.Page > 1 || hasQueryFilter(options.Query) {
+		return 1
+	}
+
+	return 0
+}
+
+// Credits returns the query and the scan credits left on the account.
+func (i *APIInfo) Credits() CreditEstimate {
+	return CreditEstimate{QueryCredits: i.QueryCredits, ScanCredits: i.ScanCredits}
+}
+
+// GetRemainingCredits returns the query and the scan credits left on the account. It's a call to
+// "/api-info", which doesn't cost any credits, so long-running jobs can check it periodically.
+func (c *Client) GetRemainingCredits(ctx context.Context) (CreditEstimate, error) {
+	apiInfo, err := c.GetAPIInfo(ctx)
+	if err != nil {
+		return CreditEstimate{}, err
+	}
+
+	return apiInfo.Credits(), nil
+}
+
+// PrecheckCredits checks the account is able to afford the estimated amount of credits. An
+// InsufficientCreditsError is returned if it's not.
+func (c *Client) PrecheckCredits(ctx context.Context, estimate CreditEstimate) error {
+	available, err := c.GetRemainingCredits(ctx)
+	if err != nil {
+		return err
+	}
+
+	if estimate.QueryCredits > available.QueryCredits || estimate.ScanCredits > available.ScanCredits {
+		return &InsufficientCreditsError{Required: estimate, Available: available}
+	}
+
+	return nil
+}
+
+// EnsureCredits checks the account has at least the given query and scan credits left, so a batch job can
+// fail before spending any of them. An InsufficientCreditsError is returned if it hasn't.
+func (c *Client) EnsureCredits(ctx context.Context, queryCredits, scanCredits int) error {
+	return c.PrecheckCredits(ctx, CreditEstimate{QueryCredits: queryCredits, ScanCredits: scanCredits})
+}
+
+// hasQueryFilter reports whether the search query contains a "filter:value" token.
+func hasQueryFilter(query string) bool {
+	for _, token := range strings.Fields(query) {
+		if i := strings.Index(token, ":"); i > 0 && i < len(token)-1 {
+			return true
+		}
+	}
+
+	return false
+}
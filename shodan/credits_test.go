@@ -0,0 +1,136 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateSearchCredits(t *testing.T) {
+	testCases := []struct {
+		pages    int
+		query    string
+		expected int
+	}{
+		{0, "nginx", 0},
+		{1, "nginx", 0},
+		{1, "nginx country:US", 1},
+		{5, "nginx", 4},
+		{5, "port:22", 5},
+		{2, "ssl.cert.subject.cn:example.com", 2},
+		{1, ":80", 0},
+		{1, "title: ", 0},
+	}
+
+	for _, testCase := range testCases {
+		estimate := EstimateSearchCredits(testCase.pages, testCase.query)
+		assert.Equal(t, CreditEstimate{QueryCredits: testCase.expected}, estimate, testCase.query)
+	}
+}
+
+func TestEstimateScanCredits(t *testing.T) {
+	estimate, err := EstimateScanCredits([]string{"8.8.8.8", "198.20.22.0/24", "2001:db8::/120", "10.0.0.1/32"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, CreditEstimate{ScanCredits: 1 + 256 + 256 + 1}, estimate)
+}
+
+func TestEstimateScanCredits_saturated(t *testing.T) {
+	for _, ips := range [][]string{{"2001:db8::/64"}, {"::/0"}, {"8.8.8.8", "2001:db8::/1"}} {
+		estimate, err := EstimateScanCredits(ips)
+
+		assert.Nil(t, err, ips)
+		assert.Equal(t, CreditEstimate{ScanCredits: math.MaxInt}, estimate, ips)
+	}
+
+	estimate, err := EstimateScanCredits([]string{"128.0.0.0/1", "2001:db8::/97"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, CreditEstimate{ScanCredits: 1<<31 + 1<<31}, estimate)
+}
+
+func TestEstimateScanCredits_invalid(t *testing.T) {
+	invalid := []string{"8.8.8", "198.20.22.0/33", "2001:db8::/129"}
+
+	for _, ip := range invalid {
+		_, err := EstimateScanCredits([]string{ip})
+		assert.NotNil(t, err, ip)
+	}
+}
+
+func TestClient_PrecheckCredits(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		w.Write(getStub(t, "info"))
+	})
+
+	err := client.PrecheckCredits(context.Background(), CreditEstimate{QueryCredits: 2341, ScanCredits: 254})
+	assert.Nil(t, err)
+
+	err = client.PrecheckCredits(context.Background(), CreditEstimate{QueryCredits: 2000, ScanCredits: 300})
+	assert.NotNil(t, err)
+	assert.True(t, errors.Is(err, ErrInsufficientCredits))
+
+	var creditsErr *InsufficientCreditsError
+	assert.True(t, errors.As(err, &creditsErr))
+	assert.Equal(t, CreditEstimate{ScanCredits: 46}, creditsErr.Shortfall())
+	assert.Equal(t, CreditEstimate{QueryCredits: 2341, ScanCredits: 254}, creditsErr.Available)
+}
+
+func TestClient_PrecheckCredits_cancelled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.PrecheckCredits(ctx, CreditEstimate{})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestClient_EnsureCredits(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	requests := 0
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(getStub(t, "info"))
+	})
+
+	assert.Nil(t, client.EnsureCredits(context.Background(), 100, 0))
+
+	err := client.EnsureCredits(context.Background(), 3000, 10)
+
+	var creditsErr *InsufficientCreditsError
+	assert.True(t, errors.As(err, &creditsErr))
+	assert.Equal(t, CreditEstimate{QueryCredits: 3000, ScanCredits: 10}, creditsErr.Required)
+	assert.Equal(t, CreditEstimate{QueryCredits: 659}, creditsErr.Shortfall())
+	assert.Equal(t, "insufficient credits: 659 query and 0 scan credits short", err.Error())
+	assert.Equal(t, 2, requests)
+}
+
+func TestClient_GetRemainingCredits(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+
+	credits, err := client.GetRemainingCredits(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, CreditEstimate{QueryCredits: 2341, ScanCredits: 254}, credits)
+}
@@ -0,0 +1,83 @@
+package shodan
+
+import (
+	"encoding/json"
+)
+
+// searchCursorVersion is bumped whenever the serialized cursor layout changes.
+const searchCursorVersion = 1
+
+// SearchCursor is the position of a host search iteration. It can be persisted as JSON
+// and used to resume the search later without fetching the already seen pages again.
+type SearchCursor struct {
+	// Query is the search query the cursor was created for.
+	Query string
+
+	// Facets and Minify are the rest of the search options.
+	Facets string
+	Minify bool
+
+	// Page is the next page to fetch, Offset is the number of its matches already seen.
+	Page   int
+	Offset int
+
+	// Total is the total number of results, Fetched is the number of matches already seen.
+	Total   int
+	Fetched int
+
+	// Done is true when there are no more pages to fetch.
+	Done bool
+}
+
+type searchCursorJSON struct {
+	Version int    `json:"version"`
+	Query   string `json:"query"`
+	Facets  string `json:"facets,omitempty"`
+	Minify  bool   `json:"minify,omitempty"`
+	Page    int    `json:"page"`
+	Offset  int    `json:"offset,omitempty"`
+	Total   int    `json:"total"`
+	Fetched int    `json:"fetched"`
+	Done    bool   `json:"done,omitempty"`
+}
+
+// MarshalJSON encodes the cursor to JSON.
+func (c *SearchCursor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&searchCursorJSON{
+		Version: searchCursorVersion,
+		Query:   c.Query,
+		Facets:  c.Facets,
+		Minify:  c.Minify,
+		Page:    c.Page,
+		Offset:  c.Offset,
+		Total:   c.Total,
+		Fetched: c.Fetched,
+		Done:    c.Done,
+	})
+}
+
+// UnmarshalJSON decodes the cursor from JSON. ErrInvalidCursor is returned if the cursor
+// was created by an incompatible version or holds an impossible position.
+func (c *SearchCursor) UnmarshalJSON(b []byte) error {
+	var decoded searchCursorJSON
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.Version != searchCursorVersion || decoded.Page < 1 || decoded.Offset < 0 || decoded.Fetched < 0 {
+		return ErrInvalidCursor
+	}
+
+	*c = SearchCursor{
+		Query:   decoded.Query,
+		Facets:  decoded.Facets,
+		Minify:  decoded.Minify,
+		Page:    decoded.Page,
+		Offset:  decoded.Offset,
+		Total:   decoded.Total,
+		Fetched: decoded.Fetched,
+		Done:    decoded.Done,
+	}
+
+	return nil
+}
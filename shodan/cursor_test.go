@@ -0,0 +1,146 @@
+package shodan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPagedSearch(t *testing.T, total, perPage int) *[]int {
+	pages := make([]int, 0)
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.Nil(t, err)
+		pages = append(pages, page)
+
+		matches := make([]*HostData, 0)
+		for i := 0; i < perPage; i++ {
+			matches = append(matches, &HostData{IP: fmt.Sprintf("10.0.%d.%d", page, i)})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"total": total, "matches": matches})
+	})
+
+	return &pages
+}
+
+func TestSearchCursor_MarshalJSON(t *testing.T) {
+	cursor := &SearchCursor{
+		Query:   "nginx",
+		Facets:  "country:5",
+		Minify:  true,
+		Page:    3,
+		Offset:  2,
+		Total:   300,
+		Fetched: 202,
+	}
+
+	b, err := json.Marshal(cursor)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"version": 1, "query": "nginx", "facets": "country:5", "minify": true, "page": 3,
+		"offset": 2, "total": 300, "fetched": 202}`, string(b))
+
+	decoded := new(SearchCursor)
+	assert.Nil(t, json.Unmarshal(b, decoded))
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestSearchCursor_UnmarshalJSON_invalid(t *testing.T) {
+	testCases := []string{
+		`{"version": 2, "query": "nginx", "page": 1}`,
+		`{"version": 1, "query": "nginx", "page": 0}`,
+		`{"version": 1, "query": "nginx", "page": 1, "offset": -1}`,
+		`{"query": "nginx", "page": 1}`,
+	}
+
+	for _, testCase := range testCases {
+		err := json.Unmarshal([]byte(testCase), new(SearchCursor))
+		assert.Equal(t, ErrInvalidCursor, err, testCase)
+	}
+}
+
+func TestClient_ResumeHostsForQuery(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := setUpPagedSearch(t, 300, 3)
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx", Minify: true})
+	for i := 0; i < 4; i++ {
+		assert.True(t, it.Next())
+	}
+
+	b, err := json.Marshal(it.Cursor())
+	assert.Nil(t, err)
+
+	cursor := new(SearchCursor)
+	assert.Nil(t, json.Unmarshal(b, cursor))
+	assert.Equal(t, 2, cursor.Page)
+	assert.Equal(t, 1, cursor.Offset)
+	assert.Equal(t, 4, cursor.Fetched)
+
+	resumed, err := client.ResumeHostsForQuery(context.Background(), "nginx", cursor)
+	assert.Nil(t, err)
+
+	ips := make([]string, 0)
+	for resumed.Next() {
+		ips = append(ips, resumed.Match().IP)
+	}
+
+	assert.Nil(t, resumed.Err())
+	assert.Equal(t, []string{"10.0.2.1", "10.0.2.2", "10.0.3.0", "10.0.3.1", "10.0.3.2"}, ips)
+	assert.Equal(t, []int{1, 2, 2, 3}, *pages)
+	assert.Equal(t, 9, resumed.Cursor().Fetched)
+	assert.True(t, resumed.Cursor().Done)
+}
+
+func TestClient_ResumeHostsForQuery_pageBoundary(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := setUpPagedSearch(t, 300, 2)
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+	assert.True(t, it.Next())
+	assert.True(t, it.Next())
+
+	cursor := it.Cursor()
+	assert.Equal(t, 2, cursor.Page)
+	assert.Equal(t, 0, cursor.Offset)
+
+	resumed, err := client.ResumeHostsForQuery(context.Background(), "nginx", cursor)
+	assert.Nil(t, err)
+	assert.True(t, resumed.Next())
+	assert.Equal(t, "10.0.2.0", resumed.Match().IP)
+	assert.Equal(t, []int{1, 2}, *pages)
+}
+
+func TestClient_ResumeHostsForQuery_done(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := setUpPagedSearch(t, 1, 1)
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+	assert.True(t, it.Next())
+
+	resumed, err := client.ResumeHostsForQuery(context.Background(), "nginx", it.Cursor())
+	assert.Nil(t, err)
+	assert.False(t, resumed.Next())
+	assert.Equal(t, []int{1}, *pages)
+}
+
+func TestClient_ResumeHostsForQuery_queryMismatch(t *testing.T) {
+	cursor := &SearchCursor{Query: "nginx", Page: 2}
+
+	_, err := client.ResumeHostsForQuery(context.Background(), "apache", cursor)
+	assert.Equal(t, ErrCursorQueryMismatch, err)
+
+	_, err = client.ResumeHostsForQuery(context.Background(), "apache", nil)
+	assert.Equal(t, ErrCursorQueryMismatch, err)
+}
@@ -0,0 +1,263 @@
+package shodan
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	datasetsPath = "/shodan/data"
+	datasetPath  = "/shodan/data/%s"
+)
+
+// Dataset is a Bulk Data dataset available for download.
+type Dataset struct {
+	Name        string `json:"name"`
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+}
+
+// DatasetFile is a single downloadable file of a dataset.
+type DatasetFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// Timestamp is the time the file was created at in milliseconds since the epoch.
+	Timestamp int64  `json:"timestamp"`
+	URL       string `json:"url"`
+	SHA1      string `json:"sha1"`
+}
+
+// DownloadOptions controls how a dataset file is downloaded.
+type DownloadOptions struct {
+	// Decompress makes the download write the decompressed content instead of the raw gzip file.
+	Decompress bool
+	// Progress is called as the file is downloaded with the number of bytes received so far and
+	// the size of the file (-1 when unknown). Both are the sizes of the compressed file regardless
+	// of Decompress.
+	Progress func(received, total int64)
+	// VerifyChecksum makes the download compare the SHA1 of the file with the one of the listing once it's
+	// been read, a *ChecksumMismatchError is returned when they differ. The checksum is the one of the
+	// gzip file as stored, so it's verified with Decompress too.
+	VerifyChecksum bool
+}
+
+// GetDatasets returns the list of the Bulk Data datasets the API key has access to.
+func (c *Client) GetDatasets(ctx context.Context) ([]*Dataset, error) {
+	url := c.buildBaseURL(datasetsPath, nil)
+
+	var datasets []*Dataset
+	err := c.executeRequest(ctx, "GET", url, &datasets, nil)
+
+	return datasets, err
+}
+
+// GetDatasetFiles returns the list of the files available for download in the dataset.
+func (c *Client) GetDatasetFiles(ctx context.Context, name string) ([]*DatasetFile, error) {
+	url := c.buildBaseURL(fmt.Sprintf(datasetPath, name), nil)
+
+	var files []*DatasetFile
+	err := c.executeRequest(ctx, "GET", url, &files, nil)
+
+	return files, err
+}
+
+// DownloadDatasetFile writes the dataset file to w and returns the number of bytes written. The files
+// are gzipped, so the raw file is written unless options.Decompress is set. Go's transparent
+// decompression is disabled for the download, so the raw file is never decompressed behind the
+// caller's back. The download goes through the rate limit, the retry policy and the hooks of the
+// client like the other requests.
+func (c *Client) DownloadDatasetFile(ctx context.Context, file *DatasetFile, w io.Writer, options *DownloadOptions) (int64, error) {
+	if options == nil {
+		options = new(DownloadOptions)
+	}
+
+	// Asking for gzip explicitly stops the transport from decoding the body.
+	header := http.Header{"Accept-Encoding": {"gzip"}}
+
+	// The response holds the length of the file for the progress.
+	response, _ := ctx.Value(responseKey{}).(*Response)
+	if response == nil {
+		response = new(Response)
+		ctx = WithResponse(ctx, response)
+	}
+
+	var n int64
+	err := c.performRequest(ctx, "GET", file.URL, nil, header, func(body io.Reader) error {
+		total := int64(-1)
+		if length, err := strconv.ParseInt(response.Header.Get("Content-Length"), 10, 64); err == nil {
+			total = length
+		} else if file.Size > 0 {
+			total = file.Size
+		}
+
+		var err error
+		n, err = copyDatasetFile(body, total, file, w, options)
+
+		return err
+	})
+
+	return n, err
+}
+
+// copyDatasetFile writes the downloaded dataset file to w as told by the options.
+func copyDatasetFile(res io.Reader, total int64, file *DatasetFile, w io.Writer, options *DownloadOptions) (int64, error) {
+	var raw io.Reader = &progressReader{reader: res, total: total, progress: options.Progress}
+
+	digest := sha1.New()
+	if options.VerifyChecksum {
+		raw = io.TeeReader(raw, digest)
+	}
+
+	body := raw
+	if options.Decompress {
+		gz, err := gzip.NewReader(raw)
+		if err != nil {
+			return 0, err
+		}
+
+		defer gz.Close()
+		body = gz
+	}
+
+	n, err := io.Copy(w, body)
+	if err != nil || !options.VerifyChecksum {
+		return n, err
+	}
+
+	// The decompression may stop short of the end of the file, the rest still counts.
+	if _, err := io.Copy(ioutil.Discard, raw); err != nil {
+		return n, err
+	}
+
+	if actual := hex.EncodeToString(digest.Sum(nil)); !strings.EqualFold(actual, file.SHA1) {
+		return n, &ChecksumMismatchError{Expected: file.SHA1, Actual: actual}
+	}
+
+	return n, nil
+}
+
+// DatasetBanners is a dataset file being downloaded and decoded, created by StreamDatasetFile.
+type DatasetBanners struct {
+	// Banners delivers the banners of the file in order. It's closed once the file has been read, the
+	// download failed or it's been stopped.
+	Banners <-chan *HostData
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Err returns the error that stopped the download before the end of the file, i.e. an APIError, a
+// *ChecksumMismatchError or the error of the context. It's nil while Banners is open.
+func (d *DatasetBanners) Err() error {
+	select {
+	case <-d.done:
+		return d.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the download and waits until Banners is closed. It's safe to call more than once.
+func (d *DatasetBanners) Close() {
+	d.cancel()
+	<-d.done
+}
+
+// StreamDatasetFile downloads the dataset file and decodes its banners as they arrive, so the file is
+// never held in memory. The file is decompressed when it's gzipped, and read as is otherwise. The banners
+// are decoded like the streamed ones, see WithFastDecoder. The Decompress option is ignored, the others
+// apply like with DownloadDatasetFile.
+func (c *Client) StreamDatasetFile(ctx context.Context, file *DatasetFile, options *DownloadOptions) *DatasetBanners {
+	var downloadOptions DownloadOptions
+	if options != nil {
+		downloadOptions = *options
+	}
+
+	downloadOptions.Decompress = false
+
+	ctx, cancel := context.WithCancel(ctx)
+	banners := make(chan *HostData)
+	download := &DatasetBanners{Banners: banners, cancel: cancel, done: make(chan struct{})}
+
+	reader, writer := io.Pipe()
+	downloaded := make(chan struct{})
+
+	go func() {
+		defer close(downloaded)
+
+		_, err := c.DownloadDatasetFile(ctx, file, writer, &downloadOptions)
+		writer.CloseWithError(err)
+	}()
+
+	go func() {
+		defer close(download.done)
+		defer close(banners)
+
+		download.err = c.decodeDatasetBanners(ctx, reader, banners)
+
+		// Stops the download when the decoding ended early.
+		cancel()
+		reader.Close()
+		<-downloaded
+	}()
+
+	return download
+}
+
+// decodeDatasetBanners sends the banners of the newline-delimited JSON to banners, r is decompressed
+// first if it starts with the gzip header.
+func (c *Client) decodeDatasetBanners(ctx context.Context, r io.Reader, banners chan<- *HostData) error {
+	buffered := bufio.NewReader(r)
+
+	var body io.Reader = buffered
+	if magic, _ := buffered.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+
+		defer gz.Close()
+		body = gz
+	}
+
+	reader := NewBannerReader(body)
+	reader.decode = c.decodeBannerBytes
+
+	for reader.Next() {
+		select {
+		case banners <- reader.Banner():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return reader.Err()
+}
+
+// progressReader reports the number of bytes read through it.
+type progressReader struct {
+	reader   io.Reader
+	received int64
+	total    int64
+	progress func(received, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 && r.progress != nil {
+		r.received += int64(n)
+		r.progress(r.received, r.total)
+	}
+
+	return n, err
+}
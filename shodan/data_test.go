@@ -0,0 +1,312 @@
+package shodan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetDatasets(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(datasetsPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		w.Write(getStub(t, "data/datasets"))
+	})
+
+	datasets, err := client.GetDatasets(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, datasets, 2)
+	assert.Equal(t, &Dataset{
+		Name:        "raw-daily",
+		Scope:       "monthly",
+		Description: "Data files containing all the information collected during a day",
+	}, datasets[0])
+}
+
+func TestClient_GetDatasetFiles(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(fmt.Sprintf(datasetPath, "raw-daily"), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		w.Write(getStub(t, "data/dataset"))
+	})
+
+	files, err := client.GetDatasetFiles(context.Background(), "raw-daily")
+
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, &DatasetFile{
+		Name:      "2021-03-01.json.gz",
+		Size:      42094431302,
+		Timestamp: 1614643200000,
+		URL:       "https://data.shodan.io/raw-daily/2021-03-01.json.gz?signature=abc",
+		SHA1:      "4c0b8a8bd3b0a6e3b8b1c2a9b1d7e2f6e1b4a2c3",
+	}, files[0])
+}
+
+func gzipBytes(t *testing.T, content string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(content))
+	assert.Nil(t, err)
+	assert.Nil(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func TestClient_DownloadDatasetFile(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	content := strings.Repeat(`{"ip_str": "1.1.1.1", "port": 80}`+"\n", 1000)
+	compressed := gzipBytes(t, content)
+
+	for _, contentEncoding := range []string{"", "gzip"} {
+		mux := http.NewServeMux()
+		server.Config.Handler = mux
+		mux.HandleFunc("/raw-daily/2021-03-01.json.gz", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+			if contentEncoding != "" {
+				w.Header().Set("Content-Encoding", contentEncoding)
+			}
+
+			w.Header().Set("Content-Length", strconv.Itoa(len(compressed)))
+			w.Write(compressed)
+		})
+
+		file := &DatasetFile{URL: server.URL + "/raw-daily/2021-03-01.json.gz"}
+
+		for _, decompress := range []bool{false, true} {
+			var received, total int64
+			var out bytes.Buffer
+			n, err := client.DownloadDatasetFile(context.Background(), file, &out, &DownloadOptions{
+				Decompress: decompress,
+				Progress: func(r, t int64) {
+					received, total = r, t
+				},
+			})
+
+			assert.Nil(t, err)
+			assert.Equal(t, int64(len(compressed)), received)
+			assert.Equal(t, int64(len(compressed)), total)
+			assert.Equal(t, int64(out.Len()), n)
+
+			if decompress {
+				assert.Equal(t, content, out.String())
+			} else {
+				assert.Equal(t, compressed, out.Bytes())
+			}
+		}
+	}
+}
+
+func TestClient_DownloadDatasetFile_error(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/expired.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Request has expired"))
+	})
+	mux.HandleFunc("/broken.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not gzipped"))
+	})
+
+	var out bytes.Buffer
+	_, err := client.DownloadDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/expired.json.gz"}, &out, nil)
+	assert.Equal(t, &APIError{
+		StatusCode: http.StatusForbidden,
+		Message:    "Request has expired",
+		Body:       []byte("Request has expired"),
+		Method:     "GET",
+		Endpoint:   "other",
+		Path:       "/expired.json.gz",
+	}, err)
+
+	_, err = client.DownloadDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/broken.json.gz"}, &out, &DownloadOptions{Decompress: true})
+	assert.Equal(t, gzip.ErrHeader, err)
+}
+
+func TestClient_DownloadDatasetFile_hooks(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/raw-daily/2021-03-01.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+		w.Write([]byte("banners"))
+	})
+
+	var accepted string
+	var status int
+	tracer := new(recordingTracer)
+	WithRequestHook(func(req *http.Request) { accepted = req.Header.Get("Accept-Encoding") })(client)
+	WithResponseHook(func(res *http.Response, elapsed time.Duration, err error) { status = res.StatusCode })(client)
+	WithTracer(tracer)(client)
+
+	var out bytes.Buffer
+	n, err := client.DownloadDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/raw-daily/2021-03-01.json.gz"}, &out, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), n)
+	assert.Equal(t, "banners", out.String())
+	assert.Equal(t, "gzip", accepted)
+	assert.Equal(t, http.StatusNonAuthoritativeInfo, status)
+	assert.Len(t, tracer.spans, 1)
+}
+
+func TestClient_DownloadDatasetFile_verifyChecksum(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	content := strings.Repeat(`{"ip_str": "1.1.1.1", "port": 80}`+"\n", 100)
+	compressed := gzipBytes(t, content)
+	sum := sha1.Sum(compressed)
+	checksum := hex.EncodeToString(sum[:])
+
+	// The modification time in the gzip header is changed, so the file still decompresses.
+	corrupted := append([]byte(nil), compressed...)
+	corrupted[4] ^= 0xff
+
+	mux.HandleFunc("/valid.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(compressed)
+	})
+	mux.HandleFunc("/corrupted.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(corrupted)
+	})
+
+	corruptedSum := sha1.Sum(corrupted)
+
+	for _, decompress := range []bool{false, true} {
+		options := &DownloadOptions{Decompress: decompress, VerifyChecksum: true}
+
+		var out bytes.Buffer
+		_, err := client.DownloadDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/valid.json.gz", SHA1: strings.ToUpper(checksum)}, &out, options)
+		assert.Nil(t, err)
+
+		out.Reset()
+		_, err = client.DownloadDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/corrupted.json.gz", SHA1: checksum}, &out, options)
+		assert.Equal(t, &ChecksumMismatchError{Expected: checksum, Actual: hex.EncodeToString(corruptedSum[:])}, err)
+		assert.True(t, errors.Is(err, ErrChecksumMismatch))
+
+		if decompress {
+			assert.Equal(t, content, out.String())
+		}
+	}
+}
+
+func TestClient_StreamDatasetFile(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	content := `{"ip_str": "1.1.1.1", "port": 80}` + "\n\n" + `{"ip_str": "8.8.8.8", "port": 53}`
+	mux.HandleFunc("/raw-daily/2021-03-01.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gzipBytes(t, content))
+	})
+	mux.HandleFunc("/raw-daily/2021-03-01.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	})
+
+	for _, name := range []string{"2021-03-01.json.gz", "2021-03-01.json"} {
+		download := client.StreamDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/raw-daily/" + name}, nil)
+
+		var ips []string
+		for banner := range download.Banners {
+			ips = append(ips, banner.IP)
+		}
+
+		assert.Nil(t, download.Err(), name)
+		assert.Equal(t, []string{"1.1.1.1", "8.8.8.8"}, ips, name)
+	}
+}
+
+func TestClient_StreamDatasetFile_fastDecoder(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithFastDecoder()(client)
+
+	content := `{"ip_str": "1.1.1.1", "port": 80}` + "\n" + `{"ip_str": "8.8.8.8",`
+	mux.HandleFunc("/raw-daily/2021-03-01.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gzipBytes(t, content))
+	})
+
+	download := client.StreamDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/raw-daily/2021-03-01.json.gz"}, nil)
+
+	var banners []*HostData
+	for banner := range download.Banners {
+		banners = append(banners, banner)
+	}
+
+	assert.Len(t, banners, 1)
+	assert.Equal(t, "1.1.1.1", banners[0].IP)
+	assert.ErrorIs(t, download.Err(), errInvalidJSON)
+	assert.EqualError(t, download.Err(), "line 2: invalid JSON")
+}
+
+func TestClient_StreamDatasetFile_error(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/expired.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Request has expired", http.StatusForbidden)
+	})
+	mux.HandleFunc("/malformed.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 80}`)
+		fmt.Fprintln(w, `{"ip_str": `)
+	})
+
+	download := client.StreamDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/expired.json.gz"}, nil)
+	_, open := <-download.Banners
+	assert.False(t, open)
+	var apiErr *APIError
+	assert.True(t, errors.As(download.Err(), &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+
+	download = client.StreamDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/malformed.json"}, nil)
+	count := 0
+	for range download.Banners {
+		count++
+	}
+
+	assert.Equal(t, 1, count)
+	assert.Contains(t, download.Err().Error(), "line 2")
+}
+
+func TestClient_StreamDatasetFile_close(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/endless.json", func(w http.ResponseWriter, r *http.Request) {
+		for r.Context().Err() == nil {
+			fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 80}`)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	download := client.StreamDatasetFile(context.Background(), &DatasetFile{URL: server.URL + "/endless.json"}, nil)
+	banner := <-download.Banners
+	assert.Equal(t, "1.1.1.1", banner.IP)
+
+	download.Close()
+	download.Close()
+
+	for range download.Banners {
+	}
+
+	assert.ErrorIs(t, download.Err(), context.Canceled)
+}
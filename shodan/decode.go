@@ -0,0 +1,110 @@
+package shodan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// decodeArrayField decodes a JSON object token by token handing every element of the array
+// stored under the key to fn, so the array never has to be held in memory as a whole. The rest
+// of the object is collected and returned as a separate JSON object.
+func decodeArrayField(decoder *json.Decoder, key string, fn func(*json.Decoder) error) ([]byte, error) {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	rest := bytes.NewBufferString("{")
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected token %v, expected object key", token)
+		}
+
+		if name == key {
+			if err := decodeArray(decoder, fn); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		encodedName, _ := json.Marshal(name)
+		if rest.Len() > 1 {
+			rest.WriteByte(',')
+		}
+
+		rest.Write(encodedName)
+		rest.WriteByte(':')
+		rest.Write(value)
+	}
+
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+
+	rest.WriteByte('}')
+
+	return rest.Bytes(), nil
+}
+
+// decodeArray hands every element of the JSON array to fn. A null array is treated as empty.
+func decodeArray(decoder *json.Decoder, fn func(*json.Decoder) error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token == nil {
+		return nil
+	}
+
+	if token != json.Delim('[') {
+		return fmt.Errorf("unexpected token %v, expected %v", token, json.Delim('['))
+	}
+
+	for decoder.More() {
+		if err := fn(decoder); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, ']')
+}
+
+// expectDelim reads the next token and makes sure it's the given delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("unexpected token %v, expected %v", token, delim)
+	}
+
+	return nil
+}
+
+// checkErrorField returns the error carried in the "error" field of the JSON object, if any.
+func checkErrorField(object []byte) error {
+	errorResponse := new(struct {
+		Error string `json:"error"`
+	})
+	if err := json.Unmarshal(object, errorResponse); err != nil || errorResponse.Error == "" {
+		return nil
+	}
+
+	return errors.New(errorResponse.Error)
+}
@@ -0,0 +1,91 @@
+package shodan
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup makes the concurrent calls with the same key share the result of the first one.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	body    []byte
+	err     error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[string]*flightCall)}
+}
+
+// do calls fn unless a call with the same key is in flight already, in which case it waits for
+// that call and returns its result. The returned body must not be modified.
+//
+// fn runs with a context carrying the values of ctx but not its cancellation, so a caller giving
+// up only returns the error of its own ctx and the others keep waiting. The call is cancelled once
+// every caller has given up.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+
+		go func() {
+			defer cancel()
+
+			call.body, call.err = fn(flightCtx)
+
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			close(call.done)
+		}()
+	}
+
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.body, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		if call.waiters--; call.waiters == 0 {
+			// nobody is interested in the result anymore, a later call starts a new flight
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+
+			call.cancel()
+		}
+		g.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// SetDeduplication makes identical GET requests issued concurrently share a single round trip to Shodan.
+// Every caller still gets its own copy of the decoded result, so they can't affect each other. Requests
+// with a body and streaming requests are never shared. A caller whose context is done stops waiting
+// without failing the request for the others, it's only cancelled once all of them have given up.
+// The deduplication is disabled by default.
+func (c *Client) SetDeduplication(enabled bool) {
+	if !enabled {
+		c.flights = nil
+		return
+	}
+
+	if c.flights == nil {
+		c.flights = newFlightGroup()
+	}
+}
@@ -0,0 +1,232 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SetDeduplication(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client.SetDeduplication(true)
+
+	var requests int64
+	ip := "8.8.8.8"
+	mux.HandleFunc(hostPath+"/"+ip, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write(getStub(t, "host/host"))
+	})
+
+	hosts := make([]*Host, 5)
+
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			hosts[i], err = client.GetServicesForHost(context.Background(), ip, nil)
+			assert.Nil(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+
+	hosts[0].Data[0].Product = "changed"
+	hosts[0].Hostnames[0] = "changed"
+
+	for _, host := range hosts[1:] {
+		assert.False(t, host == hosts[0])
+		assert.Equal(t, "", host.Data[0].Product)
+		assert.Equal(t, "dns.google", host.Hostnames[0])
+	}
+
+	_, err := client.GetServicesForHost(context.Background(), ip, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&requests))
+}
+
+func TestClient_SetDeduplication_sharedError(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client.SetDeduplication(true)
+
+	var requests int64
+	mux.HandleFunc(portsPath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		time.Sleep(100 * time.Millisecond)
+		http.Error(w, `{"error": "Invalid API key"}`, http.StatusUnauthorized)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := client.GetPorts(context.Background())
+			assert.NotNil(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+}
+
+func TestClient_SetDeduplication_mutatingRequests(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client.SetDeduplication(true)
+
+	var requests int64
+	mux.HandleFunc(scanPath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, `{"id": "BOMA59VSGWX8QJR9", "count": 1, "credits_left": 10}`)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := client.Scan(context.Background(), []string{"8.8.8.8"})
+			assert.Nil(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requests))
+}
+
+func TestClient_SetDeduplication_disabled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var requests int64
+	mux.HandleFunc(portsPath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write(getStub(t, "ports"))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := client.GetPorts(context.Background())
+			assert.Nil(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requests))
+	client.SetDeduplication(false)
+	assert.Nil(t, client.flights)
+}
+
+func TestClient_SetDeduplication_cancelledCaller(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client.SetDeduplication(true)
+
+	var requests int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux.HandleFunc(portsPath, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) == 1 {
+			close(started)
+		}
+
+		<-release
+		w.Write(getStub(t, "ports"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := client.GetPorts(ctx)
+		cancelled <- err
+	}()
+
+	<-started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ports, err := client.GetPorts(context.Background())
+			assert.Nil(t, err)
+			assert.NotEmpty(t, ports)
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		client.flights.mu.Lock()
+		defer client.flights.mu.Unlock()
+
+		for _, call := range client.flights.calls {
+			if call.waiters == 3 {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requests))
+}
+
+func TestClient_SetDeduplication_allCancelled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client.SetDeduplication(true)
+
+	aborted := make(chan struct{})
+	mux.HandleFunc(portsPath, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(aborted)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetPorts(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the shared request hasn't been cancelled")
+	}
+}
@@ -1,44 +1,176 @@
 package shodan
 
 import (
+	"context"
+	"fmt"
 	"net"
 	"strings"
+	"time"
 )
 
 const (
 	resolvePath = "/dns/resolve"
 	reversePath = "/dns/reverse"
+	domainPath  = "/dns/domain/%s"
 )
 
-// GetDNSResolve looks up the IP address for the provided list of hostnames
-func (c *Client) GetDNSResolve(hostnames []string) (map[string]*string, error) {
+// DNSAPI is the part of the client resolving the hostnames and the IP addresses.
+type DNSAPI interface {
+	GetDNSResolve(ctx context.Context, hostnames []string) (map[string]*net.IP, error)
+	GetDNSReverse(ctx context.Context, ips []net.IP) (map[string]*[]string, error)
+	GetDomain(ctx context.Context, domain string, options *DomainOptions) (*DomainInfo, error)
+}
+
+var _ DNSAPI = (*Client)(nil)
+
+// GetDNSResolve looks up the IP address for the provided list of hostnames, the hostnames that
+// don't resolve are mapped to nil.
+// It's a part of DNSAPI.
+func (c *Client) GetDNSResolve(ctx context.Context, hostnames []string) (map[string]*net.IP, error) {
 	url := c.buildBaseURL(resolvePath, struct {
 		Hostnames string `url:"hostnames"`
 	}{strings.Join(hostnames, ",")})
 
-	dnsResolved := make(map[string]*string)
-	err := c.executeRequest("GET", url, &dnsResolved, nil)
+	dnsResolved := make(map[string]*net.IP)
+	err := c.executeRequest(ctx, "GET", url, &dnsResolved, nil)
 
 	return dnsResolved, err
 }
 
-// GetDNSReverse looks up the hostnames that have been defined for the given list of IP addresses
-func (c *Client) GetDNSReverse(ip []string) (map[string]*[]string, error) {
-	for _, ipAddress := range ip {
-		if parsedIP := net.ParseIP(ipAddress); parsedIP == nil {
+// GetDNSReverse looks up the hostnames that have been defined for the given list of IP addresses.
+// The result is keyed by the string form of the IPs, the IPs without hostnames are mapped to nil.
+// It's a part of DNSAPI.
+func (c *Client) GetDNSReverse(ctx context.Context, ips []net.IP) (map[string]*[]string, error) {
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if ip.To16() == nil {
 			return nil, &net.ParseError{
 				Type: "IP address",
-				Text: ipAddress,
+				Text: ip.String(),
 			}
 		}
+
+		addresses = append(addresses, ip.String())
 	}
 
 	url := c.buildBaseURL(reversePath, struct {
 		IP string `url:"ips"`
-	}{strings.Join(ip, ",")})
+	}{strings.Join(addresses, ",")})
 
 	dnsReversed := make(map[string]*[]string)
-	err := c.executeRequest("GET", url, &dnsReversed, nil)
+	err := c.executeRequest(ctx, "GET", url, &dnsReversed, nil)
 
 	return dnsReversed, err
 }
+
+// DomainOptions is options for GetDomain.
+type DomainOptions struct {
+	// History includes the DNS records that are no longer live.
+	History bool `url:"history,omitempty"`
+	// Type only returns the records of the type, i.e. "A" or "MX".
+	Type string `url:"type,omitempty"`
+	Page int    `url:"page,omitempty"`
+}
+
+// DomainRecord is a DNS record of the domain or one of its subdomains.
+type DomainRecord struct {
+	// Subdomain is empty for the records of the domain itself.
+	Subdomain string `json:"subdomain"`
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	LastSeen  Time   `json:"last_seen"`
+}
+
+// DomainInfo is the subdomains and the DNS records of a domain.
+type DomainInfo struct {
+	Domain     string          `json:"domain"`
+	Tags       []string        `json:"tags"`
+	Subdomains []string        `json:"subdomains"`
+	Data       []*DomainRecord `json:"data"`
+	// More is set when there are more pages of records.
+	More bool `json:"more"`
+}
+
+// ActiveRecords returns the records seen within maxAge before asOf, so the historical records are left out.
+// A record listed more than once, which happens with the history included, is returned once with the most
+// recent LastSeen. The records keep the order of their first occurrence.
+func (d *DomainInfo) ActiveRecords(asOf time.Time, maxAge time.Duration) []*DomainRecord {
+	since := asOf.Add(-maxAge)
+
+	records := make([]*DomainRecord, 0)
+	seen := make(map[DomainRecord]int)
+	for _, record := range d.Data {
+		if record.LastSeen.Before(since) || record.LastSeen.After(asOf) {
+			continue
+		}
+
+		key := DomainRecord{Subdomain: record.Subdomain, Type: record.Type, Value: record.Value}
+		if i, ok := seen[key]; ok {
+			if record.LastSeen.After(records[i].LastSeen.Time) {
+				records[i] = record
+			}
+
+			continue
+		}
+
+		seen[key] = len(records)
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// GetDomain returns the subdomains and the DNS records of the domain.
+// It's a part of DNSAPI.
+func (c *Client) GetDomain(ctx context.Context, domain string, options *DomainOptions) (*DomainInfo, error) {
+	url := c.buildBaseURL(fmt.Sprintf(domainPath, domain), options)
+
+	var info DomainInfo
+	if err := c.executeRequest(ctx, "GET", url, &info, nil); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// GetDomainAll returns the subdomains and the DNS records of the domain fetching the pages of records
+// one after another while Shodan says there are more, starting at the page of the options. The records
+// of all the pages are merged into one DomainInfo.
+// Every page costs a query credit.
+func (c *Client) GetDomainAll(ctx context.Context, domain string, options *DomainOptions) (*DomainInfo, error) {
+	pageOptions := DomainOptions{Page: 1}
+	if options != nil {
+		pageOptions = *options
+		if pageOptions.Page < 1 {
+			pageOptions.Page = 1
+		}
+	}
+
+	var all *DomainInfo
+	subdomains := make(map[string]bool)
+	for {
+		info, err := c.GetDomain(ctx, domain, &pageOptions)
+		if err != nil {
+			return all, err
+		}
+
+		if all == nil {
+			all = &DomainInfo{Domain: info.Domain, Tags: info.Tags, Subdomains: []string{}, Data: []*DomainRecord{}}
+		}
+
+		for _, subdomain := range info.Subdomains {
+			if !subdomains[subdomain] {
+				subdomains[subdomain] = true
+				all.Subdomains = append(all.Subdomains, subdomain)
+			}
+		}
+
+		all.Data = append(all.Data, info.Data...)
+
+		if !info.More || len(info.Data) == 0 {
+			return all, nil
+		}
+
+		pageOptions.Page++
+	}
+}
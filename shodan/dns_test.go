@@ -1,33 +1,25 @@
-package shodan
+package shodan_test
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
-	"strings"
 	"testing"
+	"time"
 
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
 	"github.com/stretchr/testify/assert"
-	"net"
 )
 
 func TestClient_GetDNSResolve(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
+	server := shodantest.NewServer()
+	defer server.Close()
 
 	expectedHostnames := []string{"google.com", "bing.com", "idonotexist.local"}
 
-	mux.HandleFunc(resolvePath, func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "GET", r.Method)
-
-		hostnames := r.URL.Query().Get("hostnames")
-		assert.NotEmpty(t, hostnames)
-
-		splited := strings.Split(hostnames, ",")
-		assert.Len(t, splited, len(expectedHostnames))
-
-		w.Write(getStub(t, "dns_resolve"))
-	})
-
-	resolve, err := client.GetDNSResolve(expectedHostnames)
+	resolve, err := server.Client().GetDNSResolve(context.Background(), expectedHostnames)
 
 	assert.Nil(t, err)
 	assert.Len(t, resolve, len(expectedHostnames))
@@ -36,42 +28,138 @@ func TestClient_GetDNSResolve(t *testing.T) {
 		_, ok := resolve[host]
 		assert.True(t, ok)
 	}
+
+	assert.Equal(t, "74.125.227.163", resolve["google.com"].String())
+	assert.Nil(t, resolve["idonotexist.local"])
 }
 
 func TestClient_GetDNSReverse(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
-
-	expectedIPs := []string{"74.125.227.244", "92.63.108.40"}
+	server := shodantest.NewServer()
+	defer server.Close()
 
-	mux.HandleFunc(reversePath, func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "GET", r.Method)
+	expectedIPs := []net.IP{net.ParseIP("74.125.227.244"), net.ParseIP("92.63.108.40"), net.ParseIP("192.0.2.1")}
 
-		ips := r.URL.Query().Get("ips")
-		assert.NotEmpty(t, ips)
-
-		splited := strings.Split(ips, ",")
-		assert.Len(t, splited, len(expectedIPs))
-
-		w.Write(getStub(t, "dns_reverse"))
-	})
-
-	reversed, err := client.GetDNSReverse(expectedIPs)
+	reversed, err := server.Client().GetDNSReverse(context.Background(), expectedIPs)
 
 	assert.Nil(t, err)
 	assert.Len(t, reversed, len(expectedIPs))
 
 	for _, ip := range expectedIPs {
-		_, ok := reversed[ip]
+		_, ok := reversed[ip.String()]
 		assert.True(t, ok)
 	}
+
+	assert.Equal(t, []string{"free.msk.ispsystem.net"}, *reversed["92.63.108.40"])
+	assert.Nil(t, reversed["192.0.2.1"])
 }
 
 func TestClient_GetDNSReverse_invalidIP(t *testing.T) {
-	client := NewClient(nil, testClientToken)
-	_, err := client.GetDNSReverse([]string{"74.125.227", "63.11", "2747393"})
+	client := shodan.NewClient(nil, shodantest.Token)
+	_, err := client.GetDNSReverse(context.Background(), []net.IP{net.ParseIP("8.8.8.8"), {74, 125, 227}})
 
 	assert.NotNil(t, err)
 	_, ok := err.(*net.ParseError)
 	assert.True(t, ok)
 }
+
+func TestClient_GetDomain(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	info, err := server.Client().GetDomain(context.Background(), "example.com", nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", info.Domain)
+	assert.Equal(t, []string{"www", "mail"}, info.Subdomains)
+	assert.Len(t, info.Data, 4)
+	assert.Equal(t, &shodan.DomainRecord{
+		Subdomain: "www",
+		Type:      "A",
+		Value:     "93.184.216.34",
+		LastSeen:  shodan.Time{Time: time.Date(2024, time.April, 30, 8, 15, 0, 0, time.UTC)},
+	}, info.Data[2])
+
+	_, err = server.Client().GetDomain(context.Background(), "unknown.org", nil)
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    "No information available for that domain.",
+		Body:       []byte(`{"error":"No information available for that domain."}`),
+		Method:     "GET",
+		Endpoint:   "/dns/domain/{domain}",
+		Path:       "/dns/domain/unknown.org",
+	}, err)
+}
+
+func TestClient_GetDomain_history(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	info, err := server.Client().GetDomain(context.Background(), "example.com", &shodan.DomainOptions{History: true})
+
+	assert.Nil(t, err)
+	assert.Len(t, info.Data, 7)
+
+	asOf := time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC)
+	active := info.ActiveRecords(asOf, 30*24*time.Hour)
+	assert.Len(t, active, 4)
+	for i, record := range active {
+		assert.Equal(t, info.Data[i], record)
+	}
+
+	all := info.ActiveRecords(asOf, 10*365*24*time.Hour)
+	assert.Len(t, all, 6)
+	assert.Equal(t, "2024-04-30T08:15:00Z", all[2].LastSeen.Format(time.RFC3339))
+	assert.Equal(t, "old", all[5].Subdomain)
+
+	assert.Empty(t, info.ActiveRecords(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), time.Hour))
+}
+
+func TestClient_GetDomainAll(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	var pages []string
+	server.Handle("/dns/domain/example.org", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+
+		switch page {
+		case "1":
+			fmt.Fprint(w, `{"domain": "example.org", "tags": ["spf"], "subdomains": ["www", "mail"], "more": true,
+				"data": [{"subdomain": "www", "type": "A", "value": "192.0.2.1"}]}`)
+		case "2":
+			fmt.Fprint(w, `{"domain": "example.org", "tags": ["spf"], "subdomains": ["mail", "vpn"], "more": false,
+				"data": [{"subdomain": "vpn", "type": "A", "value": "192.0.2.2"}, {"subdomain": "mail", "type": "MX", "value": "mx.example.org"}]}`)
+		}
+	})
+
+	info, err := server.Client().GetDomainAll(context.Background(), "example.org", &shodan.DomainOptions{Type: "A"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, pages)
+	assert.Equal(t, "example.org", info.Domain)
+	assert.Equal(t, []string{"spf"}, info.Tags)
+	assert.Equal(t, []string{"www", "mail", "vpn"}, info.Subdomains)
+	assert.Len(t, info.Data, 3)
+	assert.Equal(t, "mx.example.org", info.Data[2].Value)
+	assert.False(t, info.More)
+}
+
+func TestClient_GetDomainAll_failure(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	server.Handle("/dns/domain/example.org", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "3" {
+			http.Error(w, `{"error": "Insufficient query credits"}`, http.StatusPaymentRequired)
+			return
+		}
+
+		fmt.Fprint(w, `{"domain": "example.org", "more": true, "data": [{"type": "A", "value": "192.0.2.1"}]}`)
+	})
+
+	info, err := server.Client().GetDomainAll(context.Background(), "example.org", &shodan.DomainOptions{Page: 2})
+
+	assert.NotNil(t, err)
+	assert.Len(t, info.Data, 1)
+}
@@ -0,0 +1,144 @@
+package shodan
+
+import (
+	"net/url"
+	"strings"
+)
+
+// otherEndpoint is the endpoint of the requests not matching any known one.
+const otherEndpoint = "other"
+
+// endpoint is a known API endpoint, the segments of the template in braces match any value.
+// The operation is the logical name of the call, it depends on the method for the endpoints
+// shared by the REST and the streaming APIs.
+type endpoint struct {
+	template   string
+	operations map[string]string
+}
+
+func op(name string) map[string]string {
+	return map[string]string{"": name}
+}
+
+var endpoints = []endpoint{
+	{infoPath, op("shodan.api_info")},
+	{profilePath, op("shodan.account.profile")},
+	{resolvePath, op("shodan.dns.resolve")},
+	{reversePath, op("shodan.dns.reverse")},
+	{"/dns/domain/{domain}", op("shodan.dns.domain")},
+	{ipPath, op("shodan.tools.myip")},
+	{headersPath, op("shodan.tools.httpheaders")},
+	{portsPath, op("shodan.ports")},
+	{protocolsPath, op("shodan.protocols")},
+	{servicesPath, op("shodan.services")},
+	{hostCountPath, op("shodan.host.count")},
+	{hostSearchPath, op("shodan.host.search")},
+	{hostSearchTokensPath, op("shodan.host.search_tokens")},
+	{hostPath + "/{ip}", op("shodan.host.get")},
+	{alertsInfoListPath, op("shodan.alert.list")},
+	{alertTriggersPath, op("shodan.alert.triggers")},
+	{alertCreatePath, map[string]string{"POST": "shodan.alert.create", "": "shodan.stream.alerts"}},
+	{"/shodan/alert/{id}/info", op("shodan.alert.get")},
+	{"/shodan/alert/{id}", map[string]string{"DELETE": "shodan.alert.delete", "": "shodan.stream.alert"}},
+	{"/shodan/alert/{id}/notifier/{notifier}", map[string]string{"DELETE": "shodan.alert.notifier.remove", "": "shodan.alert.notifier.add"}},
+	{notifierPath, map[string]string{"POST": "shodan.notifier.create", "": "shodan.notifier.list"}},
+	{notifierProvidersPath, op("shodan.notifier.providers")},
+	{notifierPath + "/{id}", map[string]string{"PUT": "shodan.notifier.update", "DELETE": "shodan.notifier.delete", "": "shodan.notifier.get"}},
+	{scanPath, op("shodan.scan")},
+	{scanInternetPath, op("shodan.scan.internet")},
+	{scanPath + "/{id}", op("shodan.scan.status")},
+	{queryPath, op("shodan.query.list")},
+	{querySearchPath, op("shodan.query.search")},
+	{queryTagsPath, op("shodan.query.tags")},
+	{datasetsPath, op("shodan.data.list")},
+	{datasetsPath + "/{dataset}", op("shodan.data.files")},
+	{"/labs/honeyscore/{ip}", op("shodan.labs.honeyscore")},
+	{bannersPath, op("shodan.stream.banners")},
+	{"/shodan/ports/{ports}", op("shodan.stream.ports")},
+	{"/shodan/asn/{asn}", op("shodan.stream.asn")},
+	{"/shodan/countries/{countries}", op("shodan.stream.countries")},
+	{exploitSearchPath, op("shodan.exploits.search")},
+	{exploitCountPath, op("shodan.exploits.count")},
+}
+
+func lookupEndpoint(path string) *endpoint {
+	segments := strings.Split(path, "/")
+
+	for i := range endpoints {
+		if matchTemplate(strings.Split(endpoints[i].template, "/"), segments) {
+			return &endpoints[i]
+		}
+	}
+
+	return nil
+}
+
+func matchTemplate(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+
+	for i, segment := range template {
+		if strings.HasPrefix(segment, "{") {
+			if segments[i] == "" {
+				return false
+			}
+
+			continue
+		}
+
+		if segment != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// endpointTemplate returns the template of the endpoint the path belongs to.
+func endpointTemplate(path string) string {
+	if e := lookupEndpoint(path); e != nil {
+		return e.template
+	}
+
+	return otherEndpoint
+}
+
+// endpointOperation returns the logical name of the call, i.e. "shodan.host.search".
+func endpointOperation(method, path string) string {
+	e := lookupEndpoint(path)
+	if e == nil {
+		return "shodan." + otherEndpoint
+	}
+
+	if operation, ok := e.operations[method]; ok {
+		return operation
+	}
+
+	return e.operations[""]
+}
+
+// endpointPath returns the path of the request relative to the base URL it was built from, so the
+// endpoints are the same behind a path-prefixed proxy or under a custom exploit prefix.
+func (c *Client) endpointPath(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	path := parsed.Path
+	prefixLen := 0
+	for _, base := range []string{c.BaseURL, c.StreamBaseURL, c.exploitBaseURL()} {
+		baseURL, err := url.Parse(base)
+		if err != nil || baseURL.Host != parsed.Host {
+			continue
+		}
+
+		prefix := strings.TrimRight(baseURL.Path, "/")
+		if len(prefix) > prefixLen && strings.HasPrefix(parsed.Path, prefix+"/") {
+			path, prefixLen = strings.TrimPrefix(parsed.Path, prefix), len(prefix)
+		}
+	}
+
+	return path
+}
@@ -0,0 +1,100 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	testCases := map[string]string{
+		"/shodan/host/8.8.8.8":                "/shodan/host/{ip}",
+		"/shodan/host/search":                 "/shodan/host/search",
+		"/shodan/host/search/tokens":          "/shodan/host/search/tokens",
+		"/shodan/host/":                       otherEndpoint,
+		"/shodan/alert/ZZ4TDUUORVE1DIIP/info": "/shodan/alert/{id}/info",
+		"/shodan/alert/ZZ4TDUUORVE1DIIP":      "/shodan/alert/{id}",
+		"/shodan/alert/info":                  "/shodan/alert/info",
+		"/shodan/ports/22,80":                 "/shodan/ports/{ports}",
+		"/shodan/ports":                       "/shodan/ports",
+		"/search":                             "/search",
+		"/api/search":                         otherEndpoint,
+		"/api-info":                           "/api-info",
+		"/notifier/provider":                  "/notifier/provider",
+		"/notifier/lR8wU9QrA1w6bl6e":          "/notifier/{id}",
+		"/something/new":                      otherEndpoint,
+	}
+
+	for path, expected := range testCases {
+		assert.Equal(t, expected, endpointTemplate(path), path)
+	}
+}
+
+func TestEndpointOperation(t *testing.T) {
+	testCases := []struct {
+		method    string
+		path      string
+		operation string
+	}{
+		{"GET", "/shodan/host/search", "shodan.host.search"},
+		{"GET", "/shodan/host/8.8.8.8", "shodan.host.get"},
+		{"GET", "/shodan/host/count", "shodan.host.count"},
+		{"POST", "/shodan/alert", "shodan.alert.create"},
+		{"GET", "/shodan/alert", "shodan.stream.alerts"},
+		{"DELETE", "/shodan/alert/ZZ4TDUUORVE1DIIP", "shodan.alert.delete"},
+		{"GET", "/shodan/alert/ZZ4TDUUORVE1DIIP", "shodan.stream.alert"},
+		{"GET", "/shodan/alert/ZZ4TDUUORVE1DIIP/info", "shodan.alert.get"},
+		{"GET", "/shodan/ports/22,80", "shodan.stream.ports"},
+		{"POST", "/shodan/scan", "shodan.scan"},
+		{"PUT", "/notifier/lR8wU9QrA1w6bl6e", "shodan.notifier.update"},
+		{"PUT", "/shodan/alert/ZZ4TDUUORVE1DIIP/notifier/default", "shodan.alert.notifier.add"},
+		{"GET", "/something/new", "shodan.other"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.operation, endpointOperation(tc.method, tc.path), tc.method+" "+tc.path)
+	}
+}
+
+func TestClient_endpointPath(t *testing.T) {
+	client := NewClient(nil, testClientToken, WithExploitPathPrefix("/api/v2"))
+	client.BaseURL = "https://proxy.example.com/shodan-api/"
+	client.StreamBaseURL = "https://proxy.example.com/shodan-stream"
+
+	testCases := map[string]string{
+		"https://proxy.example.com/shodan-api/shodan/host/8.8.8.8?key=x": "/shodan/host/8.8.8.8",
+		"https://proxy.example.com/shodan-stream/shodan/banners":         "/shodan/banners",
+		"https://proxy.example.com/shodan-apiary/shodan/host/8.8.8.8":    "/shodan-apiary/shodan/host/8.8.8.8",
+		"https://exploits.shodan.io/api/v2/search?query=nginx":           "/search",
+		"https://exploits.shodan.io/api/search":                          "/api/search",
+		"https://api.shodan.io/shodan-api/api-info":                      "/shodan-api/api-info",
+	}
+
+	for rawURL, expected := range testCases {
+		assert.Equal(t, expected, client.endpointPath(rawURL), rawURL)
+	}
+}
+
+func TestClient_pathPrefixedBaseURL(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	metrics := new(recordingMetrics)
+	client = NewClient(nil, testClientToken, WithMetrics(metrics))
+	client.BaseURL = server.URL + "/shodan-api"
+
+	mux.HandleFunc("/shodan-api"+hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+	})
+
+	_, err := client.GetServicesForHost(context.Background(), "8.8.8.8", nil)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "/shodan/host/{ip}", apiErr.Endpoint)
+	assert.Equal(t, "/shodan-api/shodan/host/8.8.8.8", apiErr.Path)
+	assert.Equal(t, "/shodan/host/{ip}", metrics.counters[0].labels["endpoint"])
+}
@@ -0,0 +1,155 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// reverseDNSChunkSize is the number of IPs looked up by a single "/dns/reverse" call.
+const reverseDNSChunkSize = 100
+
+// WithReverseDNSResolver makes EnrichWithReverseDNS look the IPs up with the resolver instead of Shodan.
+func WithReverseDNSResolver(resolver *net.Resolver) ClientOption {
+	return func(c *Client) {
+		c.resolver = resolver
+	}
+}
+
+// EnrichWithReverseDNS looks up the hostnames the IPs of the matches currently resolve back to and
+// stores them in CurrentPTR, Hostnames is left as is. Every IP is looked up once through GetDNSReverse
+// in chunks of 100, or with the resolver set by WithReverseDNSResolver, running up to concurrency
+// lookups at once. The failed lookups leave CurrentPTR nil and don't stop the other ones, their errors
+// are returned together.
+func (c *Client) EnrichWithReverseDNS(ctx context.Context, matches []*HostData, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var errs []error
+	var ips []string
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if match == nil || seen[match.IP] {
+			continue
+		}
+
+		seen[match.IP] = true
+		if net.ParseIP(match.IP) == nil {
+			errs = append(errs, fmt.Errorf("reverse DNS of %q: %w", match.IP, &net.ParseError{Type: "IP address", Text: match.IP}))
+			continue
+		}
+
+		ips = append(ips, match.IP)
+	}
+
+	lookup := c.reverseDNSChunk
+	chunkSize := reverseDNSChunkSize
+	if c.resolver != nil {
+		lookup = c.reverseDNSResolver
+		chunkSize = 1
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	found := make(map[string][]string, len(ips))
+	sem := make(chan struct{}, concurrency)
+
+	for start := 0; start < len(ips); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ips) {
+			end = len(ips)
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+
+		wg.Add(1)
+		go func(chunk []string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			resolved, err := lookup(ctx, chunk)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for ip, hostnames := range resolved {
+				found[ip] = hostnames
+			}
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(ips[start:end])
+	}
+
+	wg.Wait()
+
+	for _, match := range matches {
+		if match == nil {
+			continue
+		}
+
+		if hostnames, ok := found[match.IP]; ok {
+			match.CurrentPTR = append([]string{}, hostnames...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reverseDNSChunk looks the IPs up with Shodan, an IP without hostnames gets an empty slice.
+func (c *Client) reverseDNSChunk(ctx context.Context, ips []string) (map[string][]string, error) {
+	parsed := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		parsed = append(parsed, net.ParseIP(ip))
+	}
+
+	reversed, err := c.GetDNSReverse(ctx, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("reverse DNS of %d IPs: %w", len(ips), err)
+	}
+
+	resolved := make(map[string][]string, len(ips))
+	for i, ip := range ips {
+		resolved[ip] = nil
+		if hostnames := reversed[parsed[i].String()]; hostnames != nil {
+			resolved[ip] = *hostnames
+		}
+	}
+
+	return resolved, nil
+}
+
+// reverseDNSResolver looks the IPs up with the resolver, an IP without PTR records gets an empty slice.
+func (c *Client) reverseDNSResolver(ctx context.Context, ips []string) (map[string][]string, error) {
+	resolved := make(map[string][]string, len(ips))
+
+	var errs []error
+	for _, ip := range ips {
+		hostnames, err := c.resolver.LookupAddr(ctx, ip)
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			hostnames, err = nil, nil
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reverse DNS of %s: %w", ip, err))
+			continue
+		}
+
+		resolved[ip] = hostnames
+	}
+
+	return resolved, errors.Join(errs...)
+}
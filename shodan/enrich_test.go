@@ -0,0 +1,79 @@
+package shodan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_EnrichWithReverseDNS(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var calls int64
+	mux.HandleFunc(reversePath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+
+		ips := strings.Split(r.URL.Query().Get("ips"), ",")
+		assert.LessOrEqual(t, len(ips), reverseDNSChunkSize)
+
+		reversed := make(map[string][]string)
+		for _, ip := range ips {
+			if ip == "10.0.1.20" {
+				http.Error(w, `{"error": "Internal error"}`, http.StatusInternalServerError)
+				return
+			}
+
+			if ip != "10.0.0.7" {
+				reversed[ip] = []string{"ptr-" + ip + ".example.com"}
+			}
+		}
+
+		assert.Nil(t, json.NewEncoder(w).Encode(reversed))
+	})
+
+	var matches []*HostData
+	for i := 0; i < 150; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/100, i%100)
+		matches = append(matches,
+			&HostData{IP: ip, Hostnames: []string{"indexed.example.com"}},
+			&HostData{IP: ip, Port: 443})
+	}
+	matches = append(matches, &HostData{IP: "10.0.1"}, nil)
+
+	err := client.EnrichWithReverseDNS(context.Background(), matches, 4)
+
+	assert.Equal(t, int64(2), atomic.LoadInt64(&calls))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "reverse DNS of 50 IPs")
+	assert.Contains(t, err.Error(), `reverse DNS of "10.0.1"`)
+
+	var parseErr *net.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.ErrorIs(t, err, ErrServerError)
+
+	assert.Equal(t, []string{"ptr-10.0.0.1.example.com"}, matches[2].CurrentPTR)
+	assert.Equal(t, []string{"ptr-10.0.0.1.example.com"}, matches[3].CurrentPTR)
+	assert.Equal(t, []string{"indexed.example.com"}, matches[2].Hostnames)
+	assert.Equal(t, []string{}, matches[14].CurrentPTR)
+	assert.Nil(t, matches[200].CurrentPTR)
+	assert.Nil(t, matches[300].CurrentPTR)
+}
+
+func TestClient_EnrichWithReverseDNS_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	matches := []*HostData{{IP: "10.0.0.1"}}
+	err := NewClient(nil, testClientToken).EnrichWithReverseDNS(ctx, matches, 1)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, matches[0].CurrentPTR)
+}
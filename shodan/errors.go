@@ -1,13 +1,368 @@
 package shodan
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
 var (
 	// ErrInvalidQuery is returned when query is not valid.
 	ErrInvalidQuery = errors.New("query is invalid")
 
+	// ErrInvalidTagsSize is returned when the number of query tags requested is out of range.
+	ErrInvalidTagsSize = errors.New("query tags size is invalid")
+
 	// ErrBodyRead is returned when response's body cannot be read.
 	ErrBodyRead = errors.New("could not read error response")
+
+	// ErrInvalidCursor is returned when a persisted search cursor cannot be decoded.
+	ErrInvalidCursor = errors.New("search cursor is invalid")
+
+	// ErrCursorQueryMismatch is returned when a search cursor is resumed with a different query.
+	ErrCursorQueryMismatch = errors.New("search cursor was created for another query")
+
+	// ErrInsufficientCredits is matched by InsufficientCreditsError when used with errors.Is, and by an
+	// APIError when Shodan refused the request because the account is out of credits.
+	ErrInsufficientCredits = errors.New("insufficient credits")
+
+	// ErrUnauthorized is matched by an APIError with the 401 status code when used with errors.Is.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrNotFound is matched by an APIError with the 404 status code when used with errors.Is.
+	ErrNotFound = errors.New("not found")
+
+	// ErrServerError is matched by an APIError with a 5xx status code when used with errors.Is.
+	ErrServerError = errors.New("server error")
+
+	// ErrUnreachable is matched by ConnectionError when used with errors.Is, unless it's a TLS failure.
+	ErrUnreachable = errors.New("host unreachable")
+
+	// ErrTLS is matched by ConnectionError when used with errors.Is if the TLS handshake failed.
+	ErrTLS = errors.New("TLS handshake failed")
+
+	// ErrRateLimited is matched by an APIError with the 429 status code when used with errors.Is.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrRetriesExhausted is matched by RetryError when used with errors.Is.
+	ErrRetriesExhausted = errors.New("retries exhausted")
+
+	// ErrFirehoseUnavailable is returned by PrecheckFirehose when the plan doesn't include the firehose.
+	ErrFirehoseUnavailable = errors.New("firehose is not available on the plan")
+
+	// ErrNotRateLimited is returned by WaitForRateLimit when there's no error to wait for.
+	ErrNotRateLimited = errors.New("not a rate limit error")
+
+	// ErrCannotEvaluateLocally is matched by RuleEvaluationError when used with errors.Is.
+	ErrCannotEvaluateLocally = errors.New("rule cannot be evaluated locally")
+
+	// ErrMonitoringQuotaExceeded is matched by MonitoringQuotaError when used with errors.Is.
+	ErrMonitoringQuotaExceeded = errors.New("monitored IPs quota exceeded")
+
+	// ErrSearchLimitReached is matched by SearchLimitError when used with errors.Is.
+	ErrSearchLimitReached = errors.New("search limit reached")
+
+	// ErrMalformedBanner is matched by MalformedBannerError when used with errors.Is.
+	ErrMalformedBanner = errors.New("malformed banner")
+
+	// ErrNotifierNotCreated is returned by CreateNotifier when Shodan doesn't report the ID of the notifier.
+	ErrNotifierNotCreated = errors.New("notifier was not created")
+
+	// ErrChecksumMismatch is matched by ChecksumMismatchError when used with errors.Is.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
 )
+
+// APIError is returned when Shodan responds with an unsuccessful status code.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Message is the error message sent by Shodan, it's the whole body when it's not JSON with
+	// the "error" field.
+	Message string
+
+	// Body is the raw body of the response, up to 64KB of it.
+	Body []byte
+
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Endpoint is the endpoint template of the request, i.e. "/shodan/host/{ip}".
+	Endpoint string
+
+	// Path is the actual path of the request, i.e. "/shodan/host/8.8.8.8". The query and so
+	// the API key is never included.
+	Path string
+
+	// RetryAfter is how long Shodan asked to wait before retrying, 0 if it didn't say.
+	RetryAfter time.Duration
+}
+
+// Error formats the error as "shodan: GET /shodan/host/{ip} -> 404: message". The endpoint
+// template is used, so the queried values don't end up in the logs.
+func (e *APIError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("shodan: %d: %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("shodan: %s %s -> %d: %s", e.Method, e.Endpoint, e.StatusCode, e.Message)
+}
+
+// Is reports whether the target is ErrUnauthorized, ErrNotFound, ErrRateLimited, ErrServerError or
+// ErrInsufficientCredits and the response matches it.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	case ErrInsufficientCredits:
+		return e.outOfCredits()
+	}
+
+	return false
+}
+
+// outOfCredits reports whether the response is Shodan refusing the request for a lack of credits, it
+// answers 402 for that on some endpoints and 401 or 403 with a message about the credits on the others.
+func (e *APIError) outOfCredits() bool {
+	switch e.StatusCode {
+	case http.StatusPaymentRequired:
+		return true
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return strings.Contains(strings.ToLower(e.Message), "credits")
+	}
+
+	return false
+}
+
+// RetryError is returned when a request still fails after all the retries of the RetryPolicy.
+type RetryError struct {
+	// Retries is the number of retries sent after the first attempt.
+	Retries int
+
+	// Err is the error of the last attempt, an APIError with one of the status codes of the policy.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s after %d retries: %s", ErrRetriesExhausted, e.Retries, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrRetriesExhausted.
+func (e *RetryError) Is(target error) bool {
+	return target == ErrRetriesExhausted
+}
+
+// MalformedBannerError is delivered to the errors channel of a stream for a banner that can't be decoded.
+type MalformedBannerError struct {
+	// Stream is the endpoint template of the stream, i.e. "/shodan/ports/{ports}".
+	Stream string
+
+	// Message is the raw message of the stream.
+	Message []byte
+
+	// Err is the decoding error.
+	Err error
+}
+
+func (e *MalformedBannerError) Error() string {
+	return fmt.Sprintf("%s on %s: %s", ErrMalformedBanner, e.Stream, e.Err)
+}
+
+func (e *MalformedBannerError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrMalformedBanner.
+func (e *MalformedBannerError) Is(target error) bool {
+	return target == ErrMalformedBanner
+}
+
+// InsufficientCreditsError is returned when the account can't afford an operation.
+type InsufficientCreditsError struct {
+	// Required is the amount of credits the operation needs.
+	Required CreditEstimate
+
+	// Available is the amount of credits left on the account.
+	Available CreditEstimate
+}
+
+// Shortfall returns the amount of credits missing to perform the operation.
+func (e *InsufficientCreditsError) Shortfall() CreditEstimate {
+	var shortfall CreditEstimate
+	if e.Required.QueryCredits > e.Available.QueryCredits {
+		shortfall.QueryCredits = e.Required.QueryCredits - e.Available.QueryCredits
+	}
+
+	if e.Required.ScanCredits > e.Available.ScanCredits {
+		shortfall.ScanCredits = e.Required.ScanCredits - e.Available.ScanCredits
+	}
+
+	return shortfall
+}
+
+func (e *InsufficientCreditsError) Error() string {
+	shortfall := e.Shortfall()
+
+	return fmt.Sprintf("%s: %d query and %d scan credits short",
+		ErrInsufficientCredits, shortfall.QueryCredits, shortfall.ScanCredits)
+}
+
+// Is reports whether the target is ErrInsufficientCredits.
+func (e *InsufficientCreditsError) Is(target error) bool {
+	return target == ErrInsufficientCredits
+}
+
+// ConnectionError is returned by Ping and PingStream when no response has been received from the host.
+type ConnectionError struct {
+	// Host is the host that couldn't be reached.
+	Host string
+
+	// Err is the error of the transport, it never contains the URL and so the API key.
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	if e.TLS() {
+		return fmt.Sprintf("shodan: TLS handshake with %s failed: %s", e.Host, e.Err)
+	}
+
+	return fmt.Sprintf("shodan: can't reach %s: %s", e.Host, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// TLS reports whether the connection failed because of the TLS handshake.
+func (e *ConnectionError) TLS() bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+	)
+
+	return errors.As(e.Err, &recordErr) || errors.As(e.Err, &verifyErr) || errors.As(e.Err, &authorityErr) ||
+		errors.As(e.Err, &invalidErr) || errors.As(e.Err, &hostnameErr)
+}
+
+// Is reports whether the target is ErrTLS for TLS failures or ErrUnreachable for the other ones.
+func (e *ConnectionError) Is(target error) bool {
+	switch target {
+	case ErrTLS:
+		return e.TLS()
+	case ErrUnreachable:
+		return !e.TLS()
+	}
+
+	return false
+}
+
+// MonitoringQuotaError is returned when an alert would monitor more IPs than the plan allows.
+type MonitoringQuotaError struct {
+	// Used is the number of IPs the existing alerts monitor.
+	Used int
+
+	// Requested is the number of IPs the new alert would add.
+	Requested int
+
+	// Limit is the number of IPs the plan allows to monitor.
+	Limit int
+}
+
+func (e *MonitoringQuotaError) Error() string {
+	return fmt.Sprintf("%s: %d IPs monitored, %d requested, %d allowed",
+		ErrMonitoringQuotaExceeded, e.Used, e.Requested, e.Limit)
+}
+
+// Is reports whether the target is ErrMonitoringQuotaExceeded.
+func (e *MonitoringQuotaError) Is(target error) bool {
+	return target == ErrMonitoringQuotaExceeded
+}
+
+// SearchLimit is the bound of SearchLimits that stopped SearchAll.
+type SearchLimit string
+
+// The bounds of SearchLimits.
+const (
+	SearchLimitPages   SearchLimit = "pages"
+	SearchLimitResults SearchLimit = "results"
+	SearchLimitCredits SearchLimit = "credits"
+)
+
+// SearchLimitError is returned by SearchAll when it stopped before the search ran out of matches.
+type SearchLimitError struct {
+	// Limit is the bound that has been reached and Bound is its value.
+	Limit SearchLimit
+	Bound int
+
+	// Pages, Results and Credits are the pages fetched, the matches collected and the query credits
+	// spent until then.
+	Pages   int
+	Results int
+	Credits int
+
+	// Total is the total number of results as reported by the last fetched page.
+	Total int
+}
+
+func (e *SearchLimitError) Error() string {
+	return fmt.Sprintf("%s: %d %s, %d of %d results fetched",
+		ErrSearchLimitReached, e.Bound, e.Limit, e.Results, e.Total)
+}
+
+// Is reports whether the target is ErrSearchLimitReached.
+func (e *SearchLimitError) Is(target error) bool {
+	return target == ErrSearchLimitReached
+}
+
+// ChecksumMismatchError is returned when a downloaded dataset file doesn't match the SHA1 of the listing.
+type ChecksumMismatchError struct {
+	// Expected is the digest of the listing.
+	Expected string
+
+	// Actual is the digest of the downloaded file.
+	Actual string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected sha1 %s, got %s", ErrChecksumMismatch, e.Expected, e.Actual)
+}
+
+// Is reports whether the target is ErrChecksumMismatch.
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// RuleEvaluationError is returned when a trigger rule uses a syntax MatchesTriggerRule doesn't support.
+type RuleEvaluationError struct {
+	// Rule is the rule being evaluated.
+	Rule string
+
+	// Filter is the part of the rule that isn't supported.
+	Filter string
+}
+
+func (e *RuleEvaluationError) Error() string {
+	return fmt.Sprintf("%s: unsupported filter %q in %q", ErrCannotEvaluateLocally, e.Filter, e.Rule)
+}
+
+// Is reports whether the target is ErrCannotEvaluateLocally.
+func (e *RuleEvaluationError) Is(target error) bool {
+	return target == ErrCannotEvaluateLocally
+}
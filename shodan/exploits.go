@@ -1,5 +1,12 @@
 package shodan
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
 type (
 	// ExploitSource is the name of the data source.
 	ExploitSource string
@@ -90,8 +97,8 @@ const (
 
 // Exploit represents the normalized data from a variety of vulnerability data sources.
 type Exploit struct {
-	// Unique ID for the exploit/ vulnerability (integer or string)
-	ID interface{} `json:"_id"`
+	// Unique ID for the exploit/ vulnerability
+	ID ExploitID `json:"_id"`
 
 	// An array of Bugtraq IDs that reference this vulnerability
 	BID []int `json:"bid"`
@@ -102,8 +109,8 @@ type Exploit struct {
 	// An array of Microsoft Security Bulletin reference IDs for this exploit
 	MSB []string `json:"msb"`
 
-	// An array (integer or string) of OSVDB IDs that are relevant to this exploit
-	OSVDB []interface{} `json:"osvdb"`
+	// An array of OSVDB IDs that are relevant to this exploit
+	OSVDB []ExploitID `json:"osvdb"`
 
 	// A description explaining the details of the exploit
 	Description string `json:"description"`
@@ -117,14 +124,14 @@ type Exploit struct {
 	// The actual code for the exploit
 	Code string `json:"code"`
 
-	// The timestamp for when the exploit was released in the UTC timezone. Example: "2014-01-15T05:49:56.283713"
-	Date string `json:"date"`
+	// The timestamp for when the exploit was released in the UTC timezone
+	Date Time `json:"date"`
 
-	// An array of platforms or a single platform that the exploit targets
-	Platform interface{} `json:"platform"`
+	// The platforms that the exploit targets
+	Platform ExploitPlatforms `json:"platform"`
 
-	// The port number for the affected service
-	Port int `json:"port"`
+	// The port numbers for the affected service
+	Port ExploitPorts `json:"port"`
 
 	// The type of exploit
 	Type ExploitType `json:"type"`
@@ -139,6 +146,108 @@ type Exploit struct {
 	Version string `json:"version"`
 }
 
+// ExploitID is the ID of an exploit. The sources report it either as a number or as a string, it's
+// always encoded back as a string.
+type ExploitID string
+
+// UnmarshalJSON decodes the ID from both JSON strings and numbers.
+func (id *ExploitID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*id = ExploitID(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+
+	*id = ExploitID(n)
+
+	return nil
+}
+
+// ExploitPlatforms is the platforms an exploit targets. The sources report either a single platform or
+// a list of them, it's always encoded back as a list.
+type ExploitPlatforms []ExploitPlatform
+
+// UnmarshalJSON decodes the platforms from both a JSON string and a list of strings.
+func (p *ExploitPlatforms) UnmarshalJSON(b []byte) error {
+	return unmarshalScalarOrList(b, func(item json.RawMessage) error {
+		var platform ExploitPlatform
+		if err := json.Unmarshal(item, &platform); err != nil {
+			return err
+		}
+
+		if platform != "" {
+			*p = append(*p, platform)
+		}
+
+		return nil
+	}, func() { *p = nil })
+}
+
+// ExploitPorts is the ports of the services an exploit targets. The sources report either a single port
+// or a list of them, as numbers or as strings, it's always encoded back as a list of numbers.
+type ExploitPorts []int
+
+// UnmarshalJSON decodes the ports from both a JSON number or string and a list of them.
+func (p *ExploitPorts) UnmarshalJSON(b []byte) error {
+	return unmarshalScalarOrList(b, func(item json.RawMessage) error {
+		var s string
+		if err := json.Unmarshal(item, &s); err != nil {
+			var n json.Number
+			if err := json.Unmarshal(item, &n); err != nil {
+				return err
+			}
+
+			s = n.String()
+		}
+
+		if s == "" {
+			return nil
+		}
+
+		port, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+
+		*p = append(*p, port)
+
+		return nil
+	}, func() { *p = nil })
+}
+
+// unmarshalScalarOrList passes every item of a JSON list to decode, or the value itself when it's not a
+// list. reset is called first, null results in no items.
+func unmarshalScalarOrList(b []byte, decode func(json.RawMessage) error, reset func()) error {
+	reset()
+
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	if len(b) == 0 || b[0] != '[' {
+		return decode(b)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(b, &items); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := decode(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ExploitSearchOptions is options for exploit search query.
 type ExploitSearchOptions struct {
 	// Search query used to search the database of known exploits
@@ -160,7 +269,7 @@ type ExploitSearch struct {
 
 // SearchExploits searches across a variety of data sources for exploits and
 // use facets to get summary information.
-func (c *Client) SearchExploits(options *ExploitSearchOptions) (*ExploitSearch, error) {
+func (c *Client) SearchExploits(ctx context.Context, options *ExploitSearchOptions) (*ExploitSearch, error) {
 	if options == nil || options.Query == "" {
 		return nil, ErrInvalidQuery
 	}
@@ -168,14 +277,14 @@ func (c *Client) SearchExploits(options *ExploitSearchOptions) (*ExploitSearch,
 	url := c.buildExploitBaseURL(exploitSearchPath, options)
 
 	var found ExploitSearch
-	err := c.executeRequest("GET", url, &found, nil)
+	err := c.executeRequest(ctx, "GET", url, &found, nil)
 
 	return &found, err
 }
 
 // CountExploits behaves identical to the "/search" method with the difference
 // that it doesn't return any results.
-func (c *Client) CountExploits(options *ExploitSearchOptions) (*ExploitSearch, error) {
+func (c *Client) CountExploits(ctx context.Context, options *ExploitSearchOptions) (*ExploitSearch, error) {
 	if options == nil || options.Query == "" {
 		return nil, ErrInvalidQuery
 	}
@@ -183,7 +292,7 @@ func (c *Client) CountExploits(options *ExploitSearchOptions) (*ExploitSearch, e
 	url := c.buildExploitBaseURL(exploitCountPath, options)
 
 	var found ExploitSearch
-	err := c.executeRequest("GET", url, &found, nil)
+	err := c.executeRequest(ctx, "GET", url, &found, nil)
 
 	return &found, err
 }
@@ -1,6 +1,8 @@
 package shodan
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -8,13 +10,13 @@ import (
 )
 
 func TestClient_CountExploits_nilOptions(t *testing.T) {
-	_, err := client.CountExploits(nil)
+	_, err := client.CountExploits(context.Background(), nil)
 	assert.NotNil(t, err)
 	assert.EqualValues(t, ErrInvalidQuery, err)
 }
 
 func TestClient_CountExploits_emptyQuery(t *testing.T) {
-	_, err := client.CountExploits(&ExploitSearchOptions{})
+	_, err := client.CountExploits(context.Background(), &ExploitSearchOptions{})
 	assert.NotNil(t, err)
 	assert.EqualValues(t, ErrInvalidQuery, err)
 }
@@ -30,7 +32,7 @@ func TestClient_CountExploits_noFacets(t *testing.T) {
 
 	expectedExploitsCount := &ExploitSearch{Total: 40, Matches: []*Exploit{}}
 	options := &ExploitSearchOptions{Query: "port=22"}
-	exploitsCount, err := client.CountExploits(options)
+	exploitsCount, err := client.CountExploits(context.Background(), options)
 
 	assert.Nil(t, err)
 	assert.Equal(t, expectedExploitsCount, exploitsCount)
@@ -75,20 +77,65 @@ func TestClient_CountExploits_facets(t *testing.T) {
 		Query:  "type=exploit",
 		Facets: "platform,author",
 	}
-	exploitsCount, err := client.CountExploits(options)
+	exploitsCount, err := client.CountExploits(context.Background(), options)
 
 	assert.Nil(t, err)
 	assert.Equal(t, expectedExploitsCount, exploitsCount)
 }
 
 func TestClient_SearchExploits_nilOptions(t *testing.T) {
-	_, err := client.SearchExploits(nil)
+	_, err := client.SearchExploits(context.Background(), nil)
 	assert.NotNil(t, err)
 	assert.EqualValues(t, ErrInvalidQuery, err)
 }
 
 func TestClient_SearchExploits_emptyQuery(t *testing.T) {
-	_, err := client.SearchExploits(&ExploitSearchOptions{})
+	_, err := client.SearchExploits(context.Background(), &ExploitSearchOptions{})
 	assert.NotNil(t, err)
 	assert.EqualValues(t, ErrInvalidQuery, err)
 }
+
+func TestClient_SearchExploits(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(exploitSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "exim", r.URL.Query().Get("query"))
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		w.Write(getStub(t, "exploits/exploits_search"))
+	})
+
+	found, err := client.SearchExploits(context.Background(), &ExploitSearchOptions{Query: "exim", Page: 2})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, found.Total)
+	assert.Len(t, found.Matches, 3)
+
+	exploitDB, metasploit, cve := found.Matches[0], found.Matches[1], found.Matches[2]
+
+	assert.Equal(t, ExploitID("16925"), exploitDB.ID)
+	assert.Equal(t, ExploitSourceExploitDB, exploitDB.Source)
+	assert.Equal(t, ExploitPlatforms{"linux"}, exploitDB.Platform)
+	assert.Equal(t, ExploitPorts{25}, exploitDB.Port)
+
+	assert.Equal(t, ExploitID("exploit/unix/smtp/exim4_string_format"), metasploit.ID)
+	assert.Equal(t, ExploitSourceMetasploit, metasploit.Source)
+	assert.Equal(t, ExploitPlatforms{"unix", "linux"}, metasploit.Platform)
+	assert.Equal(t, ExploitPorts{25, 465}, metasploit.Port)
+	assert.Equal(t, []int{45308}, metasploit.BID)
+	assert.Equal(t, []ExploitID{"69685", "69860"}, metasploit.OSVDB)
+
+	assert.Equal(t, ExploitID("CVE-2010-4344"), cve.ID)
+	assert.Equal(t, ExploitSourceCVE, cve.Source)
+	assert.Nil(t, cve.Platform)
+	assert.Nil(t, cve.Port)
+}
+
+func TestExploit_UnmarshalJSON_invalid(t *testing.T) {
+	var exploit Exploit
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"_id": true}`), &exploit))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"port": ["ssh"]}`), &exploit))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"platform": [1]}`), &exploit))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"osvdb": [{}]}`), &exploit))
+}
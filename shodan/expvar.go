@@ -0,0 +1,57 @@
+package shodan
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+)
+
+// expvarNames guards the publishing of the client stats, expvar panics on a name used twice.
+var expvarNames sync.Mutex
+
+// clientVars are the stats of a client published with expvar.
+type clientVars struct {
+	requests       expvar.Int
+	errors         expvar.Map
+	retries        expvar.Int
+	rateLimitWaits expvar.Int
+	activeStreams  expvar.Int
+	streamMessages expvar.Int
+}
+
+// WithExpvar publishes the stats of the client with expvar under the prefix, so they are served on
+// /debug/vars: "requests", "errors" by class ("4xx", "5xx" or "error" for the transport failures),
+// "retries", "rate_limit_waits", "active_streams" and "stream_messages". When the prefix is already
+// taken, i.e. by another client, an instance id is appended to it like "shodan.2".
+func WithExpvar(prefix string) ClientOption {
+	return func(c *Client) {
+		vars := new(clientVars)
+		vars.errors.Init()
+
+		stats := new(expvar.Map).Init()
+		stats.Set("requests", &vars.requests)
+		stats.Set("errors", &vars.errors)
+		stats.Set("retries", &vars.retries)
+		stats.Set("rate_limit_waits", &vars.rateLimitWaits)
+		stats.Set("active_streams", &vars.activeStreams)
+		stats.Set("stream_messages", &vars.streamMessages)
+
+		expvarNames.Lock()
+		defer expvarNames.Unlock()
+
+		name := prefix
+		for instance := 2; expvar.Get(name) != nil; instance++ {
+			name = prefix + "." + strconv.Itoa(instance)
+		}
+
+		expvar.Publish(name, stats)
+		c.vars = vars
+		c.varsName = name
+	}
+}
+
+// ExpvarName returns the name the stats of the client are published under, it's empty when
+// WithExpvar is not used.
+func (c *Client) ExpvarName() string {
+	return c.varsName
+}
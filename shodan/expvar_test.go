@@ -0,0 +1,121 @@
+package shodan
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readDebugVars(t *testing.T) map[string]json.RawMessage {
+	recorder := httptest.NewRecorder()
+	expvar.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/vars", nil))
+
+	vars := make(map[string]json.RawMessage)
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &vars))
+
+	return vars
+}
+
+func TestClient_WithExpvar(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client = NewClient(nil, testClientToken, WithExpvar("shodan_test_client"))
+	client.BaseURL = server.URL
+	client.StreamBaseURL = server.URL
+	client.SetRateLimit(10, 1)
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+	})
+	mux.HandleFunc(bannersPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 22}`)
+		fmt.Fprintln(w, `{"ip_str": "8.8.8.8", "port": 22}`)
+	})
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	_, err = client.GetServicesForHost(context.Background(), "1.1.1.1", nil)
+	assert.NotNil(t, err)
+
+	client.GetBanners(context.Background())
+	for range client.StreamChan {
+	}
+
+	assert.Equal(t, "shodan_test_client", client.ExpvarName())
+
+	var stats struct {
+		Requests       int            `json:"requests"`
+		Errors         map[string]int `json:"errors"`
+		Retries        int            `json:"retries"`
+		RateLimitWaits int            `json:"rate_limit_waits"`
+		ActiveStreams  int            `json:"active_streams"`
+		StreamMessages int            `json:"stream_messages"`
+	}
+
+	assert.Nil(t, json.Unmarshal(readDebugVars(t)["shodan_test_client"], &stats))
+	assert.Equal(t, 2, stats.Requests)
+	assert.Equal(t, map[string]int{"4xx": 1}, stats.Errors)
+	assert.Equal(t, 0, stats.Retries)
+	assert.Equal(t, 1, stats.RateLimitWaits)
+	assert.Equal(t, 0, stats.ActiveStreams)
+	assert.Equal(t, 2, stats.StreamMessages)
+}
+
+func TestClient_WithExpvar_retries(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	client = NewClient(nil, testClientToken, WithExpvar("shodan_test_retries"),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond}))
+	client.BaseURL = server.URL
+
+	hits := 0
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		if hits++; hits <= 2 {
+			http.Error(w, `{"error": "Service unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write(getStub(t, "info"))
+	})
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+
+	var stats struct {
+		Requests int            `json:"requests"`
+		Errors   map[string]int `json:"errors"`
+		Retries  int            `json:"retries"`
+	}
+
+	assert.Nil(t, json.Unmarshal(readDebugVars(t)["shodan_test_retries"], &stats))
+	assert.Equal(t, 3, stats.Requests)
+	assert.Equal(t, map[string]int{"5xx": 2}, stats.Errors)
+	assert.Equal(t, 2, stats.Retries)
+}
+
+func TestWithExpvar_sharedPrefix(t *testing.T) {
+	first := NewClient(nil, testClientToken, WithExpvar("shodan_shared"))
+	second := NewClient(nil, testClientToken, WithExpvar("shodan_shared"))
+	third := NewClient(nil, testClientToken, WithExpvar("shodan_shared"))
+
+	assert.Equal(t, "shodan_shared", first.ExpvarName())
+	assert.Equal(t, "shodan_shared.2", second.ExpvarName())
+	assert.Equal(t, "shodan_shared.3", third.ExpvarName())
+
+	vars := readDebugVars(t)
+	for _, name := range []string{"shodan_shared", "shodan_shared.2", "shodan_shared.3"} {
+		assert.Contains(t, vars, name)
+	}
+}
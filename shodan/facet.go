@@ -1,7 +1,127 @@
 package shodan
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 // Facet is a property to get summary information on.
 type Facet struct {
-	Count int    `json:"count"`
+	Count int `json:"count"`
+	// Value is the value of the property. Shodan sends the numeric ones, i.e. of the port facet, as
+	// numbers, they are kept as strings.
 	Value string `json:"value"`
 }
+
+// UnmarshalJSON decodes the facet taking both JSON strings and numbers as the value.
+func (f *Facet) UnmarshalJSON(b []byte) error {
+	var facet struct {
+		Count int             `json:"count"`
+		Value json.RawMessage `json:"value"`
+	}
+
+	if err := json.Unmarshal(b, &facet); err != nil {
+		return err
+	}
+
+	f.Count = facet.Count
+	f.Value = ""
+
+	if len(facet.Value) == 0 || string(facet.Value) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(facet.Value, &f.Value); err == nil {
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(facet.Value, &n); err != nil {
+		return err
+	}
+
+	f.Value = n.String()
+
+	return nil
+}
+
+// FacetRequest asks for the summary of a property, i.e. the top 10 countries.
+type FacetRequest struct {
+	Name string
+	// Count is the number of values returned, 0 means the Shodan default.
+	Count int
+}
+
+// EncodeFacets encodes the facets as the Facets of the search options expect, i.e. "country:10,org".
+func EncodeFacets(facets ...FacetRequest) string {
+	encoded := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		if facet.Count > 0 {
+			encoded = append(encoded, facet.Name+":"+strconv.Itoa(facet.Count))
+		} else {
+			encoded = append(encoded, facet.Name)
+		}
+	}
+
+	return strings.Join(encoded, ",")
+}
+
+// CountFacetsParallel gets the summary information for every facet with a separate "/shodan/host/count" request,
+// all of them sent concurrently under the rate limit of the client. Up to limit values are returned per facet,
+// 0 means the Shodan default. A failure of a single facet (i.e. the one not available on the plan) doesn't stop
+// the others: the facets that succeeded are returned along with the errors joined in the order of facet names.
+func (c *Client) CountFacetsParallel(ctx context.Context, query string, facets []string, limit int) (map[string][]*Facet, error) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   = make(map[string]error)
+		counts = make(map[string][]*Facet)
+	)
+
+	for _, facet := range facets {
+		wg.Add(1)
+		go func(facet string) {
+			defer wg.Done()
+
+			options := &HostQueryOptions{Query: query, Facets: EncodeFacets(FacetRequest{Name: facet, Count: limit})}
+
+			found, err := c.GetHostsCountForQuery(ctx, options)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs[facet] = fmt.Errorf("facet %s: %w", facet, err)
+				return
+			}
+
+			counts[facet] = found.Facets[facet]
+		}(facet)
+	}
+
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return counts, nil
+	}
+
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	joined := make([]error, 0, len(names))
+	for _, name := range names {
+		joined = append(joined, errs[name])
+	}
+
+	return counts, errors.Join(joined...)
+}
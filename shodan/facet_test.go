@@ -0,0 +1,99 @@
+package shodan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CountFacetsParallel(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostCountPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "nginx", r.URL.Query().Get("query"))
+
+		facet := strings.Split(r.URL.Query().Get("facets"), ":")
+		assert.Equal(t, []string{facet[0], "2"}, facet)
+
+		switch facet[0] {
+		case "vuln", "tag":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"error": "The %s facet is not available on your plan."}`, facet[0])
+		default:
+			fmt.Fprintf(w, `{"total": 10, "matches": [], "facets": {"%s": [{"count": 7, "value": "a"}, {"count": 3, "value": "b"}]}}`,
+				facet[0])
+		}
+	})
+
+	facets := []string{"vuln", "country", "tag", "org"}
+	counts, err := client.CountFacetsParallel(context.Background(), "nginx", facets, 2)
+
+	expectedFacets := []*Facet{{Count: 7, Value: "a"}, {Count: 3, Value: "b"}}
+	assert.Equal(t, map[string][]*Facet{"country": expectedFacets, "org": expectedFacets}, counts)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "facet tag: shodan: GET /shodan/host/count -> 403: The tag facet is not available on your plan.\n"+
+		"facet vuln: shodan: GET /shodan/host/count -> 403: The vuln facet is not available on your plan.", err.Error())
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+}
+
+func TestClient_CountFacetsParallel_noLimit(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostCountPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "port", r.URL.Query().Get("facets"))
+		fmt.Fprint(w, `{"total": 10, "facets": {"port": [{"count": 10, "value": "80"}]}}`)
+	})
+
+	counts, err := client.CountFacetsParallel(context.Background(), "nginx", []string{"port"}, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]*Facet{"port": {{Count: 10, Value: "80"}}}, counts)
+}
+
+func TestEncodeFacets(t *testing.T) {
+	assert.Equal(t, "", EncodeFacets())
+	assert.Equal(t, "org", EncodeFacets(FacetRequest{Name: "org"}))
+	assert.Equal(t, "country:10,org,port:5", EncodeFacets(
+		FacetRequest{Name: "country", Count: 10},
+		FacetRequest{Name: "org", Count: -1},
+		FacetRequest{Name: "port", Count: 5}))
+}
+
+func TestClient_GetHostsForQuery_numericFacets(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "port,country", r.URL.Query().Get("facets"))
+		fmt.Fprint(w, `{"total": 10, "matches": [], "facets": {
+			"port": [{"count": 7, "value": 80}, {"count": 3, "value": 443}],
+			"country": [{"count": 10, "value": "US"}, {"count": 0, "value": null}]
+		}}`)
+	})
+
+	found, err := client.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx", Facets: "port,country"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*Facet{{Count: 7, Value: "80"}, {Count: 3, Value: "443"}}, found.Facets["port"])
+	assert.Equal(t, []*Facet{{Count: 10, Value: "US"}, {}}, found.Facets["country"])
+}
+
+func TestFacet_UnmarshalJSON_invalid(t *testing.T) {
+	var facet Facet
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"count": 1, "value": true}`), &facet))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"count": "1", "value": "US"}`), &facet))
+}
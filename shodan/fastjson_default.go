@@ -0,0 +1,6 @@
+//go:build !shodan_fastjson
+
+package shodan
+
+// fastDecoderDefault tells whether the banners are decoded by the fast decoder by default.
+const fastDecoderDefault = false
@@ -0,0 +1,209 @@
+package shodan
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collectBanners returns every object in the JSON value that looks like a banner.
+func collectBanners(value interface{}, banners *[][]byte) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if _, ok := v["ip_str"]; ok {
+			if _, ok := v["port"]; ok {
+				b, _ := json.Marshal(v)
+				*banners = append(*banners, b)
+			}
+		}
+
+		for _, child := range v {
+			collectBanners(child, banners)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectBanners(child, banners)
+		}
+	}
+}
+
+func TestDecodeHostDataFast_stubs(t *testing.T) {
+	var banners [][]byte
+	err := filepath.Walk(stubsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err == nil {
+			collectBanners(value, &banners)
+		}
+
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, banners)
+
+	for _, banner := range banners {
+		var expected, actual HostData
+		assert.Nil(t, json.Unmarshal(banner, &expected))
+		assert.Nil(t, decodeHostDataFast(banner, &actual))
+		assert.Equal(t, expected, actual, string(banner))
+	}
+}
+
+func TestDecodeHostDataFast_edgeCases(t *testing.T) {
+	testCases := []string{
+		`null`,
+		`{}`,
+		`{"ip_str": "1.1.1.1", "port": 443, "version": 2.4}`,
+		`{"product": "nginx \"quoted\" é", "data": "HTTP/1.1 200 OK\r\n\r\n"}`,
+		"{\"title\": \"broken \xff utf8\"}",
+		`{"hostnames": [], "cpe": null, "domains": ["a.com", null, "b.com"]}`,
+		`{"port": null, "ip": -5, "product": null, "location": null}`,
+		`{"ip": 99999999999999999999}`,
+		`{"timestamp": "2021-01-02T03:04:05.123456", "version": null}`,
+		`{"timestamp": "2021-01-02 03:04:05+00:00"}`,
+		`{"timestamp": null, "location": {"city": "Zürich", "latitude": -47.5, "longitude": 8, "dma_code": 0, "area_code": null}}`,
+		`{"_shodan": {"module": "https", "options": {"hostname": "a.com"}}, "opts": {"vulns": []}, "unknown": [1, {"x": "}"}]}`,
+		`{"port": 1, "port": 2}`,
+		`{"data": "tab\t nl\n cr\r slash\/ bs\\ quote\" \b\f \u00e9\u20AC \ud83d\ude00 \ud83d lone"}`,
+		`{"opts": {"a": [true, false, null, -1.5e3, "x", {"b\u00e9": {}}], "c": []}, "_shodan": {"id": null}}`,
+		` { "port" : 80 , "transport" : "tcp" } `,
+		`{"ip_str": "1.1.1.1", "port": 53, "current_ptr": ["one.one.one.one"], "hostnames": ["one.one.one.one"]}`,
+		`{"current_ptr": []}`,
+		`{"current_ptr": null}`,
+	}
+
+	for _, testCase := range testCases {
+		var expected, actual HostData
+		expectedErr := json.Unmarshal([]byte(testCase), &expected)
+		actualErr := decodeHostDataFast([]byte(testCase), &actual)
+
+		assert.Equal(t, expectedErr == nil, actualErr == nil, testCase)
+		assert.Equal(t, expected, actual, testCase)
+	}
+}
+
+func TestDecodeHostDataFast_typeErrors(t *testing.T) {
+	testCases := []string{
+		`{"port": "443"}`,
+		`{"port": 1.5}`,
+		`{"product": 1}`,
+		`{"hostnames": "a.com"}`,
+		`{"hostnames": [1]}`,
+		`{"current_ptr": "one.one.one.one"}`,
+		`{"location": []}`,
+		`{"location": {"latitude": "1"}}`,
+		`{"timestamp": "yesterday"}`,
+		`{"version": true}`,
+		`{"opts": []}`,
+		`[]`,
+	}
+
+	for _, testCase := range testCases {
+		var expected, actual HostData
+		assert.NotNil(t, json.Unmarshal([]byte(testCase), &expected), testCase)
+		assert.NotNil(t, decodeHostDataFast([]byte(testCase), &actual), testCase)
+	}
+}
+
+func TestClient_WithFastDecoder(t *testing.T) {
+	assert.Equal(t, fastDecoderDefault, NewClient(nil, testClientToken).fastDecoder)
+	assert.True(t, NewClient(nil, testClientToken, WithFastDecoder()).fastDecoder)
+}
+
+func TestClient_decodeBannerBytes(t *testing.T) {
+	fast := NewClient(nil, testClientToken, WithFastDecoder())
+
+	var banner HostData
+	assert.Nil(t, fast.decodeBannerBytes([]byte(`{"ip_str": "1.1.1.1", "port": 80}`+"\n"), &banner))
+	assert.Equal(t, "1.1.1.1", banner.IP)
+	assert.Equal(t, errInvalidJSON, fast.decodeBannerBytes([]byte(`{"ip_str": "1.1.1.1"`), &banner))
+	assert.EqualError(t, fast.decodeBannerBytes([]byte(`{"error": "Invalid API key"}`), &banner), "Invalid API key")
+}
+
+func benchmarkBannerDecode(b *testing.B, options ...ClientOption) {
+	var stub struct {
+		Matches []json.RawMessage `json:"matches"`
+	}
+	if err := json.Unmarshal(getStub(b, "host/search"), &stub); err != nil {
+		b.Fatal(err)
+	}
+
+	c := NewClient(nil, testClientToken, options...)
+
+	b.SetBytes(int64(len(stub.Matches[0]) + len(stub.Matches[1])))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, match := range stub.Matches {
+			var banner HostData
+			if err := c.decodeBannerBytes(match, &banner); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeBanner_reflect(b *testing.B) {
+	benchmarkBannerDecode(b)
+}
+
+func BenchmarkDecodeBanner_fast(b *testing.B) {
+	benchmarkBannerDecode(b, WithFastDecoder())
+}
+
+func TestClient_WithFastDecoder_search(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "host/search"))
+	})
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "host/host"))
+	})
+
+	fast := NewClient(nil, testClientToken, WithFastDecoder())
+	fast.BaseURL = server.URL
+	standard := NewClient(nil, testClientToken)
+	standard.BaseURL = server.URL
+
+	options := &HostQueryOptions{Query: "nginx"}
+
+	expectedMatches, err := standard.GetHostsForQuery(context.Background(), options)
+	assert.Nil(t, err)
+	actualMatches, err := fast.GetHostsForQuery(context.Background(), options)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedMatches, actualMatches)
+
+	expectedHost, err := standard.GetServicesForHost(context.Background(), "8.8.8.8", nil)
+	assert.Nil(t, err)
+	actualHost, err := fast.GetServicesForHost(context.Background(), "8.8.8.8", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedHost, actualHost)
+
+	lazy, err := fast.GetHostsForQueryLazy(context.Background(), options)
+	assert.Nil(t, err)
+	assert.Len(t, lazy.Matches, len(expectedMatches.Matches))
+
+	for i, match := range lazy.Matches {
+		banner, err := match.Full()
+		assert.Nil(t, err)
+		assert.Equal(t, expectedMatches.Matches[i], banner)
+	}
+}
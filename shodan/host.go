@@ -1,7 +1,14 @@
 package shodan
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -11,6 +18,17 @@ const (
 	hostSearchTokensPath = "/shodan/host/search/tokens"
 )
 
+// HostSearcher is the part of the client looking up and searching the hosts.
+type HostSearcher interface {
+	GetServicesForHost(ctx context.Context, ip string, options *HostServicesOptions) (*Host, error)
+	GetHostsCountForQuery(ctx context.Context, options *HostQueryOptions) (*HostMatch, error)
+	GetHostsForQuery(ctx context.Context, options *HostQueryOptions) (*HostMatch, error)
+	SearchHostsFunc(ctx context.Context, options *HostQueryOptions, fn func(*HostData) error) (*SearchSummary, error)
+	BreakQueryIntoTokens(ctx context.Context, query string) (*HostQueryTokens, error)
+}
+
+var _ HostSearcher = (*Client)(nil)
+
 // HostServicesOptions is options for querying services.
 type HostServicesOptions struct {
 	History bool `url:"history,omitempty"`
@@ -31,11 +49,38 @@ type HostLocation struct {
 	DMA          int     `json:"dma_code"`
 }
 
+// Version is a product version. Shodan reports it either as a number or as a string
+// depending on the module that grabbed the banner, it's always encoded back as a string.
+type Version string
+
+// UnmarshalJSON decodes the version from both JSON strings and numbers.
+func (v *Version) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*v = Version(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+
+	*v = Version(n)
+
+	return nil
+}
+
+// String returns the version as a string.
+func (v Version) String() string {
+	return string(v)
+}
+
 // HostData is all services that have been found on the given host IP.
 type HostData struct {
 	Product      string                 `json:"product"`
 	Hostnames    []string               `json:"hostnames"`
-	Version      json.Number            `json:"version"`
+	Version      Version                `json:"version"`
 	Title        string                 `json:"title"`
 	IPLong       int                    `json:"ip"`
 	IP           string                 `json:"ip_str"`
@@ -51,11 +96,123 @@ type HostData struct {
 	Link         string                 `json:"link"`
 	Transport    string                 `json:"transport"`
 	Domains      []string               `json:"domains"`
-	Timestamp    string                 `json:"timestamp"`
+	Timestamp    Time                   `json:"timestamp"`
 	DeviceType   string                 `json:"devicetype"`
 	Location     *HostLocation          `json:"location"`
 	ShodanData   map[string]interface{} `json:"_shodan"`
 	Opts         map[string]interface{} `json:"opts"`
+	SSL          *HostSSL               `json:"ssl"`
+	HTTP         *HostHTTP              `json:"http"`
+	SSH          *HostSSH               `json:"ssh"`
+	Vulns        map[string]*HostVuln   `json:"vulns"`
+	Tags         []string               `json:"tags"`
+
+	// CurrentPTR holds the hostnames the IP currently resolves back to, it's filled by
+	// EnrichWithReverseDNS and nil otherwise.
+	CurrentPTR []string `json:"current_ptr,omitempty"`
+}
+
+// HostSSL is the SSL/TLS information of the service.
+type HostSSL struct {
+	Versions []string   `json:"versions"`
+	Cipher   *SSLCipher `json:"cipher"`
+	Cert     *SSLCert   `json:"cert"`
+	// Chain holds the PEM encoded certificates sent by the service.
+	Chain []string `json:"chain"`
+}
+
+// SSLCipher is the cipher negotiated with the service.
+type SSLCipher struct {
+	Version string `json:"version"`
+	Bits    int    `json:"bits"`
+	Name    string `json:"name"`
+}
+
+// SSLCert is the certificate of the service.
+type SSLCert struct {
+	Subject     map[string]string `json:"subject"`
+	Issuer      map[string]string `json:"issuer"`
+	Issued      Time              `json:"issued"`
+	Expires     Time              `json:"expires"`
+	Expired     bool              `json:"expired"`
+	SigAlg      string            `json:"sig_alg"`
+	Fingerprint SSLFingerprint    `json:"fingerprint"`
+}
+
+// SSLFingerprint is the fingerprint of a certificate.
+type SSLFingerprint struct {
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+}
+
+// HostHTTP is the HTTP information of a web service.
+type HostHTTP struct {
+	Status      int                       `json:"status"`
+	Title       string                    `json:"title"`
+	Host        string                    `json:"host"`
+	Location    string                    `json:"location"`
+	Server      string                    `json:"server"`
+	HTMLHash    int                       `json:"html_hash"`
+	Robots      string                    `json:"robots"`
+	RobotsHash  int                       `json:"robots_hash"`
+	Sitemap     string                    `json:"sitemap"`
+	SecurityTxt string                    `json:"securitytxt"`
+	WAF         string                    `json:"waf"`
+	Redirects   []*HTTPRedirect           `json:"redirects"`
+	Favicon     *HTTPFavicon              `json:"favicon"`
+	Components  map[string]*HTTPComponent `json:"components"`
+}
+
+// HTTPRedirect is a redirect followed to get to the page.
+type HTTPRedirect struct {
+	Host     string `json:"host"`
+	Location string `json:"location"`
+	Data     string `json:"data"`
+}
+
+// HTTPFavicon is the favicon of the website, Hash is the one searched with the http.favicon.hash filter
+// and Data is the base64 encoded icon.
+type HTTPFavicon struct {
+	Hash     int    `json:"hash"`
+	Location string `json:"location"`
+	Data     string `json:"data"`
+}
+
+// HTTPComponent is a web technology the website is built with, they are keyed by name in
+// HostHTTP.Components.
+type HTTPComponent struct {
+	Categories []string `json:"categories"`
+}
+
+// HostSSH is the SSH information of the service, Key is the base64 encoded host key.
+type HostSSH struct {
+	Type        string  `json:"type"`
+	Key         string  `json:"key"`
+	Fingerprint string  `json:"fingerprint"`
+	MAC         string  `json:"mac"`
+	Cipher      string  `json:"cipher"`
+	HASSH       string  `json:"hassh"`
+	Kex         *SSHKex `json:"kex"`
+}
+
+// SSHKex holds the algorithms offered by the service during the key exchange.
+type SSHKex struct {
+	KexAlgorithms           []string `json:"kex_algorithms"`
+	ServerHostKeyAlgorithms []string `json:"server_host_key_algorithms"`
+	EncryptionAlgorithms    []string `json:"encryption_algorithms"`
+	MACAlgorithms           []string `json:"mac_algorithms"`
+	CompressionAlgorithms   []string `json:"compression_algorithms"`
+	Languages               []string `json:"languages"`
+	KexFollows              bool     `json:"kex_follows"`
+	Unused                  int      `json:"unused"`
+}
+
+// HostVuln is a vulnerability the service is affected by, they are keyed by CVE in HostData.Vulns.
+type HostVuln struct {
+	CVSS       float64  `json:"cvss"`
+	Verified   bool     `json:"verified"`
+	Summary    string   `json:"summary"`
+	References []string `json:"references"`
 }
 
 // Host is the all information about the host.
@@ -68,18 +225,176 @@ type Host struct {
 	Hostnames       []string    `json:"hostnames"`
 	Organization    string      `json:"org"`
 	Vulnerabilities []string    `json:"vulns"`
+	Tags            []string    `json:"tags"`
+	Domains         []string    `json:"domains"`
 	ASN             string      `json:"asn"`
-	LastUpdate      string      `json:"last_update"`
+	LastUpdate      Time        `json:"last_update"`
 	Data            []*HostData `json:"data"`
 	HostLocation
 }
 
+// String returns a one-line summary of the banner, i.e. "1.2.3.4:443/tcp nginx 1.18 [US, AS13335]".
+// Bulky fields like the raw data and html body are left out.
+func (h *HostData) String() string {
+	parts := []string{net.JoinHostPort(h.IP, strconv.Itoa(h.Port))}
+	if h.Transport != "" {
+		parts[0] += "/" + h.Transport
+	}
+
+	if h.Product != "" {
+		parts = append(parts, h.Product)
+	}
+
+	if h.Version != "" {
+		parts = append(parts, h.Version.String())
+	}
+
+	var countryCode string
+	if h.Location != nil {
+		countryCode = h.Location.CountryCode
+	}
+
+	if origin := formatOrigin(countryCode, h.ASN); origin != "" {
+		parts = append(parts, origin)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// LogValue implements slog.LogValuer.
+func (h *HostData) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("ip", h.IP),
+		slog.Int("port", h.Port),
+		slog.String("transport", h.Transport),
+	}
+
+	if h.Product != "" {
+		attrs = append(attrs, slog.String("product", h.Product))
+	}
+
+	if h.Version != "" {
+		attrs = append(attrs, slog.String("version", h.Version.String()))
+	}
+
+	if h.ASN != "" {
+		attrs = append(attrs, slog.String("asn", h.ASN))
+	}
+
+	if !h.Timestamp.IsZero() {
+		attrs = append(attrs, slog.Time("timestamp", h.Timestamp.Time))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// String returns a one-line summary of the host, i.e. "1.2.3.4 ports 22,443 [US, AS13335]".
+func (h *Host) String() string {
+	parts := []string{h.IP}
+	if len(h.Ports) > 0 {
+		ports := make([]string, 0, len(h.Ports))
+		for _, port := range h.Ports {
+			ports = append(ports, strconv.Itoa(port))
+		}
+
+		parts = append(parts, "ports "+strings.Join(ports, ","))
+	}
+
+	if origin := formatOrigin(h.CountryCode, h.ASN); origin != "" {
+		parts = append(parts, origin)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// LogValue implements slog.LogValuer.
+func (h *Host) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("ip", h.IP),
+		slog.Any("ports", h.Ports),
+		slog.String("asn", h.ASN),
+		slog.Int("banners", len(h.Data)),
+	)
+}
+
+// formatOrigin formats the country code and the ASN as "[US, AS13335]".
+func formatOrigin(countryCode, asn string) string {
+	origin := make([]string, 0, 2)
+	if countryCode != "" {
+		origin = append(origin, countryCode)
+	}
+
+	if asn != "" {
+		origin = append(origin, asn)
+	}
+
+	if len(origin) == 0 {
+		return ""
+	}
+
+	return "[" + strings.Join(origin, ", ") + "]"
+}
+
+// BannersBetween returns the banners collected in the [from, to) time range.
+// A zero from or to leaves the corresponding side of the range open.
+func (h *Host) BannersBetween(from, to time.Time) []*HostData {
+	banners := make([]*HostData, 0)
+	for _, banner := range h.Data {
+		if !from.IsZero() && banner.Timestamp.Before(from) {
+			continue
+		}
+
+		if !to.IsZero() && !banner.Timestamp.Before(to) {
+			continue
+		}
+
+		banners = append(banners, banner)
+	}
+
+	return banners
+}
+
+// LatestPerService returns the most recent banner for every service of the host.
+// Services are keyed by port and transport, i.e. "443/tcp".
+func (h *Host) LatestPerService() map[string]*HostData {
+	latest := make(map[string]*HostData)
+	for _, banner := range h.Data {
+		key := fmt.Sprintf("%d/%s", banner.Port, banner.Transport)
+		if current, ok := latest[key]; !ok || banner.Timestamp.After(current.Timestamp.Time) {
+			latest[key] = banner
+		}
+	}
+
+	return latest
+}
+
+// FirstSeen returns the time the service on the given port was seen for the first time.
+// The second return value is false if there is no banner with a timestamp for the port.
+func (h *Host) FirstSeen(port int) (time.Time, bool) {
+	var first time.Time
+	for _, banner := range h.Data {
+		if banner.Port != port || banner.Timestamp.IsZero() {
+			continue
+		}
+
+		if first.IsZero() || banner.Timestamp.Before(first) {
+			first = banner.Timestamp.Time
+		}
+	}
+
+	return first, !first.IsZero()
+}
+
 // HostQueryOptions is Shodan search query options.
 type HostQueryOptions struct {
 	Query  string `url:"query"`
 	Facets string `url:"facets,omitempty"`
-	Minify bool   `url:"minify,omitempty"`
 	Page   int    `url:"page,omitempty"`
+
+	// Minify strips the banners down to the basic host information. Minified matches only have
+	// IP, IPLong, Port, Transport, Organization, ISP, ASN, OS, Hostnames, Domains, Location and
+	// Timestamp populated, Data and all the protocol specific fields are left empty.
+	Minify bool `url:"minify,omitempty"`
 }
 
 // HostMatch is the search results with all matched hosts.
@@ -89,6 +404,12 @@ type HostMatch struct {
 	Matches []*HostData         `json:"matches"`
 }
 
+// SearchSummary is the search results without the matches.
+type SearchSummary struct {
+	Total  int                 `json:"total"`
+	Facets map[string][]*Facet `json:"facets"`
+}
+
 // HostQueryTokens is filters are being used by the query string and what
 // parameters were provided to the filters.
 type HostQueryTokens struct {
@@ -99,24 +420,34 @@ type HostQueryTokens struct {
 	Attributes map[string]interface{} `json:"attributes"`
 }
 
-// GetServicesForHost returns all services that have been found on the given host IP
-func (c *Client) GetServicesForHost(ip string, options *HostServicesOptions) (*Host, error) {
+// GetServicesForHost returns all services that have been found on the given host IP.
+// It's a part of HostSearcher.
+func (c *Client) GetServicesForHost(ctx context.Context, ip string, options *HostServicesOptions) (*Host, error) {
 	url := c.buildBaseURL(hostPath+"/"+ip, options)
 
 	var host Host
-	err := c.executeRequest("GET", url, &host, nil)
+	err := c.executeDecoderRequest(ctx, "GET", url, func(decoder *json.Decoder) error {
+		return c.decodeHost(decoder, &host)
+	})
 
 	return &host, err
 }
 
 // GetHostsCountForQuery behaves identical to "/shodan/host/search" with the only difference that this method
 // does not return any host results, it only returns the total number of results that matched the query and any facet
-// information that was requested. As a result this method does not consume query credits
-func (c *Client) GetHostsCountForQuery(options *HostQueryOptions) (*HostMatch, error) {
-	url := c.buildBaseURL(hostCountPath, options)
+// information that was requested. As a result this method does not consume query credits.
+// The page and the minify options are not sent, they don't apply to the count.
+// It's a part of HostSearcher.
+func (c *Client) GetHostsCountForQuery(ctx context.Context, options *HostQueryOptions) (*HostMatch, error) {
+	var countOptions *HostQueryOptions
+	if options != nil {
+		countOptions = &HostQueryOptions{Query: options.Query, Facets: options.Facets}
+	}
+
+	url := c.buildBaseURL(hostCountPath, countOptions)
 
 	var found HostMatch
-	err := c.executeRequest("GET", url, &found, nil)
+	err := c.executeRequest(ctx, "GET", url, &found, nil)
 
 	return &found, err
 }
@@ -127,24 +458,125 @@ func (c *Client) GetHostsCountForQuery(options *HostQueryOptions) (*HostMatch, e
 // 1. The search query contains a filter
 // 2. Accessing results past the 1st page using the "page". For every 100 results past the 1st page 1 query credit is
 // deducted
-func (c *Client) GetHostsForQuery(options *HostQueryOptions) (*HostMatch, error) {
+// It's a part of HostSearcher.
+func (c *Client) GetHostsForQuery(ctx context.Context, options *HostQueryOptions) (*HostMatch, error) {
+	found := &HostMatch{Matches: make([]*HostData, 0)}
+	summary, err := c.SearchHostsFunc(ctx, options, func(banner *HostData) error {
+		found.Matches = append(found.Matches, banner)
+		return nil
+	})
+
+	if err != nil {
+		return found, err
+	}
+
+	found.Total = summary.Total
+	found.Facets = summary.Facets
+
+	return found, nil
+}
+
+// SearchHostsFunc behaves like GetHostsForQuery, but instead of collecting the matches it hands them to fn one by
+// one as they are decoded from the response. The iteration stops as soon as fn returns an error which is then
+// returned as is. The total and the facets are returned once the whole response has been read.
+// It's a part of HostSearcher.
+func (c *Client) SearchHostsFunc(ctx context.Context, options *HostQueryOptions, fn func(*HostData) error) (*SearchSummary, error) {
 	url := c.buildBaseURL(hostSearchPath, options)
+	ctx = withCredits(ctx, searchPageCredits(options))
 
-	var found HostMatch
-	err := c.executeRequest("GET", url, &found, nil)
+	var summary *SearchSummary
+	err := c.executeDecoderRequest(ctx, "GET", url, func(decoder *json.Decoder) error {
+		var err error
+		summary, err = c.decodeHostMatches(decoder, fn)
 
-	return &found, err
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// decodeHostMatches walks through the host search response passing every match to fn.
+func (c *Client) decodeHostMatches(decoder *json.Decoder, fn func(*HostData) error) (*SearchSummary, error) {
+	rest, err := decodeArrayField(decoder, "matches", func(decoder *json.Decoder) error {
+		banner, err := c.decodeBanner(decoder)
+		if err != nil {
+			return err
+		}
+
+		return fn(banner)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkErrorField(rest); err != nil {
+		return nil, err
+	}
+
+	summary := new(SearchSummary)
+	if err := json.Unmarshal(rest, summary); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// decodeHost decodes the host information, the banners are decoded one by one so even
+// the host history doesn't need to be held in memory as a whole.
+func (c *Client) decodeHost(decoder *json.Decoder, host *Host) error {
+	rest, err := decodeArrayField(decoder, "data", func(decoder *json.Decoder) error {
+		banner, err := c.decodeBanner(decoder)
+		if err != nil {
+			return err
+		}
+
+		host.Data = append(host.Data, banner)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := checkErrorField(rest); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(rest, host)
+}
+
+// decodeBanner decodes the next banner of the response with decodeBannerBytes, so the fast decoder
+// applies to it the same as to the streamed banners.
+func (c *Client) decodeBanner(decoder *json.Decoder) (*HostData, error) {
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	banner := new(HostData)
+	if err := c.decodeBannerBytes(raw, banner); err != nil {
+		return nil, err
+	}
+
+	return banner, nil
 }
 
 // BreakQueryIntoTokens determines which filters are being used by the query string
 // and what parameters were provided to the filters.
-func (c *Client) BreakQueryIntoTokens(query string) (*HostQueryTokens, error) {
+// It's a part of HostSearcher.
+func (c *Client) BreakQueryIntoTokens(ctx context.Context, query string) (*HostQueryTokens, error) {
 	url := c.buildBaseURL(hostSearchTokensPath, struct {
 		Query string `url:"query"`
 	}{Query: query})
 
 	var tokens HostQueryTokens
-	err := c.executeRequest("GET", url, &tokens, nil)
+	err := c.executeRequest(ctx, "GET", url, &tokens, nil)
 
 	return &tokens, err
 }
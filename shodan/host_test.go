@@ -1,8 +1,17 @@
 package shodan
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +26,481 @@ func TestClient_GetHostsForQuery_DifferentVersionFormats(t *testing.T) {
 	})
 
 	options := &HostQueryOptions{Query: "argentina"}
-	_, err := client.GetHostsForQuery(options)
+	found, err := client.GetHostsForQuery(context.Background(), options)
 
 	assert.Nil(t, err)
+	assert.Equal(t, Version("47"), found.Matches[0].Version)
+	assert.Equal(t, "XW.ar934x.v6.0.3.30600.170329.1817", found.Matches[2].Version.String())
+}
+
+func newHistoryHost(t *testing.T) *Host {
+	banners := []struct {
+		port      int
+		transport string
+		timestamp string
+	}{
+		{80, "tcp", "2017-09-01T10:00:00.000000"},
+		{80, "tcp", "2017-09-10T10:00:00.000000"},
+		{443, "tcp", "2017-08-15T10:00:00.000000"},
+		{443, "tcp", "2017-09-05T10:00:00.000000"},
+		{53, "udp", "2017-09-20T10:00:00.000000"},
+		{53, "tcp", "2017-07-01T10:00:00.000000"},
+	}
+
+	host := &Host{}
+	for _, banner := range banners {
+		timestamp, err := time.Parse("2006-01-02T15:04:05.999999", banner.timestamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		host.Data = append(host.Data, &HostData{
+			Port:      banner.port,
+			Transport: banner.transport,
+			Timestamp: Time{timestamp},
+		})
+	}
+
+	return host
+}
+
+func TestHost_BannersBetween(t *testing.T) {
+	host := newHistoryHost(t)
+	date := func(value string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return parsed
+	}
+
+	testCases := []struct {
+		from     time.Time
+		to       time.Time
+		expected []*HostData
+	}{
+		{date("2017-09-01"), date("2017-09-11"), []*HostData{host.Data[0], host.Data[1], host.Data[3]}},
+		{date("2017-09-01"), date("2017-09-10"), []*HostData{host.Data[0], host.Data[3]}},
+		{time.Time{}, date("2017-08-16"), []*HostData{host.Data[2], host.Data[5]}},
+		{date("2017-09-06"), time.Time{}, []*HostData{host.Data[1], host.Data[4]}},
+		{time.Time{}, time.Time{}, host.Data},
+		{date("2018-01-01"), time.Time{}, []*HostData{}},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, host.BannersBetween(testCase.from, testCase.to))
+	}
+}
+
+func TestHost_LatestPerService(t *testing.T) {
+	host := newHistoryHost(t)
+	expected := map[string]*HostData{
+		"80/tcp":  host.Data[1],
+		"443/tcp": host.Data[3],
+		"53/udp":  host.Data[4],
+		"53/tcp":  host.Data[5],
+	}
+
+	assert.Equal(t, expected, host.LatestPerService())
+	assert.Empty(t, new(Host).LatestPerService())
+}
+
+func TestHost_FirstSeen(t *testing.T) {
+	host := newHistoryHost(t)
+	testCases := []struct {
+		port     int
+		expected time.Time
+		found    bool
+	}{
+		{80, host.Data[0].Timestamp.Time, true},
+		{443, host.Data[2].Timestamp.Time, true},
+		{53, host.Data[5].Timestamp.Time, true},
+		{22, time.Time{}, false},
+	}
+
+	for _, testCase := range testCases {
+		firstSeen, found := host.FirstSeen(testCase.port)
+
+		assert.Equal(t, testCase.found, found)
+		assert.Equal(t, testCase.expected, firstSeen)
+	}
+}
+
+func TestClient_GetHostsForQuery_minified(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("minify") == "true" {
+			w.Write(getStub(t, "host/search_minified"))
+		} else {
+			w.Write(getStub(t, "host/search"))
+		}
+	})
+
+	full, err := client.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "port:443,22"})
+	assert.Nil(t, err)
+
+	minified, err := client.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "port:443,22", Minify: true})
+	assert.Nil(t, err)
+
+	assert.Equal(t, full.Total, minified.Total)
+	assert.Len(t, minified.Matches, len(full.Matches))
+
+	for i, match := range minified.Matches {
+		assert.Equal(t, full.Matches[i].IP, match.IP)
+		assert.Equal(t, full.Matches[i].IPLong, match.IPLong)
+		assert.Equal(t, full.Matches[i].Port, match.Port)
+		assert.Equal(t, full.Matches[i].Transport, match.Transport)
+		assert.Equal(t, full.Matches[i].Organization, match.Organization)
+		assert.Equal(t, full.Matches[i].Location, match.Location)
+		assert.Equal(t, full.Matches[i].Timestamp, match.Timestamp)
+
+		assert.NotEmpty(t, full.Matches[i].Data)
+		assert.Empty(t, match.Data)
+		assert.Empty(t, match.HTML)
+		assert.Empty(t, match.Product)
+		assert.Nil(t, match.ShodanData)
+	}
+}
+
+func benchmarkHostMatchDecode(b *testing.B, stubName string) {
+	content, err := ioutil.ReadFile(fmt.Sprintf("%s/%s.json", stubsDir, stubName))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var found HostMatch
+		if err := json.Unmarshal(content, &found); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHostMatch_decodeFull(b *testing.B) {
+	benchmarkHostMatchDecode(b, "host/search")
+}
+
+func BenchmarkHostMatch_decodeMinified(b *testing.B) {
+	benchmarkHostMatchDecode(b, "host/search_minified")
+}
+
+func TestHostData_String(t *testing.T) {
+	testCases := []struct {
+		banner   *HostData
+		expected string
+	}{
+		{
+			&HostData{
+				IP:        "1.2.3.4",
+				Port:      443,
+				Transport: "tcp",
+				Product:   "nginx",
+				Version:   "1.18",
+				ASN:       "AS13335",
+				HTML:      "<html><body>very large body</body></html>",
+				Data:      "HTTP/1.1 200 OK",
+				Location:  &HostLocation{CountryCode: "US"},
+			},
+			"1.2.3.4:443/tcp nginx 1.18 [US, AS13335]",
+		},
+		{&HostData{IP: "2001:db8::1", Port: 22, Transport: "tcp"}, "[2001:db8::1]:22/tcp"},
+		{&HostData{IP: "1.2.3.4", Port: 53, ASN: "AS1"}, "1.2.3.4:53 [AS1]"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.banner.String())
+		assert.Equal(t, testCase.expected, fmt.Sprint(testCase.banner))
+	}
+}
+
+func TestHost_String(t *testing.T) {
+	host := &Host{IP: "1.2.3.4", Ports: []int{22, 443}, ASN: "AS13335"}
+	host.CountryCode = "US"
+
+	assert.Equal(t, "1.2.3.4 ports 22,443 [US, AS13335]", host.String())
+	assert.Equal(t, "1.2.3.4", (&Host{IP: "1.2.3.4"}).String())
+}
+
+func TestHostData_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	banner := &HostData{
+		IP:        "1.2.3.4",
+		Port:      443,
+		Transport: "tcp",
+		Product:   "nginx",
+		HTML:      "<html><body>very large body</body></html>",
+	}
+	logger.Info("banner", "banner", banner)
+
+	var record map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, map[string]interface{}{
+		"ip":        "1.2.3.4",
+		"port":      float64(443),
+		"transport": "tcp",
+		"product":   "nginx",
+	}, record["banner"])
+}
+
+func assertJSONRoundTrip(t *testing.T, content []byte, decoded, redecoded interface{}) {
+	assert.Nil(t, json.Unmarshal(content, decoded))
+
+	encoded, err := json.Marshal(decoded)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(encoded, redecoded))
+	assert.Equal(t, decoded, redecoded)
+}
+
+func TestHostMatch_jsonRoundTrip(t *testing.T) {
+	for _, stubName := range []string{"host/search", "host/search_minified", "host/version"} {
+		assertJSONRoundTrip(t, getStub(t, stubName), new(HostMatch), new(HostMatch))
+	}
+}
+
+func TestHost_jsonRoundTrip(t *testing.T) {
+	for _, stubName := range []string{"host/host", "host/ipv6"} {
+		assertJSONRoundTrip(t, getStub(t, stubName), new(Host), new(Host))
+	}
+}
+
+func TestHostData_MarshalJSON(t *testing.T) {
+	var found HostMatch
+	assert.Nil(t, json.Unmarshal(getStub(t, "host/version"), &found))
+
+	encoded, err := json.Marshal(found.Matches[0])
+	assert.Nil(t, err)
+
+	var banner map[string]interface{}
+	assert.Nil(t, json.Unmarshal(encoded, &banner))
+	assert.Equal(t, float64(3187713176), banner["ip"])
+	assert.Equal(t, "190.0.164.152", banner["ip_str"])
+	assert.Equal(t, "2017-09-09T14:03:08.722893", banner["timestamp"])
+	assert.Equal(t, "steam-a2s", banner["_shodan"].(map[string]interface{})["module"])
+}
+
+func TestClient_SearchHostsFunc(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "country:2", r.URL.Query().Get("facets"))
+		fmt.Fprint(w, `{"matches": [{"ip_str": "1.1.1.1", "port": 443}, {"ip_str": "8.8.8.8", "port": 22}],
+			"facets": {"country": [{"count": 5, "value": "US"}, {"count": 2, "value": "AU"}]}, "total": 7}`)
+	})
+
+	ips := make([]string, 0)
+	options := &HostQueryOptions{Query: "port:443,22", Facets: "country:2"}
+	summary, err := client.SearchHostsFunc(context.Background(), options, func(banner *HostData) error {
+		ips = append(ips, banner.IP)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1.1.1.1", "8.8.8.8"}, ips)
+	assert.Equal(t, &SearchSummary{
+		Total: 7,
+		Facets: map[string][]*Facet{
+			"country": {{Count: 5, Value: "US"}, {Count: 2, Value: "AU"}},
+		},
+	}, summary)
+}
+
+func TestClient_SearchHostsFunc_stub(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "host/search"))
+	})
+
+	expected, err := client.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "port:443,22"})
+	assert.Nil(t, err)
+
+	matches := make([]*HostData, 0)
+	summary, err := client.SearchHostsFunc(context.Background(), &HostQueryOptions{Query: "port:443,22"},
+		func(banner *HostData) error {
+			matches = append(matches, banner)
+			return nil
+		})
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Total, summary.Total)
+	assert.Equal(t, expected.Matches, matches)
+}
+
+func TestClient_SearchHostsFunc_callbackError(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "host/search"))
+	})
+
+	stop := errors.New("stop")
+	calls := 0
+	summary, err := client.SearchHostsFunc(context.Background(), &HostQueryOptions{Query: "nginx"},
+		func(banner *HostData) error {
+			calls++
+			return stop
+		})
+
+	assert.Nil(t, summary)
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestClient_SearchHostsFunc_errorField(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error": "Request timed out"}`)
+	})
+
+	_, err := client.SearchHostsFunc(context.Background(), &HostQueryOptions{Query: "nginx"},
+		func(banner *HostData) error { return nil })
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "Request timed out", err.Error())
+}
+
+func TestClient_SearchHostsFunc_malformed(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches": {"ip_str": "1.1.1.1"}}`)
+	})
+
+	_, err := client.SearchHostsFunc(context.Background(), &HostQueryOptions{Query: "nginx"},
+		func(banner *HostData) error { return nil })
+
+	assert.NotNil(t, err)
+}
+
+func TestClient_GetHostsCountForQuery(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostCountPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "apache", r.URL.Query().Get("query"))
+		assert.Equal(t, "country:2", r.URL.Query().Get("facets"))
+		assert.NotContains(t, r.URL.Query(), "page")
+		assert.NotContains(t, r.URL.Query(), "minify")
+		w.Write(getStub(t, "host/count"))
+	})
+
+	options := &HostQueryOptions{
+		Query:  "apache",
+		Facets: EncodeFacets(FacetRequest{Name: "country", Count: 2}),
+		Page:   3,
+		Minify: true,
+	}
+	found, err := client.GetHostsCountForQuery(context.Background(), options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, found.Total)
+	assert.Empty(t, found.Matches)
+	assert.Equal(t, []*Facet{{Value: "AU", Count: 1}, {Value: "US", Count: 1}}, found.Facets["country"])
+	assert.Equal(t, 3, options.Page)
+}
+
+func TestClient_GetServicesForHost(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	ip := "8.8.8.8"
+	mux.HandleFunc(hostPath+"/"+ip, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("history"))
+		w.Write(getStub(t, "host/host"))
+	})
+
+	expected := new(Host)
+	assert.Nil(t, json.Unmarshal(getStub(t, "host/host"), expected))
+
+	host, err := client.GetServicesForHost(context.Background(), ip, &HostServicesOptions{History: true})
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, host)
+	assert.Equal(t, "8.8.8.8", host.IP)
+	assert.Equal(t, "US", host.CountryCode)
+	assert.Len(t, host.Data, 2)
+}
+
+func TestClient_GetServicesForHost_services(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	ip := "2001:db8::1"
+	mux.HandleFunc(hostPath+"/"+ip, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("minify"))
+		w.Write(getStub(t, "host/ipv6"))
+	})
+
+	host, err := client.GetServicesForHost(context.Background(), ip, &HostServicesOptions{Minify: true})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ipv6"}, host.Tags)
+	assert.Equal(t, []string{"example.net"}, host.Domains)
+
+	ssh := host.Data[0].SSH
+	assert.Equal(t, "ssh-ed25519", ssh.Type)
+	assert.Equal(t, "b12d2871a1189eff20364cf5333619ee", ssh.HASSH)
+	assert.Equal(t, []string{"curve25519-sha256", "diffie-hellman-group14-sha256"}, ssh.Kex.KexAlgorithms)
+	assert.Nil(t, host.Data[0].HTTP)
+
+	web := host.Data[1].HTTP
+	assert.Equal(t, 200, web.Status)
+	assert.Equal(t, "Welcome to nginx!", web.Title)
+	assert.Equal(t, "", web.Robots)
+	assert.Equal(t, -1343712810, web.Favicon.Hash)
+	assert.Equal(t, "http://v6.example.net/", web.Redirects[0].Location)
+	assert.Equal(t, []string{"Web servers", "Reverse proxies"}, web.Components["Nginx"].Categories)
+	assert.Len(t, host.Data[1].SSL.Chain, 1)
+}
+
+func TestClient_GetServicesForHost_errorField(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	ip := "8.8.8.8"
+	mux.HandleFunc(hostPath+"/"+ip, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error": "No information available for that IP."}`)
+	})
+
+	_, err := client.GetServicesForHost(context.Background(), ip, nil)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "No information available for that IP.", err.Error())
+}
+
+func TestDecodeHost_nullData(t *testing.T) {
+	var host Host
+	decoder := json.NewDecoder(strings.NewReader(`{"ip_str": "8.8.8.8", "data": null, "ports": [53]}`))
+
+	assert.Nil(t, NewClient(nil, testClientToken).decodeHost(decoder, &host))
+	assert.Equal(t, Host{IP: "8.8.8.8", Ports: []int{53}}, host)
+}
+
+func BenchmarkDecodeHost_history(b *testing.B) {
+	banner := `{"ip_str": "8.8.8.8", "port": 53, "data": "` + strings.Repeat("x", 4096) + `"}`
+	body := []byte(`{"ip_str": "8.8.8.8", "data": [` + strings.TrimSuffix(strings.Repeat(banner+",", 2000), ",") + `]}`)
+	c := NewClient(nil, testClientToken)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var host Host
+		if err := c.decodeHost(json.NewDecoder(bytes.NewReader(body)), &host); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
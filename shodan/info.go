@@ -1,26 +1,120 @@
 package shodan
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 const (
 	infoPath = "/api-info"
 )
 
+// FirehosePlans are the API plans Shodan streams the whole firehose of GetBanners to, see
+// APIInfo.SupportsFirehose.
+var FirehosePlans = []string{"enterprise"}
+
+// validateTokenTimeout is how long ValidateToken waits for Shodan unless ctx expires earlier.
+const validateTokenTimeout = 5 * time.Second
+
 // APIInfo holds API information.
 type APIInfo struct {
-	QueryCredits int    `json:"query_credits"`
-	ScanCredits  int    `json:"scan_credits"`
-	Telnet       bool   `json:"telnet"`
-	Plan         string `json:"plan"`
-	HTTPS        bool   `json:"https"`
-	Unlocked     bool   `json:"unlocked"`
-	UnlockedLeft int    `json:"unlocked_left"`
+	QueryCredits int         `json:"query_credits"`
+	ScanCredits  int         `json:"scan_credits"`
+	MonitoredIPs int         `json:"monitored_ips"`
+	Telnet       bool        `json:"telnet"`
+	Plan         string      `json:"plan"`
+	HTTPS        bool        `json:"https"`
+	Unlocked     bool        `json:"unlocked"`
+	UnlockedLeft int         `json:"unlocked_left"`
+	UsageLimits  UsageLimits `json:"usage_limits"`
+}
+
+// UsageLimits holds the limits of the API plan, a negative limit means there's none.
+type UsageLimits struct {
+	QueryCredits int `json:"query_credits"`
+	ScanCredits  int `json:"scan_credits"`
+	MonitoredIPs int `json:"monitored_ips"`
+}
+
+// SupportsFirehose reports whether the plan is one of the FirehosePlans.
+func (i *APIInfo) SupportsFirehose() bool {
+	for _, plan := range FirehosePlans {
+		if strings.EqualFold(i.Plan, plan) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // GetAPIInfo returns information about the API plan belonging to the given API key.
-func (c *Client) GetAPIInfo() (*APIInfo, error) {
+func (c *Client) GetAPIInfo(ctx context.Context) (*APIInfo, error) {
 	url := c.buildBaseURL(infoPath, nil)
 
 	var apiInfo APIInfo
-	err := c.executeRequest("GET", url, &apiInfo, nil)
+	err := c.executeRequest(ctx, "GET", url, &apiInfo, nil)
+	if err == nil {
+		c.observeCredits("query", apiInfo.QueryCredits)
+		c.observeCredits("scan", apiInfo.ScanCredits)
+	}
 
 	return &apiInfo, err
 }
+
+// ValidateToken checks the API key of the client with a call to "/api-info", which doesn't cost any
+// credits. ErrUnauthorized is returned if Shodan rejects the key. The check gives up after 5 seconds
+// unless ctx expires earlier.
+func (c *Client) ValidateToken(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, validateTokenTimeout)
+	defer cancel()
+
+	_, err := c.GetAPIInfo(ctx)
+	if errors.Is(err, ErrUnauthorized) {
+		return ErrUnauthorized
+	}
+
+	return err
+}
+
+// ValidateTokenString checks the API key just like ValidateToken, the client keeps using its own key.
+// The check is sent with the configuration of the client, only the key differs.
+func (c *Client) ValidateTokenString(ctx context.Context, token string) error {
+	return c.withToken(token).ValidateToken(ctx)
+}
+
+// withToken returns a copy of the client using the token. The rate limiter is shared, the state tied
+// to the key like the coalesced calls and the cached trigger rules is not.
+func (c *Client) withToken(token string) *Client {
+	v := *c
+	v.Token = token
+	v.StreamChan = make(chan HostData)
+	v.streamCounters = streamCounters{}
+
+	if c.triggerRules != nil {
+		v.triggerRules = &triggerRulesCache{ttl: c.triggerRules.ttl}
+	}
+
+	if c.flights != nil {
+		v.flights = newFlightGroup()
+	}
+
+	return &v
+}
+
+// PrecheckFirehose checks the plan of the API key supports the firehose before connecting to it with
+// GetBanners. ErrFirehoseUnavailable is returned if it doesn't.
+func (c *Client) PrecheckFirehose(ctx context.Context) error {
+	apiInfo, err := c.GetAPIInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !apiInfo.SupportsFirehose() {
+		return fmt.Errorf("%w: %s", ErrFirehoseUnavailable, apiInfo.Plan)
+	}
+
+	return nil
+}
@@ -1,23 +1,23 @@
-package shodan
+package shodan_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestClient_GetAPIInfo(t *testing.T) {
-	setUpTestServe()
-	defer tearDownTestServe()
+	server := shodantest.NewServer()
+	defer server.Close()
 
-	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "GET", r.Method)
-		w.Write(getStub(t, "info"))
-	})
-
-	info, err := client.GetAPIInfo()
-	infoExpected := &APIInfo{
+	info, err := server.Client().GetAPIInfo(context.Background())
+	infoExpected := &shodan.APIInfo{
 		HTTPS:        true,
 		Unlocked:     true,
 		UnlockedLeft: 9999,
@@ -25,9 +25,83 @@ func TestClient_GetAPIInfo(t *testing.T) {
 		ScanCredits:  254,
 		Plan:         "basic",
 		QueryCredits: 2341,
+		MonitoredIPs: 512,
+		UsageLimits: shodan.UsageLimits{
+			QueryCredits: 5120,
+			ScanCredits:  5120,
+			MonitoredIPs: 1024,
+		},
 	}
 
 	assert.Nil(t, err)
 	assert.IsType(t, infoExpected, info)
 	assert.EqualValues(t, infoExpected, info)
 }
+
+func TestClient_PrecheckFirehose(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	err := server.Client().PrecheckFirehose(context.Background())
+
+	assert.True(t, errors.Is(err, shodan.ErrFirehoseUnavailable))
+	assert.Equal(t, "firehose is not available on the plan: basic", err.Error())
+
+	server.Handle("/api-info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"plan": "Enterprise"}`))
+	})
+
+	assert.Nil(t, server.Client().PrecheckFirehose(context.Background()))
+}
+
+func TestClient_ValidateToken(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	assert.Nil(t, client.ValidateToken(context.Background()))
+
+	client.Token = "INVALID"
+	assert.Equal(t, shodan.ErrUnauthorized, client.ValidateToken(context.Background()))
+}
+
+func TestClient_ValidateToken_unavailable(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	server.InjectError("/api-info", http.StatusServiceUnavailable, "Unavailable")
+
+	err := server.Client().ValidateToken(context.Background())
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, shodan.ErrUnauthorized))
+}
+
+func TestClient_ValidateTokenString(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	assert.Equal(t, shodan.ErrUnauthorized, client.ValidateTokenString(context.Background(), "INVALID"))
+	assert.Nil(t, client.ValidateTokenString(context.Background(), shodantest.Token))
+	assert.Equal(t, shodantest.Token, client.Token)
+	assert.Equal(t, 2, server.Requests("/api-info"))
+}
+
+func TestClient_ValidateTokenString_configuration(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	hooked := 0
+	client := server.Client(
+		shodan.WithRetryPolicy(shodan.RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond}),
+		shodan.WithRequestHook(func(req *http.Request) {
+			hooked++
+		}),
+	)
+	server.RateLimit("/api-info", 1)
+
+	assert.Nil(t, client.ValidateTokenString(context.Background(), shodantest.Token))
+	assert.Equal(t, 2, server.Requests("/api-info"))
+	assert.Equal(t, 2, hooked)
+}
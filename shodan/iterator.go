@@ -0,0 +1,305 @@
+package shodan
+
+import "context"
+
+// hostSearchPageSize is the number of matches "/shodan/host/search" returns per page.
+const hostSearchPageSize = 100
+
+// pager keeps track of the pages of a paginated endpoint, the iterators fetch the pages through it.
+type pager struct {
+	pageSize int
+	// next is the page fetched next, page is the last fetched one.
+	next  int
+	page  int
+	total int
+	done  bool
+	err   error
+}
+
+func newPager(pageSize, start int) pager {
+	if start < 1 {
+		start = 1
+	}
+
+	return pager{pageSize: pageSize, next: start}
+}
+
+// exhausted reports whether there's nothing more to fetch.
+func (p *pager) exhausted() bool {
+	return p.done || p.err != nil
+}
+
+// advance fetches the next page. fetch returns the number of items on the page and the total number of
+// results, the pages run out once a page is empty or the total is reached. Rate limited fetches are
+// retried after waiting as WaitForRateLimit does.
+func (p *pager) advance(ctx context.Context, fetch func(page int) (items, total int, err error)) {
+	if p.exhausted() {
+		return
+	}
+
+	var items, total int
+	err := retryRateLimited(ctx, func() (err error) {
+		items, total, err = fetch(p.next)
+		return err
+	})
+	if err != nil {
+		p.err = err
+		return
+	}
+
+	p.page = p.next
+	p.total = total
+	if items == 0 || p.next*p.pageSize >= total {
+		p.done = true
+	}
+
+	p.next++
+}
+
+// HostIterator walks through all the pages of a host search one match at a time.
+// It's not safe for concurrent use.
+type HostIterator struct {
+	pager
+
+	client  *Client
+	options HostQueryOptions
+	matches []*HostData
+	offset  int
+	skip    int
+	current *HostData
+	fetched int
+
+	ctx context.Context
+	// budget is called before a page is fetched, an error stops the iteration instead.
+	budget func(page int) error
+}
+
+// IterateHostsForQuery returns an iterator over all the hosts matching the query.
+// Pages are requested from "/shodan/host/search" on demand starting with options.Page
+// (or the 1st one when it's not set), so the same query credits rules as for
+// GetHostsForQuery apply. The options are copied and can be reused by the caller. The pages
+// are fetched with ctx, once it's done the iteration stops with its error.
+func (c *Client) IterateHostsForQuery(ctx context.Context, options *HostQueryOptions) *HostIterator {
+	it := &HostIterator{client: c, ctx: ctx}
+	if options != nil {
+		it.options = *options
+	}
+
+	it.pager = newPager(hostSearchPageSize, it.options.Page)
+
+	return it
+}
+
+// ResumeHostsForQuery returns an iterator continuing the search saved in the cursor.
+// ErrCursorQueryMismatch is returned if the cursor was created for another query.
+func (c *Client) ResumeHostsForQuery(ctx context.Context, query string, cursor *SearchCursor) (*HostIterator, error) {
+	if cursor == nil || cursor.Query != query {
+		return nil, ErrCursorQueryMismatch
+	}
+
+	it := c.IterateHostsForQuery(ctx, &HostQueryOptions{
+		Query:  cursor.Query,
+		Facets: cursor.Facets,
+		Minify: cursor.Minify,
+		Page:   cursor.Page,
+	})
+	it.skip = cursor.Offset
+	it.total = cursor.Total
+	it.fetched = cursor.Fetched
+	it.done = cursor.Done
+
+	return it, nil
+}
+
+// Next advances the iterator to the next match fetching a new page when needed.
+// It returns false when there are no more matches or an error occurred.
+func (it *HostIterator) Next() bool {
+	for it.offset >= len(it.matches) {
+		if it.exhausted() {
+			it.current = nil
+			return false
+		}
+
+		if it.budget != nil {
+			if err := it.budget(it.next); err != nil {
+				it.err = err
+				continue
+			}
+		}
+
+		it.advance(it.ctx, it.fetch)
+	}
+
+	it.current = it.matches[it.offset]
+	it.offset++
+	it.fetched++
+
+	return true
+}
+
+// Match returns the match the iterator currently points at.
+func (it *HostIterator) Match() *HostData {
+	return it.current
+}
+
+// Total returns the total number of results as reported by the last fetched page.
+func (it *HostIterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *HostIterator) Err() error {
+	return it.err
+}
+
+// Cursor returns a cursor pointing right after the current match. It can be persisted
+// to continue the search later with ResumeHostsForQuery.
+func (it *HostIterator) Cursor() *SearchCursor {
+	cursor := &SearchCursor{
+		Query:   it.options.Query,
+		Facets:  it.options.Facets,
+		Minify:  it.options.Minify,
+		Page:    it.next,
+		Offset:  it.skip,
+		Total:   it.total,
+		Fetched: it.fetched,
+	}
+
+	if it.offset < len(it.matches) {
+		cursor.Page = it.page
+		cursor.Offset = it.offset
+	} else {
+		cursor.Done = it.done
+	}
+
+	return cursor
+}
+
+func (it *HostIterator) fetch(page int) (int, int, error) {
+	it.options.Page = page
+
+	if err := it.ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	found, err := it.client.GetHostsForQuery(it.ctx, &it.options)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	it.matches = found.Matches
+	it.offset = it.skip
+	it.skip = 0
+
+	return len(found.Matches), found.Total, nil
+}
+
+// DefaultSearchMaxPages is the number of pages SearchAll fetches when SearchLimits.MaxPages is not set.
+const DefaultSearchMaxPages = 10
+
+// SearchLimits bounds the pages SearchAll fetches. MaxPages defaults to DefaultSearchMaxPages when it's
+// zero, MaxResults and MaxCredits are not enforced when they are zero. A negative value lifts the bound.
+type SearchLimits struct {
+	MaxPages   int
+	MaxResults int
+	// MaxCredits is the maximum number of query credits spent, see EstimateSearchCredits.
+	MaxCredits int
+
+	// Precheck checks the account can afford the pages allowed by MaxPages before fetching any, an
+	// InsufficientCreditsError is returned if it can't. Only the 1st page is checked without a page bound.
+	Precheck bool
+}
+
+// SearchAll collects the matches of all the pages of a host search, fetching them the same way
+// IterateHostsForQuery does. It stops before exceeding any of the limits, the matches collected so far
+// are returned along with a SearchLimitError then. No error is returned when the search runs out of
+// matches first.
+func (c *Client) SearchAll(ctx context.Context, options *HostQueryOptions, limits SearchLimits) ([]*HostData, error) {
+	if limits.MaxPages == 0 {
+		limits.MaxPages = DefaultSearchMaxPages
+	}
+
+	if limits.Precheck {
+		if err := c.PrecheckCredits(ctx, estimateSearchAll(options, limits)); err != nil {
+			return nil, err
+		}
+	}
+
+	it := c.IterateHostsForQuery(ctx, options)
+
+	matches := make([]*HostData, 0)
+	pages, credits := 0, 0
+
+	limitReached := func(limit SearchLimit, bound int) error {
+		return &SearchLimitError{
+			Limit:   limit,
+			Bound:   bound,
+			Pages:   pages,
+			Results: len(matches),
+			Credits: credits,
+			Total:   it.total,
+		}
+	}
+
+	it.budget = func(page int) error {
+		if limits.MaxPages > 0 && pages >= limits.MaxPages {
+			return limitReached(SearchLimitPages, limits.MaxPages)
+		}
+
+		cost := searchPageCredits(&HostQueryOptions{Query: it.options.Query, Page: page})
+		if limits.MaxCredits > 0 && credits+cost > limits.MaxCredits {
+			return limitReached(SearchLimitCredits, limits.MaxCredits)
+		}
+
+		pages++
+		credits += cost
+
+		return nil
+	}
+
+	for {
+		if limits.MaxResults > 0 && len(matches) >= limits.MaxResults {
+			if it.offset < len(it.matches) || !it.exhausted() {
+				return matches, limitReached(SearchLimitResults, limits.MaxResults)
+			}
+
+			return matches, nil
+		}
+
+		if !it.Next() {
+			break
+		}
+
+		matches = append(matches, it.Match())
+	}
+
+	return matches, it.Err()
+}
+
+// estimateSearchAll estimates the query credits of the pages SearchAll may fetch, capped by MaxCredits.
+func estimateSearchAll(options *HostQueryOptions, limits SearchLimits) CreditEstimate {
+	var query string
+	first := 1
+	if options != nil {
+		query = options.Query
+		if options.Page > 1 {
+			first = options.Page
+		}
+	}
+
+	pages := limits.MaxPages
+	if pages < 0 {
+		pages = 1
+	}
+
+	var credits int
+	for page := first; page < first+pages; page++ {
+		credits += searchPageCredits(&HostQueryOptions{Query: query, Page: page})
+	}
+
+	if limits.MaxCredits > 0 && credits > limits.MaxCredits {
+		credits = limits.MaxCredits
+	}
+
+	return CreditEstimate{QueryCredits: credits}
+}
@@ -0,0 +1,233 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_IterateHostsForQuery(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := make([]int, 0)
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("minify"))
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.Nil(t, err)
+		pages = append(pages, page)
+
+		fmt.Fprintf(w, `{"total": 201, "matches": [{"ip_str": "1.1.1.%d", "port": 80}]}`, page)
+	})
+
+	options := &HostQueryOptions{Query: "nginx", Minify: true}
+	it := client.IterateHostsForQuery(context.Background(), options)
+
+	ips := make([]string, 0)
+	for it.Next() {
+		ips = append(ips, it.Match().IP)
+	}
+
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 201, it.Total())
+	assert.Equal(t, []int{1, 2, 3}, pages)
+	assert.Equal(t, []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}, ips)
+	assert.Equal(t, 0, options.Page)
+	assert.False(t, it.Next())
+}
+
+func TestClient_IterateHostsForQuery_startPage(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		fmt.Fprint(w, `{"total": 150, "matches": [{"ip_str": "1.1.1.1", "port": 80}]}`)
+	})
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx", Page: 2})
+
+	assert.True(t, it.Next())
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+}
+
+func TestClient_IterateHostsForQuery_emptyPage(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	requests := 0
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"total": 1000, "matches": []}`)
+	})
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Match())
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_IterateHostsForQuery_error(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Invalid API key"}`, http.StatusUnauthorized)
+	})
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+
+	assert.False(t, it.Next())
+	assert.NotNil(t, it.Err())
+}
+
+func TestClient_IterateHostsForQuery_rateLimited(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	requests := 0
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"error": "Rate limit reached"}`, http.StatusTooManyRequests)
+			return
+		}
+
+		w.Write(getStub(t, "host/search"))
+	})
+
+	it := client.IterateHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+
+	assert.True(t, it.Next())
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 2, requests)
+}
+
+// handleSearchPages serves pages of two matches out of the total.
+func handleSearchPages(t *testing.T, total int) *[]int {
+	pages := make([]int, 0)
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.Nil(t, err)
+		pages = append(pages, page)
+
+		fmt.Fprintf(w, `{"total": %d, "matches": [{"ip_str": "1.1.%d.1"}, {"ip_str": "1.1.%d.2"}]}`, total, page, page)
+	})
+
+	return &pages
+}
+
+func TestClient_SearchAll(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := handleSearchPages(t, 250)
+
+	matches, err := client.SearchAll(context.Background(), &HostQueryOptions{Query: "nginx"}, SearchLimits{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, *pages)
+	assert.Len(t, matches, 6)
+	assert.Equal(t, "1.1.3.2", matches[5].IP)
+}
+
+func TestClient_SearchAll_limits(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		limits   SearchLimits
+		pages    []int
+		expected *SearchLimitError
+	}{
+		{
+			name:     "default pages",
+			query:    "nginx",
+			pages:    []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			expected: &SearchLimitError{Limit: SearchLimitPages, Bound: 10, Pages: 10, Results: 20, Credits: 9, Total: 40000000},
+		},
+		{
+			name:     "results",
+			query:    "nginx",
+			limits:   SearchLimits{MaxResults: 3},
+			pages:    []int{1, 2},
+			expected: &SearchLimitError{Limit: SearchLimitResults, Bound: 3, Pages: 2, Results: 3, Credits: 1, Total: 40000000},
+		},
+		{
+			name:     "credits",
+			query:    "port:22",
+			limits:   SearchLimits{MaxPages: -1, MaxCredits: 2},
+			pages:    []int{1, 2},
+			expected: &SearchLimitError{Limit: SearchLimitCredits, Bound: 2, Pages: 2, Results: 4, Credits: 2, Total: 40000000},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setUpTestServe()
+			defer tearDownTestServe()
+
+			pages := handleSearchPages(t, 40000000)
+
+			matches, err := client.SearchAll(context.Background(), &HostQueryOptions{Query: c.query}, c.limits)
+
+			assert.Equal(t, c.expected, err)
+			assert.ErrorIs(t, err, ErrSearchLimitReached)
+			assert.Equal(t, c.pages, *pages)
+			assert.Len(t, matches, c.expected.Results)
+		})
+	}
+}
+
+func TestClient_SearchAll_precheck(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+
+	pages := handleSearchPages(t, 40000000)
+	options := &HostQueryOptions{Query: "port:22"}
+
+	_, err := client.SearchAll(context.Background(), options, SearchLimits{MaxPages: 3000, Precheck: true})
+
+	var creditsErr *InsufficientCreditsError
+	assert.True(t, errors.As(err, &creditsErr))
+	assert.Equal(t, CreditEstimate{QueryCredits: 3000}, creditsErr.Required)
+	assert.Empty(t, *pages)
+
+	_, err = client.SearchAll(context.Background(), options, SearchLimits{MaxPages: 3000, MaxCredits: 2, Precheck: true})
+	assert.ErrorIs(t, err, ErrSearchLimitReached)
+	assert.Equal(t, []int{1, 2}, *pages)
+}
+
+func TestEstimateSearchAll(t *testing.T) {
+	assert.Equal(t, CreditEstimate{QueryCredits: 9}, estimateSearchAll(&HostQueryOptions{Query: "nginx"}, SearchLimits{MaxPages: 10}))
+	assert.Equal(t, CreditEstimate{QueryCredits: 10}, estimateSearchAll(&HostQueryOptions{Query: "nginx", Page: 3}, SearchLimits{MaxPages: 10}))
+	assert.Equal(t, CreditEstimate{QueryCredits: 1}, estimateSearchAll(&HostQueryOptions{Query: "port:22"}, SearchLimits{MaxPages: -1}))
+	assert.Equal(t, CreditEstimate{}, estimateSearchAll(nil, SearchLimits{MaxPages: 1}))
+}
+
+func TestClient_SearchAll_exactResults(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := handleSearchPages(t, 200)
+
+	matches, err := client.SearchAll(context.Background(), &HostQueryOptions{Query: "nginx"}, SearchLimits{MaxResults: 4})
+
+	assert.Nil(t, err)
+	assert.Len(t, matches, 4)
+	assert.Equal(t, []int{1, 2}, *pages)
+}
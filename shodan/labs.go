@@ -1,6 +1,7 @@
 package shodan
 
 import (
+	"context"
 	"fmt"
 	"net"
 )
@@ -11,7 +12,7 @@ const (
 
 // CalcHoneyScore calculates a honeypot probability score ranging from
 // 0 (not a honeypot) to 1.0 (is a honeypot)
-func (c *Client) CalcHoneyScore(ip string) (float64, error) {
+func (c *Client) CalcHoneyScore(ctx context.Context, ip string) (float64, error) {
 	var score float64
 
 	if parsedIP := net.ParseIP(ip); parsedIP == nil {
@@ -23,7 +24,7 @@ func (c *Client) CalcHoneyScore(ip string) (float64, error) {
 
 	path := fmt.Sprintf(honeyscorePath, ip)
 	url := c.buildBaseURL(path, nil)
-	err := c.executeRequest("GET", url, &score, nil)
+	err := c.executeRequest(ctx, "GET", url, &score, nil)
 
 	return score, err
 }
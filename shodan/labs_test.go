@@ -1,6 +1,7 @@
 package shodan
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -20,14 +21,14 @@ func TestClient_CalcHoneyScore(t *testing.T) {
 		fmt.Fprint(w, `0.5`)
 	})
 
-	score, err := client.CalcHoneyScore(ip)
+	score, err := client.CalcHoneyScore(context.Background(), ip)
 	assert.Nil(t, err)
 	assert.Equal(t, 0.5, score)
 }
 
 func TestClient_CalcHoneyScore_invalidIP(t *testing.T) {
 	client := NewClient(nil, testClientToken)
-	_, err := client.CalcHoneyScore("invalid-ip")
+	_, err := client.CalcHoneyScore(context.Background(), "invalid-ip")
 
 	assert.NotNil(t, err)
 	_, ok := err.(*net.ParseError)
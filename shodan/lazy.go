@@ -0,0 +1,246 @@
+package shodan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// errMalformedObject is returned when a raw banner is not a JSON object.
+var errMalformedObject = errors.New("malformed JSON object")
+
+// LazyHostMatch is the search results with the matches decoded on demand.
+type LazyHostMatch struct {
+	Total   int                 `json:"total"`
+	Facets  map[string][]*Facet `json:"facets"`
+	Matches []*LazyMatch        `json:"matches"`
+}
+
+// LazyMatch is a search match holding the raw banner. Only the basic fields are decoded
+// right away, the whole banner is decoded by Full on the first call.
+type LazyMatch struct {
+	IP        string `json:"ip_str"`
+	Port      int    `json:"port"`
+	Timestamp Time   `json:"timestamp"`
+
+	raw    json.RawMessage
+	decode func(b []byte, banner *HostData) error
+	once   sync.Once
+	full   *HostData
+	err    error
+}
+
+// UnmarshalJSON keeps the raw banner and decodes the basic fields.
+func (m *LazyMatch) UnmarshalJSON(b []byte) error {
+	var match LazyMatch
+	err := scanObjectFields(b, func(key, value []byte) error {
+		switch string(key) {
+		case `"ip_str"`:
+			return json.Unmarshal(value, &match.IP)
+		case `"port"`:
+			return json.Unmarshal(value, &match.Port)
+		case `"timestamp"`:
+			return json.Unmarshal(value, &match.Timestamp)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	m.IP = match.IP
+	m.Port = match.Port
+	m.Timestamp = match.Timestamp
+	m.raw = append(json.RawMessage(nil), b...)
+
+	return nil
+}
+
+// MarshalJSON encodes the raw banner as is.
+func (m *LazyMatch) MarshalJSON() ([]byte, error) {
+	if m.raw == nil {
+		return []byte("null"), nil
+	}
+
+	return m.raw, nil
+}
+
+// Raw returns the banner as it was received from Shodan.
+func (m *LazyMatch) Raw() json.RawMessage {
+	return m.raw
+}
+
+// Full decodes the whole banner with the decoder of the client it was fetched with. The result is
+// memoized, so it's decoded only once. It's safe for concurrent use.
+func (m *LazyMatch) Full() (*HostData, error) {
+	m.once.Do(func() {
+		decode := m.decode
+		if decode == nil {
+			decode = func(b []byte, banner *HostData) error {
+				return json.Unmarshal(b, banner)
+			}
+		}
+
+		banner := new(HostData)
+		if m.err = decode(m.raw, banner); m.err == nil {
+			m.full = banner
+		}
+	})
+
+	return m.full, m.err
+}
+
+// GetHostsForQueryLazy behaves the same as GetHostsForQuery, but the matches are decoded only when asked to,
+// which saves a lot of work when only the IP and port of the matches are interesting.
+func (c *Client) GetHostsForQueryLazy(ctx context.Context, options *HostQueryOptions) (*LazyHostMatch, error) {
+	url := c.buildBaseURL(hostSearchPath, options)
+
+	found := &LazyHostMatch{Matches: make([]*LazyMatch, 0)}
+	err := c.executeDecoderRequest(ctx, "GET", url, func(decoder *json.Decoder) error {
+		return c.decodeLazyHostMatches(decoder, found)
+	})
+
+	return found, err
+}
+
+func (c *Client) decodeLazyHostMatches(decoder *json.Decoder, found *LazyHostMatch) error {
+	rest, err := decodeArrayField(decoder, "matches", func(decoder *json.Decoder) error {
+		match := new(LazyMatch)
+		if err := decoder.Decode(match); err != nil {
+			return err
+		}
+
+		match.decode = c.decodeBannerBytes
+
+		found.Matches = append(found.Matches, match)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := checkErrorField(rest); err != nil {
+		return err
+	}
+
+	summary := new(SearchSummary)
+	if err := json.Unmarshal(rest, summary); err != nil {
+		return err
+	}
+
+	found.Total = summary.Total
+	found.Facets = summary.Facets
+
+	return nil
+}
+
+// scanObjectFields calls fn with the raw key (quotes included) and the raw value of every top
+// level field of the JSON object. It's way cheaper than a full decode when only a couple of
+// fields are needed, but it relies on the object being valid JSON already.
+func scanObjectFields(b []byte, fn func(key, value []byte) error) error {
+	i := skipSpaces(b, 0)
+	if i >= len(b) || b[i] != '{' {
+		return errMalformedObject
+	}
+
+	for i = skipSpaces(b, i+1); i < len(b) && b[i] != '}'; {
+		if b[i] != '"' {
+			return errMalformedObject
+		}
+
+		keyEnd := skipValue(b, i)
+		key := b[i:keyEnd]
+
+		i = skipSpaces(b, keyEnd)
+		if i >= len(b) || b[i] != ':' {
+			return errMalformedObject
+		}
+
+		valueStart := skipSpaces(b, i+1)
+		valueEnd := skipValue(b, valueStart)
+		if err := fn(key, b[valueStart:valueEnd]); err != nil {
+			return err
+		}
+
+		i = skipSpaces(b, valueEnd)
+		if i < len(b) && b[i] == ',' {
+			i = skipSpaces(b, i+1)
+		}
+	}
+
+	if i >= len(b) {
+		return errMalformedObject
+	}
+
+	return nil
+}
+
+// skipValue returns the index right after the JSON value starting at i.
+func skipValue(b []byte, i int) int {
+	if i >= len(b) {
+		return i
+	}
+
+	switch b[i] {
+	case '"':
+		return skipString(b, i)
+	case '{', '[':
+		depth := 0
+		for i < len(b) {
+			switch b[i] {
+			case '"':
+				i = skipString(b, i)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+
+			i++
+		}
+
+		return i
+	default:
+		for i < len(b) {
+			switch b[i] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return i
+			}
+
+			i++
+		}
+
+		return i
+	}
+}
+
+// skipString returns the index right after the JSON string starting at i.
+func skipString(b []byte, i int) int {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return i
+}
+
+func skipSpaces(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+		i++
+	}
+
+	return i
+}
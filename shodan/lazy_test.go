@@ -0,0 +1,181 @@
+package shodan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetHostsForQueryLazy(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		w.Write(getStub(t, "host/search"))
+	})
+
+	expected, err := client.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "port:443,22"})
+	assert.Nil(t, err)
+
+	found, err := client.GetHostsForQueryLazy(context.Background(), &HostQueryOptions{Query: "port:443,22"})
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Total, found.Total)
+	assert.Len(t, found.Matches, len(expected.Matches))
+
+	for i, match := range found.Matches {
+		assert.Equal(t, expected.Matches[i].IP, match.IP)
+		assert.Equal(t, expected.Matches[i].Port, match.Port)
+		assert.Equal(t, expected.Matches[i].Timestamp, match.Timestamp)
+
+		full, err := match.Full()
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Matches[i], full)
+
+		again, err := match.Full()
+		assert.Nil(t, err)
+		assert.True(t, full == again)
+	}
+}
+
+func TestLazyMatch_Full_concurrent(t *testing.T) {
+	match := new(LazyMatch)
+	assert.Nil(t, json.Unmarshal([]byte(`{"ip_str": "1.1.1.1", "port": 443, "product": "nginx"}`), match))
+
+	var wg sync.WaitGroup
+	results := make([]*HostData, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = match.Full()
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, result := range results {
+		assert.True(t, results[0] == result)
+	}
+
+	assert.Equal(t, "nginx", results[0].Product)
+}
+
+func TestLazyMatch_Full_invalid(t *testing.T) {
+	match := new(LazyMatch)
+	assert.Nil(t, json.Unmarshal([]byte(`{"ip_str": "1.1.1.1", "port": 443, "hostnames": "invalid"}`), match))
+
+	full, err := match.Full()
+	assert.Nil(t, full)
+	assert.NotNil(t, err)
+}
+
+func TestLazyMatch_MarshalJSON(t *testing.T) {
+	raw := `{"ip_str":"1.1.1.1","port":443,"http":{"status":200}}`
+
+	match := new(LazyMatch)
+	assert.Nil(t, json.Unmarshal([]byte(raw), match))
+
+	encoded, err := json.Marshal(match)
+	assert.Nil(t, err)
+	assert.Equal(t, raw, string(encoded))
+}
+
+// newRealisticSearchPage repeats the matches of the search stub to build a 100 matches page.
+func newRealisticSearchPage(b *testing.B) []byte {
+	var page struct {
+		Total   int               `json:"total"`
+		Matches []json.RawMessage `json:"matches"`
+	}
+
+	content := getStub(b, "host/search")
+	if err := json.Unmarshal(content, &page); err != nil {
+		b.Fatal(err)
+	}
+
+	matches := page.Matches
+	for len(page.Matches) < hostSearchPageSize {
+		page.Matches = append(page.Matches, matches...)
+	}
+
+	page.Total = len(page.Matches)
+	body, err := json.Marshal(page)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return body
+}
+
+func BenchmarkDecodeHostMatches_headers(b *testing.B) {
+	body := newRealisticSearchPage(b)
+	c := NewClient(nil, testClientToken)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ports := 0
+		_, err := c.decodeHostMatches(json.NewDecoder(bytes.NewReader(body)), func(banner *HostData) error {
+			ports += banner.Port
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeLazyHostMatches_headers(b *testing.B) {
+	body := newRealisticSearchPage(b)
+	c := NewClient(nil, testClientToken)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		found := new(LazyHostMatch)
+		if err := c.decodeLazyHostMatches(json.NewDecoder(bytes.NewReader(body)), found); err != nil {
+			b.Fatal(err)
+		}
+
+		ports := 0
+		for _, match := range found.Matches {
+			ports += match.Port
+		}
+	}
+}
+
+func TestScanObjectFields(t *testing.T) {
+	raw := ` { "a" : 1, "b\"c": "x\"}", "nested": {"d": [1, {"e": "]"}]}, "f":null,"g" :true } `
+
+	fields := make(map[string]string)
+	err := scanObjectFields([]byte(raw), func(key, value []byte) error {
+		fields[string(key)] = string(value)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		`"a"`:      `1`,
+		`"b\"c"`:   `"x\"}"`,
+		`"nested"`: `{"d": [1, {"e": "]"}]}`,
+		`"f"`:      `null`,
+		`"g"`:      `true`,
+	}, fields)
+}
+
+func TestLazyMatch_UnmarshalJSON_notObject(t *testing.T) {
+	for _, raw := range []string{`[1, 2]`, `"1.1.1.1"`, `null`} {
+		match := new(LazyMatch)
+		assert.NotNil(t, match.UnmarshalJSON([]byte(raw)), raw)
+	}
+
+	match := new(LazyMatch)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"ip_str": 1}`), match))
+}
@@ -0,0 +1,100 @@
+package shodan
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// WithLogger makes the client log to the logger. The request summaries are logged at the debug
+// level, the stream reconnects and the retried attempts at the warn level and the failures at the
+// error level. The records only hold endpoint templates like "/shodan/host/{ip}", the API key never
+// ends up in the logs.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// LogValue implements slog.LogValuer.
+func (e *APIError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("status", e.StatusCode),
+		slog.String("message", e.Message),
+	)
+}
+
+// logRequest logs an attempt of the request, status is the one of the response when there's one and
+// retrying tells the attempt is going to be retried.
+func (c *Client) logRequest(ctx context.Context, method, rawURL string, attempt, status int, started time.Time, err error, retrying bool) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpointTemplate(c.endpointPath(rawURL))),
+		slog.String("method", method),
+		slog.Int("attempt", attempt),
+		slog.Duration("duration", time.Since(started)),
+	}
+
+	if status == 0 {
+		status = errorStatus(err)
+	}
+
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+
+	if err == nil {
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "shodan: request succeeded", attrs...)
+		return
+	}
+
+	attrs = append(attrs, c.errorAttr(err))
+	if retrying {
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "shodan: request failed, retrying", attrs...)
+		return
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelError, "shodan: request failed", attrs...)
+}
+
+func (c *Client) logStreamConnect(stream string, reconnect bool) {
+	if c.logger == nil {
+		return
+	}
+
+	if reconnect {
+		c.logger.LogAttrs(context.Background(), slog.LevelWarn, "shodan: stream reconnecting", slog.String("endpoint", stream))
+		return
+	}
+
+	c.logger.LogAttrs(context.Background(), slog.LevelDebug, "shodan: stream connecting", slog.String("endpoint", stream))
+}
+
+// logStreamEnd logs how the stream ended, err is the error returned while reading it.
+func (c *Client) logStreamEnd(rawURL string, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	stream := slog.String("endpoint", endpointTemplate(c.endpointPath(rawURL)))
+	if err = streamEnd(err); err == nil {
+		c.logger.LogAttrs(context.Background(), slog.LevelDebug, "shodan: stream ended", stream)
+		return
+	}
+
+	c.logger.LogAttrs(context.Background(), slog.LevelError, "shodan: stream failed", stream, c.errorAttr(err))
+}
+
+// errorAttr returns the error attribute of a record. The transport errors hold the request URL,
+// so the API key is masked in them.
+func (c *Client) errorAttr(err error) slog.Attr {
+	if c.Token != "" && strings.Contains(err.Error(), c.Token) {
+		return slog.String("error", strings.ReplaceAll(err.Error(), c.Token, "REDACTED"))
+	}
+
+	return slog.Any("error", err)
+}
@@ -0,0 +1,167 @@
+package shodan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func captureLogs(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal([]byte(line), &record))
+		delete(record, "time")
+		delete(record, "duration")
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestClient_WithLogger(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var buf bytes.Buffer
+	client = NewClient(nil, testClientToken, WithLogger(captureLogs(&buf)))
+	client.BaseURL = server.URL
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+	})
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	_, err = client.GetServicesForHost(context.Background(), "1.1.1.1", nil)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"level": "DEBUG", "msg": "shodan: request succeeded",
+			"endpoint": infoPath, "method": "GET", "attempt": float64(1), "status": float64(200),
+		},
+		{
+			"level": "ERROR", "msg": "shodan: request failed",
+			"endpoint": hostPath + "/{ip}", "method": "GET", "attempt": float64(1), "status": float64(404),
+			"error": map[string]interface{}{"status": float64(404), "message": "No information available for that IP."},
+		},
+	}, decodeLogs(t, &buf))
+	assert.NotContains(t, buf.String(), testClientToken)
+}
+
+func TestClient_WithLogger_retries(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var buf bytes.Buffer
+	client = NewClient(nil, testClientToken, WithLogger(captureLogs(&buf)),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond}))
+	client.BaseURL = server.URL
+
+	hits := 0
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		if hits++; hits == 1 {
+			http.Error(w, `{"error": "Service unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+		w.Write(getStub(t, "info"))
+	})
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Service unavailable"}`, http.StatusServiceUnavailable)
+	})
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	_, err = client.GetServicesForHost(context.Background(), "1.1.1.1", nil)
+	assert.NotNil(t, err)
+
+	unavailable := map[string]interface{}{"status": float64(503), "message": "Service unavailable"}
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"level": "WARN", "msg": "shodan: request failed, retrying",
+			"endpoint": infoPath, "method": "GET", "attempt": float64(1), "status": float64(503), "error": unavailable,
+		},
+		{
+			"level": "DEBUG", "msg": "shodan: request succeeded",
+			"endpoint": infoPath, "method": "GET", "attempt": float64(2), "status": float64(203),
+		},
+		{
+			"level": "WARN", "msg": "shodan: request failed, retrying",
+			"endpoint": hostPath + "/{ip}", "method": "GET", "attempt": float64(1), "status": float64(503), "error": unavailable,
+		},
+		{
+			"level": "ERROR", "msg": "shodan: request failed",
+			"endpoint": hostPath + "/{ip}", "method": "GET", "attempt": float64(2), "status": float64(503), "error": unavailable,
+		},
+	}, decodeLogs(t, &buf))
+}
+
+func TestClient_WithLogger_redactsToken(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient(nil, testClientToken, WithLogger(captureLogs(&buf)))
+	client.BaseURL = "http://127.0.0.1:0"
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), testClientToken)
+
+	records := decodeLogs(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.NotContains(t, buf.String(), testClientToken)
+	assert.Contains(t, records[0]["error"], "key=REDACTED")
+}
+
+func TestClient_WithLogger_stream(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var buf bytes.Buffer
+	client = NewClient(nil, testClientToken, WithLogger(captureLogs(&buf)))
+	client.StreamBaseURL = server.URL
+
+	mux.HandleFunc(fmt.Sprintf(bannersPortsPath, "22"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 22}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		client.StreamChan = make(chan HostData)
+		client.GetBannersByPorts(context.Background(), []int{22})
+		for range client.StreamChan {
+		}
+	}
+
+	stream := "/shodan/ports/{ports}"
+	assert.Equal(t, []map[string]interface{}{
+		{"level": "DEBUG", "msg": "shodan: stream connecting", "endpoint": stream},
+		{"level": "DEBUG", "msg": "shodan: stream ended", "endpoint": stream},
+		{"level": "DEBUG", "msg": "shodan: stream connecting", "endpoint": stream},
+		{"level": "DEBUG", "msg": "shodan: stream ended", "endpoint": stream},
+	}, decodeLogs(t, &buf))
+}
+
+func TestAPIError_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	captureLogs(&buf).Info("failed", "error", &APIError{StatusCode: 401, Message: "Please provide a valid API key"})
+
+	assert.Equal(t, map[string]interface{}{"status": float64(401), "message": "Please provide a valid API key"},
+		decodeLogs(t, &buf)[0]["error"])
+}
@@ -0,0 +1,112 @@
+package shodan
+
+import (
+	"strconv"
+	"time"
+)
+
+// The names of the metrics reported to Metrics along with their labels.
+const (
+	// MetricRequests counts the REST requests by "endpoint", "method" and "code", the code being
+	// the status class like "2xx" or "error" when no response was received.
+	MetricRequests = "shodan_requests_total"
+	// MetricRequestDuration observes the duration of the REST requests in seconds by "endpoint" and "method".
+	MetricRequestDuration = "shodan_request_duration_seconds"
+	// MetricCredits is the number of credits left by "kind", either "query" or "scan".
+	MetricCredits = "shodan_credits_remaining"
+	// MetricStreamMessages counts the banners received by "stream".
+	MetricStreamMessages = "shodan_stream_messages_total"
+	// MetricStreamReconnects counts the subscriptions connecting again after their stream ended by "stream".
+	MetricStreamReconnects = "shodan_stream_reconnects_total"
+)
+
+// Metrics receives the metrics of the client, see the Metric constants for the reported ones. The labels
+// only ever hold endpoint templates like "/shodan/host/{ip}" instead of the actual paths, so their
+// cardinality is bounded. The methods are called concurrently.
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+	Observe(name string, value float64, labels map[string]string)
+	SetGauge(name string, value float64, labels map[string]string)
+}
+
+// WithMetrics makes the client report its metrics to m.
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+func statusClass(err error) string {
+	if err == nil {
+		return "2xx"
+	}
+
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode >= 100 {
+		return strconv.Itoa(apiErr.StatusCode/100) + "xx"
+	}
+
+	return "error"
+}
+
+func (c *Client) observeRequest(method, rawURL string, started time.Time, err error) {
+	if c.vars != nil {
+		c.vars.requests.Add(1)
+		if err != nil {
+			c.vars.errors.Add(statusClass(err), 1)
+		}
+	}
+
+	if c.metrics == nil {
+		return
+	}
+
+	endpoint := endpointTemplate(c.endpointPath(rawURL))
+	c.metrics.IncCounter(MetricRequests, map[string]string{"endpoint": endpoint, "method": method, "code": statusClass(err)})
+	c.metrics.Observe(MetricRequestDuration, time.Since(started).Seconds(), map[string]string{"endpoint": endpoint, "method": method})
+}
+
+func (c *Client) observeCredits(kind string, credits int) {
+	if c.metrics != nil {
+		c.metrics.SetGauge(MetricCredits, float64(credits), map[string]string{"kind": kind})
+	}
+}
+
+// observeStreamConnect records the connection to the stream, reconnect tells it's a subscription
+// connecting again.
+func (c *Client) observeStreamConnect(stream string, reconnect bool) {
+	if c.vars != nil {
+		c.vars.activeStreams.Add(1)
+	}
+
+	if reconnect && c.metrics != nil {
+		c.metrics.IncCounter(MetricStreamReconnects, map[string]string{"stream": stream})
+	}
+}
+
+func (c *Client) observeStreamEnd() {
+	if c.vars != nil {
+		c.vars.activeStreams.Add(-1)
+	}
+}
+
+func (c *Client) observeRateLimitWait() {
+	if c.vars != nil {
+		c.vars.rateLimitWaits.Add(1)
+	}
+}
+
+func (c *Client) observeRetry() {
+	if c.vars != nil {
+		c.vars.retries.Add(1)
+	}
+}
+
+func (c *Client) observeStreamMessage(stream string) {
+	if c.vars != nil {
+		c.vars.streamMessages.Add(1)
+	}
+
+	if c.metrics != nil {
+		c.metrics.IncCounter(MetricStreamMessages, map[string]string{"stream": stream})
+	}
+}
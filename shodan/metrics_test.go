@@ -0,0 +1,154 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedMetric struct {
+	name   string
+	value  float64
+	labels map[string]string
+}
+
+type recordingMetrics struct {
+	mu       sync.Mutex
+	counters []recordedMetric
+	observed []recordedMetric
+	gauges   []recordedMetric
+}
+
+func (m *recordingMetrics) IncCounter(name string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters = append(m.counters, recordedMetric{name: name, value: 1, labels: labels})
+}
+
+func (m *recordingMetrics) Observe(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.observed = append(m.observed, recordedMetric{name: name, value: value, labels: labels})
+}
+
+func (m *recordingMetrics) SetGauge(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gauges = append(m.gauges, recordedMetric{name: name, value: value, labels: labels})
+}
+
+func TestClient_WithMetrics(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	metrics := new(recordingMetrics)
+	client = NewClient(nil, testClientToken, WithMetrics(metrics))
+	client.BaseURL = server.URL
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+	})
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+
+	for _, ip := range []string{"1.1.1.1", "8.8.8.8"} {
+		_, err = client.GetServicesForHost(context.Background(), ip, nil)
+		assert.NotNil(t, err)
+	}
+
+	assert.Equal(t, []recordedMetric{
+		{MetricRequests, 1, map[string]string{"endpoint": "/api-info", "method": "GET", "code": "2xx"}},
+		{MetricRequests, 1, map[string]string{"endpoint": "/shodan/host/{ip}", "method": "GET", "code": "4xx"}},
+		{MetricRequests, 1, map[string]string{"endpoint": "/shodan/host/{ip}", "method": "GET", "code": "4xx"}},
+	}, metrics.counters)
+
+	assert.Len(t, metrics.observed, 3)
+	for _, observed := range metrics.observed {
+		assert.Equal(t, MetricRequestDuration, observed.name)
+		assert.True(t, observed.value > 0)
+	}
+
+	assert.Equal(t, []recordedMetric{
+		{MetricCredits, 2341, map[string]string{"kind": "query"}},
+		{MetricCredits, 254, map[string]string{"kind": "scan"}},
+	}, metrics.gauges)
+}
+
+func TestClient_WithMetrics_stream(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	metrics := new(recordingMetrics)
+	client = NewClient(nil, testClientToken, WithMetrics(metrics))
+	client.StreamBaseURL = server.URL
+
+	mux.HandleFunc(fmt.Sprintf(bannersPortsPath, "22"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 22}`)
+		fmt.Fprintln(w, `{"ip_str": "8.8.8.8", "port": 22}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		client.StreamChan = make(chan HostData)
+		client.GetBannersByPorts(context.Background(), []int{22})
+		for range client.StreamChan {
+		}
+	}
+
+	messages, reconnects := 0, 0
+	for _, counter := range metrics.counters {
+		assert.Equal(t, "/shodan/ports/{ports}", counter.labels["stream"])
+
+		switch counter.name {
+		case MetricStreamMessages:
+			messages++
+		case MetricStreamReconnects:
+			reconnects++
+		}
+	}
+
+	assert.Equal(t, 4, messages)
+	assert.Equal(t, 0, reconnects)
+}
+
+func TestClient_WithMetrics_subscriptionReconnects(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	metrics := new(recordingMetrics)
+	client = NewClient(nil, testClientToken, WithMetrics(metrics))
+	client.StreamBaseURL = server.URL
+
+	handleDroppingStream(bannersPath, 3, http.StatusUnauthorized)
+
+	subscription, err := client.SubscribeBanners(context.Background(), fastReconnect)
+	assert.Nil(t, err)
+
+	for range subscription.Banners {
+	}
+
+	client.GetBanners(context.Background())
+	for range client.StreamChan {
+	}
+
+	reconnects := 0
+	for _, counter := range metrics.counters {
+		if counter.name == MetricStreamReconnects {
+			reconnects++
+		}
+	}
+
+	// The subscription reconnects twice and fails on the 3rd attempt, the stream started afterwards is
+	// not a reconnect.
+	assert.Equal(t, 3, reconnects)
+}
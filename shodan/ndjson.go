@@ -0,0 +1,201 @@
+package shodan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// defaultNDJSONFlushEvery is the number of banners written between flushes by default.
+const defaultNDJSONFlushEvery = 100
+
+// NDJSONOptions is options for WriteBannersNDJSON.
+type NDJSONOptions struct {
+	// SkipInvalid skips the banners that fail to encode instead of stopping at the 1st one.
+	SkipInvalid bool
+
+	// FlushEvery is the number of banners written between flushes of writers having a Flush
+	// method, i.e. bufio.Writer or http.Flusher (default: 100).
+	FlushEvery int
+}
+
+// RecordError is returned when a banner can't be encoded, Index is its position in the input.
+type RecordError struct {
+	Index int
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %s", e.Index, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// WriteMatchesNDJSON writes the banners as newline-delimited JSON, one banner per line. It stops at the
+// 1st banner that can't be encoded with a RecordError, the banners before it are written already.
+func WriteMatchesNDJSON(w io.Writer, matches []*HostData) error {
+	encoder := newNDJSONEncoder(w, nil)
+	for i, match := range matches {
+		if err := encoder.encode(i, match); err != nil {
+			return err
+		}
+	}
+
+	return encoder.close()
+}
+
+// WriteBannersNDJSON writes the banners received from the channel as newline-delimited JSON until it's
+// closed, the output can be read back with BannerReader. A banner that can't be encoded stops the writing
+// with a RecordError, unless SkipInvalid is set. The errors of the skipped banners are returned together
+// once the channel is closed then. A failing writer always stops the writing, it's up to the caller to
+// drain the channel.
+func WriteBannersNDJSON(w io.Writer, banners <-chan *HostData, options *NDJSONOptions) error {
+	encoder := newNDJSONEncoder(w, options)
+
+	index := 0
+	for banner := range banners {
+		if err := encoder.encode(index, banner); err != nil {
+			return err
+		}
+
+		index++
+	}
+
+	return encoder.close()
+}
+
+type ndjsonEncoder struct {
+	w       io.Writer
+	options NDJSONOptions
+	buf     bytes.Buffer
+	encoder *json.Encoder
+	written int
+	skipped []error
+}
+
+func newNDJSONEncoder(w io.Writer, options *NDJSONOptions) *ndjsonEncoder {
+	e := &ndjsonEncoder{w: w}
+	if options != nil {
+		e.options = *options
+	}
+
+	if e.options.FlushEvery <= 0 {
+		e.options.FlushEvery = defaultNDJSONFlushEvery
+	}
+
+	e.encoder = json.NewEncoder(&e.buf)
+	e.encoder.SetEscapeHTML(false)
+
+	return e
+}
+
+// encode writes a single banner, the error is nil when the banner is skipped.
+func (e *ndjsonEncoder) encode(index int, banner *HostData) error {
+	e.buf.Reset()
+	if err := e.encoder.Encode(banner); err != nil {
+		recordErr := &RecordError{Index: index, Err: err}
+		if !e.options.SkipInvalid {
+			return recordErr
+		}
+
+		e.skipped = append(e.skipped, recordErr)
+		return nil
+	}
+
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return err
+	}
+
+	e.written++
+	if e.written%e.options.FlushEvery == 0 {
+		return flush(e.w)
+	}
+
+	return nil
+}
+
+// close flushes the writer and returns the errors of the skipped banners.
+func (e *ndjsonEncoder) close() error {
+	if err := flush(e.w); err != nil {
+		return err
+	}
+
+	return errors.Join(e.skipped...)
+}
+
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+
+	return nil
+}
+
+// BannerReader reads the banners of newline-delimited JSON, i.e. the files of the bulk datasets or
+// the output of WriteBannersNDJSON. The blank lines are skipped. It's not safe for concurrent use.
+type BannerReader struct {
+	reader  *bufio.Reader
+	decode  func(b []byte, banner *HostData) error
+	line    int
+	current *HostData
+	err     error
+}
+
+// NewBannerReader creates a reader reading the banners from r.
+func NewBannerReader(r io.Reader) *BannerReader {
+	return &BannerReader{reader: bufio.NewReader(r), decode: func(b []byte, banner *HostData) error {
+		return json.Unmarshal(b, banner)
+	}}
+}
+
+// Next reads the next banner. It returns false at the end of the input or once an error occurred.
+func (r *BannerReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	for {
+		message, err := readStreamMessage(r.reader)
+		if err == io.EOF && len(bytes.TrimSpace(message)) == 0 {
+			r.current = nil
+			return false
+		}
+
+		if err != nil && err != io.EOF {
+			r.current, r.err = nil, err
+			return false
+		}
+
+		r.line++
+		if len(bytes.TrimSpace(message)) == 0 {
+			continue
+		}
+
+		banner := new(HostData)
+		if err := r.decode(message, banner); err != nil {
+			r.current, r.err = nil, fmt.Errorf("line %d: %w", r.line, err)
+			return false
+		}
+
+		r.current = banner
+		return true
+	}
+}
+
+// Banner returns the banner read by the last call to Next.
+func (r *BannerReader) Banner() *HostData {
+	return r.current
+}
+
+// Err returns the error that stopped the reading, if any.
+func (r *BannerReader) Err() error {
+	return r.err
+}
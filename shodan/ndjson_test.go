@@ -0,0 +1,104 @@
+package shodan_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/stretchr/testify/assert"
+)
+
+func readBanners(t *testing.T, r io.Reader) []*shodan.HostData {
+	t.Helper()
+
+	var banners []*shodan.HostData
+
+	reader := shodan.NewBannerReader(r)
+	for reader.Next() {
+		banners = append(banners, reader.Banner())
+	}
+
+	assert.Nil(t, reader.Err())
+
+	return banners
+}
+
+func TestWriteMatchesNDJSON(t *testing.T) {
+	matches := []*shodan.HostData{
+		shodantest.NewBanner().IP("192.0.2.1").Module("https").WithSSL(shodan.HostSSL{}).Build(),
+		shodantest.NewBanner().IP("192.0.2.2").Port(22).Data("SSH-2.0-OpenSSH_8.9\r\n").Build(),
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, shodan.WriteMatchesNDJSON(&buf, matches))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"_shodan":{`)
+	assert.Contains(t, lines[0], `"<html>`)
+
+	assert.Equal(t, matches, readBanners(t, &buf))
+}
+
+func TestWriteMatchesNDJSON_invalid(t *testing.T) {
+	invalid := shodantest.NewBanner().Build()
+	invalid.Opts["score"] = math.Inf(1)
+
+	matches := []*shodan.HostData{shodantest.NewBanner().Build(), invalid, shodantest.NewBanner().Build()}
+
+	var buf bytes.Buffer
+	err := shodan.WriteMatchesNDJSON(&buf, matches)
+
+	var recordErr *shodan.RecordError
+	assert.True(t, errors.As(err, &recordErr))
+	assert.Equal(t, 1, recordErr.Index)
+	assert.Len(t, readBanners(t, &buf), 1)
+}
+
+func TestWriteBannersNDJSON_skipInvalid(t *testing.T) {
+	invalid := shodantest.NewBanner().Build()
+	invalid.Opts["score"] = math.NaN()
+
+	banners := make(chan *shodan.HostData, 4)
+	banners <- shodantest.NewBanner().Port(1).Build()
+	banners <- invalid
+	banners <- shodantest.NewBanner().Port(2).Build()
+	banners <- invalid
+	close(banners)
+
+	var out bytes.Buffer
+	w := bufio.NewWriterSize(&out, 1<<20)
+	err := shodan.WriteBannersNDJSON(w, banners, &shodan.NDJSONOptions{SkipInvalid: true, FlushEvery: 1})
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "record 1: ")
+	assert.Contains(t, err.Error(), "record 3: ")
+
+	written := readBanners(t, &out)
+	assert.Len(t, written, 2)
+	assert.Equal(t, 2, written[1].Port)
+}
+
+func TestBannerReader(t *testing.T) {
+	input := `{"ip_str": "192.0.2.1", "port": 80}` + "\n\n" + `{"ip_str": "192.0.2.2", "port": 443}`
+
+	banners := readBanners(t, strings.NewReader(input))
+
+	assert.Len(t, banners, 2)
+	assert.Equal(t, 443, banners[1].Port)
+}
+
+func TestBannerReader_malformed(t *testing.T) {
+	reader := shodan.NewBannerReader(strings.NewReader(`{"ip_str": "192.0.2.1"}` + "\n" + `{"ip_str": `))
+
+	assert.True(t, reader.Next())
+	assert.False(t, reader.Next())
+	assert.Nil(t, reader.Banner())
+	assert.Contains(t, reader.Err().Error(), "line 2: ")
+}
@@ -0,0 +1,149 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+)
+
+const (
+	notifierPath          = "/notifier"
+	notifierProvidersPath = "/notifier/provider"
+	notifierIDPath        = "/notifier/%s"
+	alertNotifierPath     = "/shodan/alert/%s/notifier/%s"
+)
+
+// NotifierAPI is the part of the client managing the notifiers delivering the network alerts.
+type NotifierAPI interface {
+	ListNotifiers(ctx context.Context) ([]*Notifier, error)
+	ListNotifierProviders(ctx context.Context) (map[string]*NotifierProvider, error)
+	GetNotifier(ctx context.Context, id string) (*Notifier, error)
+	CreateNotifier(ctx context.Context, provider, description string, args map[string]string) (*Notifier, error)
+	UpdateNotifier(ctx context.Context, id string, args map[string]string) error
+	DeleteNotifier(ctx context.Context, id string) error
+	AddAlertNotifier(ctx context.Context, alertID, notifierID string) error
+	RemoveAlertNotifier(ctx context.Context, alertID, notifierID string) error
+}
+
+var _ NotifierAPI = (*Client)(nil)
+
+// Notifier delivers the notifications of the network alerts it's added to through its provider, i.e.
+// "email" or "slack". The "default" notifier sends emails to the address of the account.
+type Notifier struct {
+	ID          string `json:"id"`
+	Provider    string `json:"provider"`
+	Description string `json:"description"`
+	// Args are the settings of the provider, i.e. the "to" address of an email notifier.
+	Args map[string]string `json:"args"`
+}
+
+// NotifierProvider is a way of delivering the notifications, they are keyed by name in
+// ListNotifierProviders.
+type NotifierProvider struct {
+	// Required are the args a notifier of the provider must be created with.
+	Required []string `json:"required"`
+}
+
+type notifierList struct {
+	Matches []*Notifier `json:"matches"`
+	Total   int         `json:"total"`
+}
+
+// ListNotifiers returns the notifiers of the account.
+// It's a part of NotifierAPI.
+func (c *Client) ListNotifiers(ctx context.Context) ([]*Notifier, error) {
+	url := c.buildBaseURL(notifierPath, nil)
+
+	var list notifierList
+	err := c.executeRequest(ctx, "GET", url, &list, nil)
+
+	return list.Matches, err
+}
+
+// ListNotifierProviders returns the providers the notifiers can be created with, keyed by name.
+// It's a part of NotifierAPI.
+func (c *Client) ListNotifierProviders(ctx context.Context) (map[string]*NotifierProvider, error) {
+	url := c.buildBaseURL(notifierProvidersPath, nil)
+
+	var providers map[string]*NotifierProvider
+	err := c.executeRequest(ctx, "GET", url, &providers, nil)
+
+	return providers, err
+}
+
+// GetNotifier returns the notifier.
+// It's a part of NotifierAPI.
+func (c *Client) GetNotifier(ctx context.Context, id string) (*Notifier, error) {
+	url := c.buildBaseURL(fmt.Sprintf(notifierIDPath, id), nil)
+
+	var notifier Notifier
+	err := c.executeRequest(ctx, "GET", url, &notifier, nil)
+
+	return &notifier, err
+}
+
+// CreateNotifier creates a notifier for the provider, the args are the settings the provider requires,
+// see ListNotifierProviders. The notifier has to be added to the alerts with AddAlertNotifier.
+// It's a part of NotifierAPI.
+func (c *Client) CreateNotifier(ctx context.Context, provider, description string, args map[string]string) (*Notifier, error) {
+	url := c.buildBaseURL(notifierPath, nil)
+
+	form := notifierForm(args)
+	form.Set("provider", provider)
+	form.Set("description", description)
+
+	var created struct {
+		ID string `json:"id"`
+	}
+
+	if err := c.executeRequest(ctx, "POST", url, &created, newFormBody(form)); err != nil {
+		return nil, err
+	}
+
+	if created.ID == "" {
+		return nil, ErrNotifierNotCreated
+	}
+
+	return &Notifier{ID: created.ID, Provider: provider, Description: description, Args: args}, nil
+}
+
+// UpdateNotifier replaces the settings of the notifier with the args.
+// It's a part of NotifierAPI.
+func (c *Client) UpdateNotifier(ctx context.Context, id string, args map[string]string) error {
+	url := c.buildBaseURL(fmt.Sprintf(notifierIDPath, id), nil)
+
+	return c.executeRequest(ctx, "PUT", url, nil, newFormBody(notifierForm(args)))
+}
+
+// DeleteNotifier removes the notifier, the alerts it was added to stop using it.
+// It's a part of NotifierAPI.
+func (c *Client) DeleteNotifier(ctx context.Context, id string) error {
+	url := c.buildBaseURL(fmt.Sprintf(notifierIDPath, id), nil)
+
+	return c.executeRequest(ctx, "DELETE", url, nil, nil)
+}
+
+// AddAlertNotifier makes the notifier deliver the notifications of the network alert.
+// It's a part of NotifierAPI.
+func (c *Client) AddAlertNotifier(ctx context.Context, alertID, notifierID string) error {
+	url := c.buildBaseURL(fmt.Sprintf(alertNotifierPath, alertID, notifierID), nil)
+
+	return c.executeRequest(ctx, "PUT", url, nil, nil)
+}
+
+// RemoveAlertNotifier stops the notifier from delivering the notifications of the network alert.
+// It's a part of NotifierAPI.
+func (c *Client) RemoveAlertNotifier(ctx context.Context, alertID, notifierID string) error {
+	url := c.buildBaseURL(fmt.Sprintf(alertNotifierPath, alertID, notifierID), nil)
+
+	return c.executeRequest(ctx, "DELETE", url, nil, nil)
+}
+
+func notifierForm(args map[string]string) neturl.Values {
+	form := neturl.Values{}
+	for name, value := range args {
+		form.Set(name, value)
+	}
+
+	return form
+}
@@ -0,0 +1,143 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_ListNotifiers(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(notifierPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		w.Write(getStub(t, "notifier/notifiers"))
+	})
+
+	notifiers, err := client.ListNotifiers(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, notifiers, 2)
+	assert.Equal(t, &Notifier{
+		ID:          "default",
+		Provider:    "email",
+		Description: "Default notification",
+		Args:        map[string]string{"to": "jmath@shodan.io"},
+	}, notifiers[0])
+}
+
+func TestClient_ListNotifierProviders(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(notifierProvidersPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "notifier/providers"))
+	})
+
+	providers, err := client.ListNotifierProviders(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, providers, 5)
+	assert.Equal(t, []string{"chat_id", "token"}, providers["telegram"].Required)
+}
+
+func TestClient_GetNotifier(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/notifier/lR8wU9QrA1w6bl6e", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "notifier/notifier"))
+	})
+
+	notifier, err := client.GetNotifier(context.Background(), "lR8wU9QrA1w6bl6e")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "slack", notifier.Provider)
+	assert.Equal(t, "https://hooks.slack.com/services/T000/B000/XXXX", notifier.Args["webhook_url"])
+}
+
+func TestClient_CreateNotifier(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(notifierPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.Equal(t, "email", r.FormValue("provider"))
+		assert.Equal(t, "Security team", r.FormValue("description"))
+		assert.Equal(t, "security@example.com", r.FormValue("to"))
+		fmt.Fprint(w, `{"success": true, "id": "2Rbu2jrzrYbGtVdO"}`)
+	})
+
+	args := map[string]string{"to": "security@example.com"}
+	notifier, err := client.CreateNotifier(context.Background(), "email", "Security team", args)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Notifier{ID: "2Rbu2jrzrYbGtVdO", Provider: "email", Description: "Security team", Args: args}, notifier)
+}
+
+func TestClient_CreateNotifier_noID(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(notifierPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success": false}`)
+	})
+
+	notifier, err := client.CreateNotifier(context.Background(), "email", "", nil)
+
+	assert.Equal(t, ErrNotifierNotCreated, err)
+	assert.Nil(t, notifier)
+}
+
+func TestClient_UpdateNotifier(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/notifier/lR8wU9QrA1w6bl6e", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "https://hooks.slack.com/services/T000/B000/YYYY", r.PostForm.Get("webhook_url"))
+		fmt.Fprint(w, `{"success": true}`)
+	})
+
+	err := client.UpdateNotifier(context.Background(), "lR8wU9QrA1w6bl6e",
+		map[string]string{"webhook_url": "https://hooks.slack.com/services/T000/B000/YYYY"})
+
+	assert.Nil(t, err)
+}
+
+func TestClient_DeleteNotifier(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/notifier/lR8wU9QrA1w6bl6e", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		fmt.Fprint(w, `{"success": true}`)
+	})
+	mux.HandleFunc("/notifier/unknown", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Unable to find notifier"}`, http.StatusNotFound)
+	})
+
+	assert.Nil(t, client.DeleteNotifier(context.Background(), "lR8wU9QrA1w6bl6e"))
+	assert.ErrorIs(t, client.DeleteNotifier(context.Background(), "unknown"), ErrNotFound)
+}
+
+func TestClient_AlertNotifier(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var methods []string
+	mux.HandleFunc("/shodan/alert/ZZ4TDUUORVE1DIIP/notifier/default", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		fmt.Fprint(w, `{"success": true}`)
+	})
+
+	assert.Nil(t, client.AddAlertNotifier(context.Background(), "ZZ4TDUUORVE1DIIP", "default"))
+	assert.Nil(t, client.RemoveAlertNotifier(context.Background(), "ZZ4TDUUORVE1DIIP", "default"))
+	assert.Equal(t, []string{"PUT", "DELETE"}, methods)
+}
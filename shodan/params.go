@@ -0,0 +1,68 @@
+package shodan
+
+import (
+	"context"
+	"net/url"
+)
+
+// CallOption changes a single call made with the context it's attached to by WithCallOptions.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	params url.Values
+}
+
+// WithParam adds a query parameter to the call, i.e. a beta flag Shodan support asked for. It's
+// applied after the typed options are encoded, so it replaces a parameter of the same name. The API
+// key can't be replaced this way, a "key" parameter is ignored. The parameters go to the URL also
+// for POST requests.
+func WithParam(key, value string) CallOption {
+	return func(o *callOptions) {
+		if key != "key" {
+			o.params.Set(key, value)
+		}
+	}
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions attaches the options to the context, they're applied to every call made with it.
+// That includes the REST and stream requests the client sends on its own, like the GetAPIInfo call
+// of the prechecks, but not the dataset file downloads which have URLs of their own. The options
+// attached to the parent context are kept, the new ones are applied after them.
+func WithCallOptions(ctx context.Context, options ...CallOption) context.Context {
+	merged := &callOptions{params: make(url.Values)}
+	if parent, ok := ctx.Value(callOptionsKey{}).(*callOptions); ok {
+		for key, values := range parent.params {
+			merged.params[key] = append([]string(nil), values...)
+		}
+	}
+
+	for _, option := range options {
+		option(merged)
+	}
+
+	return context.WithValue(ctx, callOptionsKey{}, merged)
+}
+
+// applyCallOptions merges the parameters of the call options attached to ctx into the URL.
+func applyCallOptions(ctx context.Context, rawURL string) string {
+	options, ok := ctx.Value(callOptionsKey{}).(*callOptions)
+	if !ok || len(options.params) == 0 {
+		return rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	qs := parsed.Query()
+	for key, values := range options.params {
+		qs[key] = values
+	}
+
+	parsed.RawQuery = qs.Encode()
+
+	return parsed.String()
+}
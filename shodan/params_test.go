@@ -0,0 +1,73 @@
+package shodan
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCallOptions(t *testing.T) {
+	client := NewClient(nil, testClientToken)
+	rawURL := client.buildBaseURL(hostSearchPath, &HostQueryOptions{Query: "nginx", Minify: true})
+
+	ctx := WithCallOptions(context.Background(), WithParam("minify", "false"), WithParam("beta", "1"))
+	parsed, err := url.Parse(applyCallOptions(ctx, rawURL))
+
+	assert.Nil(t, err)
+	assert.Equal(t, hostSearchPath, parsed.Path)
+	assert.Equal(t, url.Values{
+		"query":  {"nginx"},
+		"minify": {"false"},
+		"beta":   {"1"},
+		"key":    {testClientToken},
+	}, parsed.Query())
+}
+
+func TestApplyCallOptions_none(t *testing.T) {
+	client := NewClient(nil, testClientToken)
+	rawURL := client.buildBaseURL(hostSearchPath, &HostQueryOptions{Query: "nginx"})
+
+	assert.Equal(t, rawURL, applyCallOptions(context.Background(), rawURL))
+	assert.Equal(t, rawURL, applyCallOptions(WithCallOptions(context.Background()), rawURL))
+}
+
+func TestWithCallOptions_nested(t *testing.T) {
+	parent := WithCallOptions(context.Background(), WithParam("beta", "1"), WithParam("trace", "a"))
+	child := WithCallOptions(parent, WithParam("trace", "b"))
+
+	parsed, err := url.Parse(applyCallOptions(child, "https://api.shodan.io/api-info?key=x"))
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{"beta": {"1"}, "trace": {"b"}, "key": {"x"}}, parsed.Query())
+
+	parsed, err = url.Parse(applyCallOptions(parent, "https://api.shodan.io/api-info?key=x"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a", parsed.Query().Get("trace"))
+}
+
+func TestClient_WithParam(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(queryTagsPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "5", r.URL.Query().Get("size"))
+		assert.Equal(t, "yes", r.URL.Query().Get("beta"))
+		assert.Equal(t, testClientToken, r.URL.Query().Get("key"))
+		w.Write(getStub(t, "query_tags"))
+	})
+
+	ctx := WithCallOptions(context.Background(), WithParam("size", "5"), WithParam("beta", "yes"))
+	_, err := client.GetAllQueryTags(ctx)
+
+	assert.Nil(t, err)
+}
+
+func TestWithParam_key(t *testing.T) {
+	ctx := WithCallOptions(context.Background(), WithParam("key", "OTHER"), WithParam("beta", "1"))
+
+	parsed, err := url.Parse(applyCallOptions(ctx, "https://api.shodan.io/api-info?key=x"))
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{"beta": {"1"}, "key": {"x"}}, parsed.Query())
+}
@@ -0,0 +1,64 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Ping measures the round-trip time of a request to "/api-info", which doesn't cost any credits.
+// The errors can be told apart with errors.Is: ErrUnreachable and ErrTLS come from a ConnectionError
+// when no response has been received, ErrUnauthorized and ErrServerError from an APIError otherwise.
+// The rate limit set with SetRateLimit doesn't apply.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	return c.ping(ctx, c.Client, "GET", c.buildBaseURL(infoPath, nil), false)
+}
+
+// PingStream measures the round-trip time of a HEAD request to the streaming API, which is served
+// by another host. Only the server errors count since nothing is streamed, the errors are the same
+// as the ones of Ping.
+func (c *Client) PingStream(ctx context.Context) (time.Duration, error) {
+	return c.ping(ctx, c.streamHTTPClient(), "HEAD", c.StreamBaseURL+"/", true)
+}
+
+func (c *Client) ping(ctx context.Context, client *http.Client, method, rawURL string, serverErrorsOnly bool) (time.Duration, error) {
+	started := time.Now()
+	res, err := c.sendRequestWith(ctx, client, method, rawURL, nil, nil)
+	elapsed := time.Since(started)
+
+	if err == nil {
+		res.Body.Close()
+		return elapsed, nil
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if serverErrorsOnly && !errors.Is(apiErr, ErrServerError) {
+			return elapsed, nil
+		}
+
+		return elapsed, err
+	}
+
+	if errors.Is(err, ErrTransportSelectorPanic) {
+		return elapsed, err
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return elapsed, ctxErr
+	}
+
+	connErr := &ConnectionError{Err: err}
+	if parsed, parseErr := url.Parse(rawURL); parseErr == nil {
+		connErr.Host = parsed.Host
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		connErr.Err = urlErr.Err
+	}
+
+	return elapsed, connErr
+}
@@ -0,0 +1,106 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Ping(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, testClientToken, r.URL.Query().Get("key"))
+		w.Write(getStub(t, "info"))
+	})
+
+	rtt, err := client.Ping(context.Background())
+
+	assert.Nil(t, err)
+	assert.True(t, rtt > 0)
+}
+
+func TestClient_Ping_responseErrors(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	status := http.StatusUnauthorized
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Oops"}`, status)
+	})
+
+	_, err := client.Ping(context.Background())
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	assert.False(t, errors.Is(err, ErrServerError))
+
+	status = http.StatusBadGateway
+
+	_, err = client.Ping(context.Background())
+	assert.True(t, errors.Is(err, ErrServerError))
+	assert.False(t, errors.Is(err, ErrUnreachable))
+}
+
+func TestClient_Ping_unreachable(t *testing.T) {
+	client := NewClient(nil, testClientToken)
+	client.BaseURL = "http://127.0.0.1:0"
+
+	_, err := client.Ping(context.Background())
+
+	var connErr *ConnectionError
+	assert.True(t, errors.As(err, &connErr))
+	assert.Equal(t, "127.0.0.1:0", connErr.Host)
+	assert.True(t, errors.Is(err, ErrUnreachable))
+	assert.False(t, errors.Is(err, ErrTLS))
+	assert.NotContains(t, err.Error(), testClientToken)
+}
+
+func TestClient_Ping_tls(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := NewClient(nil, testClientToken)
+	client.BaseURL = server.URL
+
+	_, err := client.Ping(context.Background())
+
+	assert.True(t, errors.Is(err, ErrTLS))
+	assert.False(t, errors.Is(err, ErrUnreachable))
+	assert.NotContains(t, err.Error(), testClientToken)
+}
+
+func TestClient_Ping_cancelled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.Ping(ctx)
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestClient_PingStream(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	status := http.StatusNotFound
+	client.BaseURL = "http://127.0.0.1:0"
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "HEAD", r.Method)
+		w.WriteHeader(status)
+	})
+
+	_, err := client.PingStream(context.Background())
+	assert.Nil(t, err)
+
+	status = http.StatusServiceUnavailable
+
+	_, err = client.PingStream(context.Background())
+	assert.True(t, errors.Is(err, ErrServerError))
+}
@@ -1,15 +1,17 @@
 package shodan
 
+import "context"
+
 const (
 	portsPath = "/shodan/ports"
 )
 
 // GetPorts returns a list of port numbers that the crawlers are looking for
-func (c *Client) GetPorts() ([]int, error) {
+func (c *Client) GetPorts(ctx context.Context) ([]int, error) {
 	url := c.buildBaseURL(portsPath, nil)
 
 	var ports []int
-	err := c.executeRequest("GET", url, &ports, nil)
+	err := c.executeRequest(ctx, "GET", url, &ports, nil)
 
 	return ports, err
 }
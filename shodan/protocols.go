@@ -1,15 +1,17 @@
 package shodan
 
+import "context"
+
 const (
 	protocolsPath = "/shodan/protocols"
 )
 
 // GetProtocols returns an object containing all the protocols that can be used when launching an Internet scan
-func (c *Client) GetProtocols() (map[string]string, error) {
+func (c *Client) GetProtocols(ctx context.Context) (map[string]string, error) {
 	url := c.buildBaseURL(protocolsPath, nil)
 
 	var protocols map[string]string
-	err := c.executeRequest("GET", url, &protocols, nil)
+	err := c.executeRequest(ctx, "GET", url, &protocols, nil)
 
 	return protocols, err
 }
@@ -1,11 +1,31 @@
 package shodan
 
+import (
+	"context"
+	"fmt"
+)
+
+// querySearchPageSize is the number of queries "/shodan/query/search" returns per page.
+const querySearchPageSize = 10
+
+// MaxQueryTagsSize is the maximum number of tags "/shodan/query/tags" returns in a single call.
+const MaxQueryTagsSize = 100
+
 const (
 	queryTagsPath   = "/shodan/query/tags"
 	querySearchPath = "/shodan/query/search"
 	queryPath       = "/shodan/query"
 )
 
+// The properties and the orders GetQueries sorts the saved queries by, see QueryOptions.
+const (
+	QuerySortVotes     = "votes"
+	QuerySortTimestamp = "timestamp"
+
+	QueryOrderAsc  = "asc"
+	QueryOrderDesc = "desc"
+)
+
 // QueryTagsMatch represents a matched tag.
 type QueryTagsMatch struct {
 	Value string `json:"value"`
@@ -24,7 +44,7 @@ type QuerySearchMatch struct {
 	Description string   `json:"description"`
 	Query       string   `json:"query"`
 	Votes       int      `json:"votes"`
-	Timestamp   string   `json:"timestamp"`
+	Timestamp   Time     `json:"timestamp"`
 	Tags        []string `json:"tags"`
 }
 
@@ -36,7 +56,7 @@ type QuerySearch struct {
 
 // QueryTagsOptions represents options for GetQueryTags.
 type QueryTagsOptions struct {
-	// The number of tags to return (default: 10).
+	// The number of tags to return between 1 and MaxQueryTagsSize (default: 10).
 	Size int `url:"size,omitempty"`
 }
 
@@ -54,35 +74,51 @@ type QueryOptions struct {
 	// Page number to iterate over results; each page contains 10 items.
 	Page int `url:"page,omitempty"`
 
-	// Sort the list based on a property. Possible values are: votes, timestamp.
+	// Sort the list based on a property, QuerySortVotes or QuerySortTimestamp.
 	Sort string `url:"sort,omitempty"`
 
-	// Whether to sort the list in ascending or descending order. Possible values are: asc, desc.
+	// Whether to sort the list in ascending or descending order, QueryOrderAsc or QueryOrderDesc.
 	Order string `url:"order,omitempty"`
 }
 
 // GetQueryTags obtains a list of popular tags for the saved search queries in Shodan.
-func (c *Client) GetQueryTags(options *QueryTagsOptions) (*QueryTags, error) {
+// The tags are ordered by their count, the most popular one first.
+func (c *Client) GetQueryTags(ctx context.Context, options *QueryTagsOptions) (*QueryTags, error) {
+	if options != nil && (options.Size < 0 || options.Size > MaxQueryTagsSize) {
+		return nil, fmt.Errorf("%w: %d is not between 1 and %d", ErrInvalidTagsSize, options.Size, MaxQueryTagsSize)
+	}
+
 	url := c.buildBaseURL(queryTagsPath, options)
 
 	var queryTags QueryTags
-	err := c.executeRequest("GET", url, &queryTags, nil)
+	err := c.executeRequest(ctx, "GET", url, &queryTags, nil)
 
 	return &queryTags, err
 }
 
+// GetAllQueryTags obtains as many popular tags as Shodan returns in a single call,
+// in the same order as GetQueryTags.
+func (c *Client) GetAllQueryTags(ctx context.Context) ([]*QueryTagsMatch, error) {
+	queryTags, err := c.GetQueryTags(ctx, &QueryTagsOptions{Size: MaxQueryTagsSize})
+	if err != nil {
+		return nil, err
+	}
+
+	return queryTags.Matches, nil
+}
+
 // GetQueries obtains a list of search queries that users have saved in Shodan.
-func (c *Client) GetQueries(options *QueryOptions) (*QuerySearch, error) {
+func (c *Client) GetQueries(ctx context.Context, options *QueryOptions) (*QuerySearch, error) {
 	url := c.buildBaseURL(queryPath, options)
 
 	var querySearch QuerySearch
-	err := c.executeRequest("GET", url, &querySearch, nil)
+	err := c.executeRequest(ctx, "GET", url, &querySearch, nil)
 
 	return &querySearch, err
 }
 
 // SearchQueries searches the directory of search queries that users have saved in Shodan.
-func (c *Client) SearchQueries(options *SearchQueryOptions) (*QuerySearch, error) {
+func (c *Client) SearchQueries(ctx context.Context, options *SearchQueryOptions) (*QuerySearch, error) {
 	if options == nil || options.Query == "" {
 		return nil, ErrInvalidQuery
 	}
@@ -90,7 +126,109 @@ func (c *Client) SearchQueries(options *SearchQueryOptions) (*QuerySearch, error
 	url := c.buildBaseURL(querySearchPath, options)
 
 	var querySearch QuerySearch
-	err := c.executeRequest("GET", url, &querySearch, nil)
+	err := c.executeRequest(ctx, "GET", url, &querySearch, nil)
 
 	return &querySearch, err
 }
+
+// QueryIterator walks through all the pages of the saved queries one query at a time.
+// It's not safe for concurrent use.
+type QueryIterator struct {
+	pager
+
+	ctx       context.Context
+	fetchPage func(page int) (*QuerySearch, error)
+	matches   []*QuerySearchMatch
+	offset    int
+	current   *QuerySearchMatch
+	titles    map[string]bool
+}
+
+// SearchQueriesAll returns an iterator over all the saved queries matching the query. Pages are
+// requested on demand until they run out or ctx is done. The directory shifts while it's being paged
+// through, so a query already returned with the same title is skipped.
+func (c *Client) SearchQueriesAll(ctx context.Context, query string) *QueryIterator {
+	return newQueryIterator(ctx, 1, func(page int) (*QuerySearch, error) {
+		return c.SearchQueries(ctx, &SearchQueryOptions{Query: query, Page: page})
+	})
+}
+
+// GetQueriesAll returns an iterator over all the saved queries in the order of the options, the
+// page of the options is the first one requested. Like SearchQueriesAll, a query already returned
+// with the same title is skipped.
+func (c *Client) GetQueriesAll(ctx context.Context, options *QueryOptions) *QueryIterator {
+	var opts QueryOptions
+	if options != nil {
+		opts = *options
+	}
+
+	return newQueryIterator(ctx, opts.Page, func(page int) (*QuerySearch, error) {
+		opts.Page = page
+		return c.GetQueries(ctx, &opts)
+	})
+}
+
+func newQueryIterator(ctx context.Context, start int, fetchPage func(page int) (*QuerySearch, error)) *QueryIterator {
+	return &QueryIterator{
+		pager:     newPager(querySearchPageSize, start),
+		ctx:       ctx,
+		fetchPage: fetchPage,
+		titles:    make(map[string]bool),
+	}
+}
+
+// Next advances the iterator to the next query fetching a new page when needed.
+// It returns false when there are no more queries or an error occurred.
+func (it *QueryIterator) Next() bool {
+	for {
+		for it.offset >= len(it.matches) {
+			if it.exhausted() {
+				it.current = nil
+				return false
+			}
+
+			it.advance(it.ctx, it.fetch)
+		}
+
+		match := it.matches[it.offset]
+		it.offset++
+
+		if !it.titles[match.Title] {
+			it.titles[match.Title] = true
+			it.current = match
+
+			return true
+		}
+	}
+}
+
+// Match returns the query the iterator currently points at.
+func (it *QueryIterator) Match() *QuerySearchMatch {
+	return it.current
+}
+
+// Total returns the total number of results as reported by the last fetched page.
+func (it *QueryIterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *QueryIterator) Err() error {
+	return it.err
+}
+
+func (it *QueryIterator) fetch(page int) (int, int, error) {
+	if err := it.ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	found, err := it.fetchPage(page)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	it.matches = found.Matches
+	it.offset = 0
+
+	return len(found.Matches), found.Total, nil
+}
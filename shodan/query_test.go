@@ -1,8 +1,13 @@
 package shodan
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,12 +34,35 @@ func TestClient_GetQueryTags(t *testing.T) {
 			},
 		},
 	}
-	queryTags, err := client.GetQueryTags(new(QueryTagsOptions))
+	queryTags, err := client.GetQueryTags(context.Background(), new(QueryTagsOptions))
 
 	assert.Nil(t, err)
 	assert.EqualValues(t, queryTagsExpected, queryTags)
 }
 
+func TestClient_GetQueryTags_invalidSize(t *testing.T) {
+	for _, size := range []int{-1, MaxQueryTagsSize + 1} {
+		_, err := client.GetQueryTags(context.Background(), &QueryTagsOptions{Size: size})
+
+		assert.True(t, errors.Is(err, ErrInvalidTagsSize), "size %d", size)
+	}
+}
+
+func TestClient_GetAllQueryTags(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(queryTagsPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100", r.URL.Query().Get("size"))
+		w.Write(getStub(t, "query_tags"))
+	})
+
+	tags, err := client.GetAllQueryTags(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*QueryTagsMatch{{Count: 76, Value: "webcam"}, {Count: 68, Value: "scada"}}, tags)
+}
+
 func TestClient_SearchQueries(t *testing.T) {
 	setUpTestServe()
 	defer tearDownTestServe()
@@ -51,7 +79,7 @@ func TestClient_SearchQueries(t *testing.T) {
 				Votes:       2,
 				Description: "apache servers US",
 				Title:       "apache servers",
-				Timestamp:   "2013-02-21T02:25:53.018000",
+				Timestamp:   Time{time.Date(2013, time.February, 21, 2, 25, 53, 18000000, time.UTC)},
 				Tags:        []string{"apache"},
 				Query:       "apache country:US",
 			},
@@ -59,27 +87,27 @@ func TestClient_SearchQueries(t *testing.T) {
 				Votes:       5,
 				Description: "exacttouch ...smtp",
 				Title:       "Centos apache",
-				Timestamp:   "2010-03-07T15:47:13",
+				Timestamp:   Time{time.Date(2010, time.March, 7, 15, 47, 13, 0, time.UTC)},
 				Tags:        []string{},
 				Query:       "country:in apache centos hostname:exacttouch.com",
 			},
 		},
 	}
-	searchQuery, err := client.SearchQueries(&SearchQueryOptions{Query: "apache"})
+	searchQuery, err := client.SearchQueries(context.Background(), &SearchQueryOptions{Query: "apache"})
 
 	assert.Nil(t, err)
 	assert.EqualValues(t, searchQueryExpected, searchQuery)
 }
 
 func TestClient_SearchQueries_nilOptions(t *testing.T) {
-	_, err := client.SearchQueries(nil)
+	_, err := client.SearchQueries(context.Background(), nil)
 
 	assert.NotNil(t, err)
 	assert.IsType(t, ErrInvalidQuery, err)
 }
 
 func TestClient_SearchQueries_emptyQueryOption(t *testing.T) {
-	_, err := client.SearchQueries(&SearchQueryOptions{Query: ""})
+	_, err := client.SearchQueries(context.Background(), &SearchQueryOptions{Query: ""})
 
 	assert.NotNil(t, err)
 	assert.IsType(t, ErrInvalidQuery, err)
@@ -101,7 +129,7 @@ func TestClient_GetQueries(t *testing.T) {
 				Votes:       2,
 				Description: "apache servers US",
 				Title:       "apache servers",
-				Timestamp:   "2013-02-21T02:25:53.018000",
+				Timestamp:   Time{time.Date(2013, time.February, 21, 2, 25, 53, 18000000, time.UTC)},
 				Tags:        []string{"apache"},
 				Query:       "apache country:US",
 			},
@@ -109,14 +137,176 @@ func TestClient_GetQueries(t *testing.T) {
 				Votes:       5,
 				Description: "exacttouch ...smtp",
 				Title:       "Centos apache",
-				Timestamp:   "2010-03-07T15:47:13",
+				Timestamp:   Time{time.Date(2010, time.March, 7, 15, 47, 13, 0, time.UTC)},
 				Tags:        []string{},
 				Query:       "country:in apache centos hostname:exacttouch.com",
 			},
 		},
 	}
-	queries, err := client.GetQueries(new(QueryOptions))
+	queries, err := client.GetQueries(context.Background(), new(QueryOptions))
 
 	assert.Nil(t, err)
 	assert.EqualValues(t, queriesExpected, queries)
 }
+
+func TestClient_GetQueries_options(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(queryPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "votes", r.URL.Query().Get("sort"))
+		assert.Equal(t, "asc", r.URL.Query().Get("order"))
+		w.Write(getStub(t, "query_search_results"))
+	})
+
+	options := &QueryOptions{Page: 2, Sort: QuerySortVotes, Order: QueryOrderAsc}
+	_, err := client.GetQueries(context.Background(), options)
+
+	assert.Nil(t, err)
+}
+
+func TestClient_SearchQueries_page(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(querySearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "apache", r.URL.Query().Get("query"))
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		w.Write(getStub(t, "query_search_results"))
+	})
+
+	_, err := client.SearchQueries(context.Background(), &SearchQueryOptions{Query: "apache", Page: 2})
+
+	assert.Nil(t, err)
+}
+
+func TestClient_GetQueryTags_size(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(queryTagsPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "25", r.URL.Query().Get("size"))
+		w.Write(getStub(t, "query_tags"))
+	})
+
+	_, err := client.GetQueryTags(context.Background(), &QueryTagsOptions{Size: 25})
+
+	assert.Nil(t, err)
+}
+
+func TestClient_SearchQueriesAll(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	pages := map[string][]string{
+		"1": {"q0", "q1", "q2", "q3", "q4", "q5", "q6", "q7", "q8", "q9"},
+		// the directory shifted between the pages, so the last query of the 1st page is repeated
+		"2": {"q9", "q10", "q11"},
+	}
+
+	var requested []string
+	mux.HandleFunc(querySearchPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "apache", r.URL.Query().Get("query"))
+
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+
+		found := &QuerySearch{Total: 21}
+		for _, title := range pages[page] {
+			found.Matches = append(found.Matches, &QuerySearchMatch{Title: title})
+		}
+
+		json.NewEncoder(w).Encode(found)
+	})
+
+	it := client.SearchQueriesAll(context.Background(), "apache")
+
+	var titles []string
+	for it.Next() {
+		titles = append(titles, it.Match().Title)
+	}
+
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 21, it.Total())
+	assert.Equal(t, []string{"1", "2", "3"}, requested)
+	assert.Equal(t, []string{"q0", "q1", "q2", "q3", "q4", "q5", "q6", "q7", "q8", "q9", "q10", "q11"}, titles)
+	assert.Nil(t, it.Match())
+}
+
+func TestClient_SearchQueriesAll_emptyQuery(t *testing.T) {
+	it := client.SearchQueriesAll(context.Background(), "")
+
+	assert.False(t, it.Next())
+	assert.Equal(t, ErrInvalidQuery, it.Err())
+}
+
+func TestClient_SearchQueriesAll_cancelled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	mux.HandleFunc(querySearchPath, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		found := &QuerySearch{Total: 100}
+		for i := 0; i < querySearchPageSize; i++ {
+			found.Matches = append(found.Matches, &QuerySearchMatch{Title: fmt.Sprintf("%s-%d", r.URL.Query().Get("page"), i)})
+		}
+
+		json.NewEncoder(w).Encode(found)
+	})
+
+	it := client.SearchQueriesAll(ctx, "apache")
+
+	count := 0
+	for it.Next() {
+		count++
+		if count == querySearchPageSize {
+			cancel()
+		}
+	}
+
+	assert.Equal(t, context.Canceled, it.Err())
+	assert.Equal(t, querySearchPageSize, count)
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_GetQueriesAll(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var requested []string
+	mux.HandleFunc(queryPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, QuerySortVotes, r.URL.Query().Get("sort"))
+		assert.Equal(t, QueryOrderDesc, r.URL.Query().Get("order"))
+
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+
+		found := &QuerySearch{Total: 25}
+		if page == "2" {
+			for i := 0; i < querySearchPageSize; i++ {
+				found.Matches = append(found.Matches, &QuerySearchMatch{Title: fmt.Sprintf("q%d", i)})
+			}
+		} else if page == "3" {
+			found.Matches = append(found.Matches, &QuerySearchMatch{Title: "q10"})
+		}
+
+		json.NewEncoder(w).Encode(found)
+	})
+
+	it := client.GetQueriesAll(context.Background(), &QueryOptions{Page: 2, Sort: QuerySortVotes, Order: QueryOrderDesc})
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 11, count)
+	assert.Equal(t, []string{"2", "3"}, requested)
+}
@@ -0,0 +1,66 @@
+package shodan
+
+import (
+	"context"
+)
+
+// MonitoringQuota is how many IPs the network alerts of the account monitor.
+type MonitoringQuota struct {
+	// Used is the number of IPs covered by the existing alerts.
+	Used int
+
+	// Limit is the number of IPs the plan allows to monitor, a negative limit means there's none.
+	Limit int
+}
+
+// Available returns the number of IPs that can still be monitored, -1 if there's no limit.
+func (q *MonitoringQuota) Available() int {
+	switch {
+	case q.Limit < 0:
+		return -1
+	case q.Used >= q.Limit:
+		return 0
+	default:
+		return q.Limit - q.Used
+	}
+}
+
+// GetMonitoredIPUsage combines the monitored IPs limit of the plan from "/api-info" with the IPs
+// covered by the network alerts of the account, as counted by Shodan in their Size.
+func (c *Client) GetMonitoredIPUsage(ctx context.Context) (*MonitoringQuota, error) {
+	apiInfo, err := c.GetAPIInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := c.GetAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &MonitoringQuota{Limit: apiInfo.UsageLimits.MonitoredIPs}
+	for _, alert := range alerts {
+		quota.Used = addSaturated(quota.Used, alert.Size)
+	}
+
+	return quota, nil
+}
+
+// precheckQuota checks the IPs fit in the monitored IPs quota, a MonitoringQuotaError is returned if not.
+func (c *Client) precheckQuota(ctx context.Context, ips []string) error {
+	requested, err := countIPs(ips)
+	if err != nil {
+		return err
+	}
+
+	quota, err := c.GetMonitoredIPUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if quota.Limit >= 0 && addSaturated(quota.Used, requested) > quota.Limit {
+		return &MonitoringQuotaError{Used: quota.Used, Requested: requested, Limit: quota.Limit}
+	}
+
+	return nil
+}
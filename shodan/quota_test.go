@@ -0,0 +1,116 @@
+package shodan_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return network
+}
+
+func TestClient_GetMonitoredIPUsage(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	quota, err := server.Client().GetMonitoredIPUsage(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, &shodan.MonitoringQuota{Used: 512, Limit: 1024}, quota)
+	assert.Equal(t, 512, quota.Available())
+}
+
+func TestClient_CreateAlertForNetworks_precheckQuota(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	options := &shodan.CreateAlertOptions{PrecheckQuota: true}
+
+	networks := []*net.IPNet{mustParseCIDR(t, "198.51.100.0/24"), mustParseCIDR(t, "203.0.113.0/24")}
+	alert, err := client.CreateAlertForNetworks(context.Background(), "edge", networks, options)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"198.51.100.0/24", "203.0.113.0/24"}, alert.Filters.IP)
+	assert.Equal(t, 512, alert.Size)
+
+	networks = []*net.IPNet{mustParseCIDR(t, "192.0.2.1/32")}
+	_, err = client.CreateAlertForNetworks(context.Background(), "one more", networks, options)
+
+	var quotaErr *shodan.MonitoringQuotaError
+	assert.True(t, errors.Is(err, shodan.ErrMonitoringQuotaExceeded))
+	assert.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, &shodan.MonitoringQuotaError{Used: 1024, Requested: 1, Limit: 1024}, quotaErr)
+	assert.Equal(t, 1, server.Requests("/shodan/alert"))
+
+	_, err = client.CreateAlertForNetworks(context.Background(), "unchecked", networks, nil)
+	assert.Nil(t, err)
+}
+
+func TestClient_GetMonitoredIPUsage_ipv6(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	server.Handle("/shodan/alert/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": "V6", "size": 65536, "filters": {"ip": ["2001:db8::/64"]}}, {"id": "V4", "size": 256}]`))
+	})
+
+	quota, err := server.Client().GetMonitoredIPUsage(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, &shodan.MonitoringQuota{Used: 65792, Limit: 1024}, quota)
+}
+
+func TestClient_CreateAlertWithOptions_precheckQuota(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	_, err := client.CreateAlertWithOptions(context.Background(), "v6", []string{"2001:db8::/64"},
+		&shodan.CreateAlertOptions{Expires: 3600, PrecheckQuota: true})
+
+	var quotaErr *shodan.MonitoringQuotaError
+	assert.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, &shodan.MonitoringQuotaError{Used: 512, Requested: math.MaxInt, Limit: 1024}, quotaErr)
+	assert.Equal(t, 0, server.Requests("/shodan/alert"))
+
+	alert, err := client.CreateAlertWithOptions(context.Background(), "one", []string{"192.0.2.1"},
+		&shodan.CreateAlertOptions{PrecheckQuota: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"192.0.2.1"}, alert.Filters.IP)
+}
+
+func TestClient_CreateAlertForNetworks_unlimited(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	server.Handle("/api-info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"usage_limits": {"monitored_ips": -1}}`))
+	})
+
+	networks := []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+	_, err := server.Client().CreateAlertForNetworks(context.Background(), "everything", networks,
+		&shodan.CreateAlertOptions{PrecheckQuota: true})
+
+	assert.Nil(t, err)
+}
+
+func TestMonitoringQuota_Available(t *testing.T) {
+	assert.Equal(t, 0, (&shodan.MonitoringQuota{Used: 20, Limit: 16}).Available())
+	assert.Equal(t, -1, (&shodan.MonitoringQuota{Used: 20, Limit: -1}).Available())
+}
@@ -0,0 +1,190 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is the rate Shodan allows for the regular API plans, in requests per second.
+const DefaultRateLimit = 1
+
+const (
+	// rateLimitDefaultWait is how long WaitForRateLimit waits when Shodan didn't say, up to
+	// half of it is added on top as jitter.
+	rateLimitDefaultWait = time.Second
+
+	// rateLimitRetries is how many times the iterators and the bulk helpers retry a rate limited request.
+	rateLimitRetries = 3
+)
+
+// rateLimiter is a token bucket shared by all the requests of a client.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a request is allowed to be sent or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	return l.sleep(ctx, l.reserve())
+}
+
+// reserve takes a token and returns how long to wait before the request can be sent.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	l.last = now
+	l.tokens--
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	return delay
+}
+
+// sleep waits for the delay of a reservation, the token is given back if the context is done first.
+func (l *rateLimiter) sleep(ctx context.Context, delay time.Duration) error {
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetRateLimit makes the client pace the REST requests to the given rate allowing bursts of up to burst
+// requests. It's shared by all the goroutines using the client. Streaming requests are not limited.
+// A rate of 0 or less disables the limit, which is the default.
+func (c *Client) SetRateLimit(requestsPerSecond float64, burst int) {
+	if requestsPerSecond <= 0 {
+		c.limiter = nil
+		return
+	}
+
+	c.limiter = newRateLimiter(requestsPerSecond, burst)
+}
+
+// WithRateLimit makes the client pace the REST requests like SetRateLimit does, DefaultRateLimit is
+// the rate of the regular plans. Keys with a higher limit can pass their own rate, or 0 to not limit
+// the requests at all.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.SetRateLimit(requestsPerSecond, burst)
+	}
+}
+
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	delay := c.limiter.reserve()
+	if delay > 0 {
+		c.observeRateLimitWait()
+	}
+
+	return c.limiter.sleep(ctx, delay)
+}
+
+// WaitForRateLimit waits as long as the rate limit error asks to before the request can be retried.
+// When the response had no Retry-After header a second with some jitter is waited. ctx.Err() is
+// returned if ctx is done first. Any other error is returned as is, so the caller can stop retrying,
+// and ErrNotRateLimited is returned for a nil one.
+func WaitForRateLimit(ctx context.Context, err error) error {
+	if err == nil {
+		return ErrNotRateLimited
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !errors.Is(apiErr, ErrRateLimited) {
+		return err
+	}
+
+	delay := apiErr.RetryAfter
+	if delay <= 0 {
+		delay = rateLimitDefaultWait + time.Duration(rand.Int63n(int64(rateLimitDefaultWait/2)))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryRateLimited calls fn until it's not rate limited anymore or rateLimitRetries is reached.
+func retryRateLimited(ctx context.Context, fn func() error) error {
+	err := fn()
+	for i := 0; i < rateLimitRetries && errors.Is(err, ErrRateLimited); i++ {
+		if waitErr := WaitForRateLimit(ctx, err); waitErr != nil {
+			return waitErr
+		}
+
+		err = fn()
+	}
+
+	return err
+}
+
+// parseRetryAfter parses the Retry-After header, both the delay in seconds and the date are supported.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
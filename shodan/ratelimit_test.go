@@ -0,0 +1,129 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_wait(t *testing.T) {
+	limiter := newRateLimiter(20, 2)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		assert.Nil(t, limiter.wait(context.Background()))
+	}
+
+	// 2 requests are allowed right away, the rest are paced at 50ms.
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 190*time.Millisecond, "elapsed %s", elapsed)
+	assert.True(t, elapsed < 400*time.Millisecond, "elapsed %s", elapsed)
+}
+
+func TestRateLimiter_wait_cancelled(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+	assert.Nil(t, limiter.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, limiter.wait(ctx))
+}
+
+func TestClient_SetRateLimit(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(portsPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "ports"))
+	})
+
+	client.SetRateLimit(50, 1)
+	defer client.SetRateLimit(0, 0)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := client.GetPorts(context.Background())
+			assert.Nil(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 75*time.Millisecond, "elapsed %s", elapsed)
+}
+
+func TestClient_SetRateLimit_disabled(t *testing.T) {
+	client := NewClient(nil, testClientToken)
+	client.SetRateLimit(10, 1)
+	assert.NotNil(t, client.limiter)
+
+	client.SetRateLimit(0, 1)
+	assert.Nil(t, client.limiter)
+}
+
+func TestWithRateLimit(t *testing.T) {
+	client := NewClient(nil, testClientToken, WithRateLimit(DefaultRateLimit, 2))
+	assert.NotNil(t, client.limiter)
+	assert.Equal(t, float64(2), client.limiter.burst)
+
+	client = NewClient(nil, testClientToken, WithRateLimit(0, 2))
+	assert.Nil(t, client.limiter)
+}
+
+func TestWaitForRateLimit(t *testing.T) {
+	err := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 20 * time.Millisecond}
+
+	start := time.Now()
+	assert.Nil(t, WaitForRateLimit(context.Background(), err))
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestWaitForRateLimit_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}
+
+	assert.Equal(t, context.Canceled, WaitForRateLimit(ctx, err))
+}
+
+func TestWaitForRateLimit_notRateLimited(t *testing.T) {
+	err := &APIError{StatusCode: http.StatusNotFound, Message: "No information available for that IP."}
+
+	assert.Equal(t, err, WaitForRateLimit(context.Background(), err))
+	assert.Equal(t, ErrNotRateLimited, WaitForRateLimit(context.Background(), nil))
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	calls := 0
+	err := retryRateLimited(context.Background(), func() error {
+		calls++
+		return &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}
+	})
+
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Equal(t, rateLimitRetries+1, calls)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+
+	delay := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, delay > 50*time.Second && delay <= time.Minute, "delay %s", delay)
+}
@@ -0,0 +1,179 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultRetryMaxRetries is the number of retries of RetryPolicy when MaxRetries is 0.
+	DefaultRetryMaxRetries = 3
+
+	// DefaultRetryMinBackoff is the first wait of RetryPolicy when MinBackoff is 0.
+	DefaultRetryMinBackoff = time.Second
+
+	// DefaultRetryMaxBackoff is the longest wait of RetryPolicy when MaxBackoff is 0.
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// DefaultRetryStatusCodes are the status codes RetryPolicy retries when StatusCodes is empty. The POST
+// requests are only retried on 429, a server error doesn't tell whether Shodan already acted on them.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy tells how the client retries the failed REST requests, see WithRetryPolicy.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+
+	// MinBackoff is the wait before the first retry when Shodan didn't send Retry-After, it's doubled
+	// for every further retry up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction of the backoff added on top of it at random, i.e. 0.5 waits between 1 and
+	// 1.5 times the backoff. It's not applied to the Retry-After waits.
+	Jitter float64
+
+	// StatusCodes are the status codes of the responses that are retried.
+	StatusCodes []int
+
+	// RetryPOST retries the POST requests on all the StatusCodes too. A scan or an alert may then be
+	// created twice, spending the credits twice, when Shodan failed after accepting it.
+	RetryPOST bool
+}
+
+// WithRetryPolicy makes the client retry the requests answered with one of the status codes of the policy,
+// honoring the Retry-After header when it's sent. The retries go through the rate limit set by
+// SetRateLimit. The streams are never retried. When the retries run out, a RetryError wrapping the last
+// APIError is returned. The zero values of the policy are replaced with the defaults, but the jitter.
+// The client doesn't retry without a policy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	if policy.MaxRetries == 0 {
+		policy.MaxRetries = DefaultRetryMaxRetries
+	}
+
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultRetryMinBackoff
+	}
+
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	}
+
+	if len(policy.StatusCodes) == 0 {
+		policy.StatusCodes = DefaultRetryStatusCodes
+	}
+
+	policy.StatusCodes = append([]int(nil), policy.StatusCodes...)
+
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+// shouldRetry reports whether the request can be sent again after the error, the body is rewound then.
+func (c *Client) shouldRetry(method string, err error, retries int, body io.Reader) bool {
+	if !c.willRetry(method, err, retries, body) {
+		return false
+	}
+
+	if body == nil {
+		return true
+	}
+
+	_, seekErr := body.(io.Seeker).Seek(0, io.SeekStart)
+
+	return seekErr == nil
+}
+
+// willRetry reports whether the policy retries the request after the error, without rewinding the body.
+func (c *Client) willRetry(method string, err error, retries int, body io.Reader) bool {
+	if c.retryPolicy == nil || retries >= c.retryPolicy.MaxRetries || !c.retryable(method, err) {
+		return false
+	}
+
+	// A body is resent from its beginning, the ones that can't be rewound are sent once.
+	_, rewindable := body.(io.Seeker)
+
+	return body == nil || rewindable
+}
+
+// retryable reports whether the error is a response with one of the status codes of the policy. The
+// requests that aren't idempotent are only retried when they were rate limited, unless RetryPOST is set.
+func (c *Client) retryable(method string, err error) bool {
+	var apiErr *APIError
+	if c.retryPolicy == nil || !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if !idempotent(method) && !c.retryPolicy.RetryPOST && apiErr.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	for _, code := range c.retryPolicy.StatusCodes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// idempotent reports whether sending the request twice has the same effect as sending it once.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// waitRetry waits before the retry, as long as Retry-After says or with the exponential backoff.
+func (c *Client) waitRetry(ctx context.Context, err error, retries int) error {
+	var delay time.Duration
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		delay = apiErr.RetryAfter
+	}
+
+	if delay <= 0 {
+		delay = c.retryPolicy.MinBackoff << uint(retries)
+		if delay > c.retryPolicy.MaxBackoff || delay <= 0 {
+			delay = c.retryPolicy.MaxBackoff
+		}
+
+		if jitter := int64(float64(delay) * c.retryPolicy.Jitter); jitter > 0 {
+			delay += time.Duration(rand.Int63n(jitter))
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,225 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// handleRateLimited answers the first limited requests with 429, the ones after them succeed.
+func handleRateLimited(path string, limited int, retryAfter string) *int {
+	hits := new(int)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if *hits++; *hits <= limited {
+			if retryAfter != "" && *hits == 1 {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+
+			http.Error(w, `{"error": "Rate limit reached"}`, http.StatusTooManyRequests)
+			return
+		}
+
+		fmt.Fprint(w, `{"id": "COMAD88STBX8QNN1"}`)
+	})
+
+	return hits
+}
+
+func TestClient_retryPolicy(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	tracer := new(recordingTracer)
+	WithRetryPolicy(RetryPolicy{MinBackoff: 10 * time.Millisecond})(client)
+	WithTracer(tracer)(client)
+
+	hits := handleRateLimited(scanInternetPath, 2, "1")
+
+	started := time.Now()
+	id, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "COMAD88STBX8QNN1", id)
+	assert.Equal(t, 3, *hits)
+	assert.GreaterOrEqual(t, time.Since(started), time.Second+10*time.Millisecond)
+	assert.Equal(t, RequestResult{StatusCode: 200, Retries: 2}, tracer.spans[0].result)
+}
+
+func TestClient_retryPolicy_body(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond})(client)
+
+	hits := 0
+	mux.HandleFunc(scanInternetPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "22", r.FormValue("port"))
+		assert.Equal(t, "ssh", r.FormValue("protocol"))
+
+		if hits++; hits == 1 {
+			http.Error(w, `{"error": "Rate limit reached"}`, http.StatusTooManyRequests)
+			return
+		}
+
+		fmt.Fprint(w, `{"id": "COMAD88STBX8QNN1"}`)
+	})
+
+	_, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, hits)
+}
+
+func TestClient_retryPolicy_exhausted(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})(client)
+
+	hits := handleRateLimited(scanInternetPath, 10, "")
+
+	_, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	assert.Equal(t, 3, *hits)
+	assert.True(t, errors.Is(err, ErrRetriesExhausted))
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 2, retryErr.Retries)
+	assert.Equal(t, "retries exhausted after 2 retries: shodan: POST /shodan/scan/internet -> 429: Rate limit reached",
+		err.Error())
+}
+
+func TestClient_retryPolicy_cancel(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MinBackoff: time.Minute})(client)
+
+	hits := handleRateLimited(scanInternetPath, 10, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.ScanInternet(ctx, 22, "ssh")
+
+	assert.Equal(t, 1, *hits)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestClient_retryPolicy_disabled(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	hits := handleRateLimited(scanInternetPath, 1, "")
+
+	_, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	assert.Equal(t, 1, *hits)
+	assert.IsType(t, &APIError{}, err)
+}
+
+func TestClient_retryPolicy_serverError(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond})(client)
+
+	hits := 0
+	mux.HandleFunc("/shodan/scan/COMAD88STBX8QNN1", func(w http.ResponseWriter, r *http.Request) {
+		if hits++; hits == 1 {
+			http.Error(w, `{"error": "Bad gateway"}`, http.StatusBadGateway)
+			return
+		}
+
+		fmt.Fprint(w, `{"id": "COMAD88STBX8QNN1", "status": "DONE"}`)
+	})
+
+	status, err := client.GetScanStatus(context.Background(), "COMAD88STBX8QNN1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DONE", status.Status)
+	assert.Equal(t, 2, hits)
+}
+
+func TestClient_retryPolicy_serverErrorPOST(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond})(client)
+
+	hits := 0
+	mux.HandleFunc(scanPath, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Error(w, `{"error": "Bad gateway"}`, http.StatusBadGateway)
+	})
+
+	_, err := client.Scan(context.Background(), []string{"198.20.69.74"})
+
+	assert.Equal(t, 1, hits)
+	assert.True(t, errors.Is(err, ErrServerError))
+	assert.False(t, errors.Is(err, ErrRetriesExhausted))
+}
+
+func TestClient_retryPolicy_retryPOST(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond, RetryPOST: true})(client)
+
+	hits := 0
+	mux.HandleFunc(scanInternetPath, func(w http.ResponseWriter, r *http.Request) {
+		if hits++; hits == 1 {
+			http.Error(w, `{"error": "Bad gateway"}`, http.StatusBadGateway)
+			return
+		}
+
+		fmt.Fprint(w, `{"id": "COMAD88STBX8QNN1"}`)
+	})
+
+	id, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "COMAD88STBX8QNN1", id)
+	assert.Equal(t, 2, hits)
+}
+
+func TestClient_retryPolicy_statusCodes(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond, StatusCodes: []int{http.StatusTooManyRequests}})(client)
+
+	hits := 0
+	mux.HandleFunc(scanInternetPath, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Error(w, `{"error": "Internal error"}`, http.StatusInternalServerError)
+	})
+
+	_, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	assert.Equal(t, 1, hits)
+	assert.True(t, errors.Is(err, ErrServerError))
+	assert.False(t, errors.Is(err, ErrRetriesExhausted))
+}
+
+func TestClient_waitRetry_jitter(t *testing.T) {
+	client := NewClient(nil, testClientToken, WithRetryPolicy(RetryPolicy{
+		MinBackoff: 10 * time.Millisecond,
+		Jitter:     1,
+	}))
+
+	started := time.Now()
+	err := client.waitRetry(context.Background(), &APIError{StatusCode: http.StatusServiceUnavailable}, 0)
+	elapsed := time.Since(started)
+
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, elapsed, 10*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
@@ -1,6 +1,9 @@
 package shodan
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	neturl "net/url"
 	"strconv"
 	"strings"
@@ -9,8 +12,18 @@ import (
 const (
 	scanPath         = "/shodan/scan"
 	scanInternetPath = "/shodan/scan/internet"
+	scanStatusPath   = "/shodan/scan/%s"
 )
 
+// ScanAPI is the part of the client requesting on-demand scans.
+type ScanAPI interface {
+	Scan(ctx context.Context, ip []string) (*CrawlScanStatus, error)
+	ScanInternet(ctx context.Context, port int, protocol string) (string, error)
+	GetScanStatus(ctx context.Context, id string) (*ScanStatus, error)
+}
+
+var _ ScanAPI = (*Client)(nil)
+
 // CrawlScanStatus is the result of a scan.
 type CrawlScanStatus struct {
 	ID          string `json:"id"`
@@ -18,37 +31,169 @@ type CrawlScanStatus struct {
 	CreditsLeft int    `json:"credits_left"`
 }
 
+// String returns a one-line summary of the scan, i.e. "scan BOMA59VSGWX8QJR9 (2 IPs, 183 credits left)".
+func (s *CrawlScanStatus) String() string {
+	return fmt.Sprintf("scan %s (%d IPs, %d credits left)", s.ID, s.Count, s.CreditsLeft)
+}
+
+// LogValue implements slog.LogValuer.
+func (s *CrawlScanStatus) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", s.ID),
+		slog.Int("count", s.Count),
+		slog.Int("credits_left", s.CreditsLeft),
+	)
+}
+
 // Scan requests Shodan to crawl a network.
 // This method uses API scan credits: 1 IP consumes 1 scan credit. You must have a paid API plan (either one-time
 // payment or subscription) in order to use this method.
-func (c *Client) Scan(ip []string) (*CrawlScanStatus, error) {
+// It's a part of ScanAPI.
+func (c *Client) Scan(ctx context.Context, ip []string) (*CrawlScanStatus, error) {
 	url := c.buildBaseURL(scanPath, nil)
 
 	var crawlScanStatus CrawlScanStatus
-	body := neturl.Values{}
-	body.Add("ips", strings.Join(ip, ","))
+	form := neturl.Values{}
+	form.Add("ips", strings.Join(ip, ","))
+
+	estimate, _ := EstimateScanCredits(ip)
+	ctx = withCredits(ctx, estimate.ScanCredits)
 
-	err := c.executeRequest("POST", url, &crawlScanStatus, strings.NewReader(body.Encode()))
+	err := c.executeRequest(ctx, "POST", url, &crawlScanStatus, newFormBody(form))
+	if err == nil {
+		c.observeCredits("scan", crawlScanStatus.CreditsLeft)
+	}
 
 	return &crawlScanStatus, err
 }
 
+// DefaultScanBatchSize is the number of IPs and netblocks submitted per scan by ScanBatches unless told otherwise.
+const DefaultScanBatchSize = 100
+
+// ScanBatchOptions is options for ScanBatches.
+type ScanBatchOptions struct {
+	// BatchSize is the number of IPs and netblocks submitted per scan, DefaultScanBatchSize is used when it's 0.
+	BatchSize int
+
+	// Precheck checks the account can afford scanning all the IPs before submitting any batch, an
+	// InsufficientCreditsError is returned if it can't.
+	Precheck bool
+}
+
+// ScanBatch is a single scan submitted by ScanBatches.
+type ScanBatch struct {
+	// IPs are the IPs and netblocks of the scan.
+	IPs    []string
+	Status *CrawlScanStatus
+}
+
+// BatchScanResult is the scans submitted by ScanBatches.
+type BatchScanResult struct {
+	Batches []*ScanBatch
+
+	// Credits is the number of scan credits consumed by all the scans.
+	Credits int
+}
+
+// IDs returns the IDs of all the submitted scans.
+func (r *BatchScanResult) IDs() []string {
+	ids := make([]string, 0, len(r.Batches))
+	for _, batch := range r.Batches {
+		ids = append(ids, batch.Status.ID)
+	}
+
+	return ids
+}
+
+// ScanBatches splits the IPs and netblocks into batches and submits a scan for every batch, one after another under
+// the rate limit of the client, so large inputs don't hit the request size limits. When a submission fails, the
+// scans submitted so far are returned along with the error, the remaining IPs are not submitted.
+func (c *Client) ScanBatches(ctx context.Context, ips []string, options *ScanBatchOptions) (*BatchScanResult, error) {
+	size := DefaultScanBatchSize
+	if options != nil && options.BatchSize > 0 {
+		size = options.BatchSize
+	}
+
+	if options != nil && options.Precheck {
+		estimate, err := EstimateScanCredits(ips)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.PrecheckCredits(ctx, estimate); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &BatchScanResult{Batches: make([]*ScanBatch, 0, (len(ips)+size-1)/size)}
+	for start := 0; start < len(ips); start += size {
+		end := start + size
+		if end > len(ips) {
+			end = len(ips)
+		}
+
+		batch := ips[start:end:end]
+		status, err := c.Scan(ctx, batch)
+		if err != nil {
+			return result, err
+		}
+
+		result.Batches = append(result.Batches, &ScanBatch{IPs: batch, Status: status})
+		result.Credits += status.Count
+	}
+
+	return result, nil
+}
+
 // ScanInternet requests Shodan to crawl the Internet for a specific port.
 // This method is restricted to security researchers and companies with a Shodan Data license. To apply for access to
 // this method as a researcher, please email jmath@shodan.io with information about your project. Access is restricted
 // to prevent abuse.
-func (c *Client) ScanInternet(port int, protocol string) (string, error) {
+// It's a part of ScanAPI.
+func (c *Client) ScanInternet(ctx context.Context, port int, protocol string) (string, error) {
 	url := c.buildBaseURL(scanInternetPath, nil)
 
 	crawlScanInternetStatus := new(struct {
 		ID string `json:"id"`
 	})
 
-	body := neturl.Values{}
-	body.Add("port", strconv.Itoa(port))
-	body.Add("protocol", protocol)
+	form := neturl.Values{}
+	form.Add("port", strconv.Itoa(port))
+	form.Add("protocol", protocol)
 
-	err := c.executeRequest("POST", url, crawlScanInternetStatus, strings.NewReader(body.Encode()))
+	err := c.executeRequest(ctx, "POST", url, crawlScanInternetStatus, newFormBody(form))
 
 	return crawlScanInternetStatus.ID, err
 }
+
+// The states of a scan, see ScanStatus.
+const (
+	ScanStatusSubmitting = "SUBMITTING"
+	ScanStatusQueue      = "QUEUE"
+	ScanStatusProcessing = "PROCESSING"
+	ScanStatusDone       = "DONE"
+)
+
+// ScanStatus is the progress of a submitted scan.
+type ScanStatus struct {
+	ID      string `json:"id"`
+	Count   int    `json:"count"`
+	Status  string `json:"status"`
+	Created Time   `json:"created"`
+}
+
+// Done reports whether Shodan has finished the scan.
+func (s *ScanStatus) Done() bool {
+	return s.Status == ScanStatusDone
+}
+
+// GetScanStatus returns the progress of the scan, poll it until the status becomes ScanStatusDone.
+// It's a part of ScanAPI.
+func (c *Client) GetScanStatus(ctx context.Context, id string) (*ScanStatus, error) {
+	url := c.buildBaseURL(fmt.Sprintf(scanStatusPath, id), nil)
+
+	var scanStatus ScanStatus
+	err := c.executeRequest(ctx, "GET", url, &scanStatus, nil)
+
+	return &scanStatus, err
+}
@@ -1,12 +1,15 @@
 package shodan
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,7 +37,7 @@ func TestClient_Scan(t *testing.T) {
 		w.Write(getStub(t, "scan"))
 	})
 
-	scanStatus, err := client.Scan(expectedIPs)
+	scanStatus, err := client.Scan(context.Background(), expectedIPs)
 	scanStatusExpected := &CrawlScanStatus{
 		ID:          "BOMA59VSGWX8QJR9",
 		Count:       2,
@@ -66,8 +69,225 @@ func TestClient_ScanInternet(t *testing.T) {
 		fmt.Fprint(w, `{"id": "COMAD88STBX8QNN1"}`)
 	})
 
-	scanInternetStatusID, err := client.ScanInternet(22, "ssh")
+	scanInternetStatusID, err := client.ScanInternet(context.Background(), 22, "ssh")
 
 	assert.Nil(t, err)
 	assert.Equal(t, "COMAD88STBX8QNN1", scanInternetStatusID)
 }
+
+func TestClient_ScanInternet_notEnterprise(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(scanInternetPath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Access denied (403 Forbidden)"}`, http.StatusBadRequest)
+	})
+
+	_, err := client.ScanInternet(context.Background(), 22, "ssh")
+
+	apiErr, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "Access denied (403 Forbidden)", apiErr.Message)
+	assert.Equal(t, "/shodan/scan/internet", apiErr.Endpoint)
+}
+
+func TestClient_Scan_formBody(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(scanPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		w.Write(getStub(t, "scan"))
+	})
+
+	_, err := client.Scan(context.Background(), []string{"82.98.86.174"})
+	assert.Nil(t, err)
+}
+
+func TestClient_GetScanStatus(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	polls := 0
+	mux.HandleFunc(scanPath+"/BOMA59VSGWX8QJR9", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+
+		if polls++; polls < 3 {
+			fmt.Fprint(w, `{"id": "BOMA59VSGWX8QJR9", "count": 2, "status": "PROCESSING", "created": "2021-01-26T08:17:43.794000"}`)
+			return
+		}
+
+		w.Write(getStub(t, "scan_status"))
+	})
+
+	var scanStatus *ScanStatus
+	for {
+		var err error
+		scanStatus, err = client.GetScanStatus(context.Background(), "BOMA59VSGWX8QJR9")
+		assert.Nil(t, err)
+
+		if err != nil || scanStatus.Done() {
+			break
+		}
+
+		assert.Equal(t, ScanStatusProcessing, scanStatus.Status)
+	}
+
+	assert.Equal(t, 3, polls)
+	assert.Equal(t, "BOMA59VSGWX8QJR9", scanStatus.ID)
+	assert.Equal(t, 2, scanStatus.Count)
+	assert.Equal(t, ScanStatusDone, scanStatus.Status)
+	assert.Equal(t, time.Date(2021, 1, 26, 8, 17, 43, 794000000, time.UTC), scanStatus.Created.Time)
+}
+
+func TestClient_GetScanStatus_notFound(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(scanPath+"/UNKNOWN", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Scan not found"}`, http.StatusNotFound)
+	})
+
+	_, err := client.GetScanStatus(context.Background(), "UNKNOWN")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "/shodan/scan/{id}", err.(*APIError).Endpoint)
+}
+
+func TestCrawlScanStatus_String(t *testing.T) {
+	scanStatus := &CrawlScanStatus{ID: "BOMA59VSGWX8QJR9", Count: 2, CreditsLeft: 183}
+
+	assert.Equal(t, "scan BOMA59VSGWX8QJR9 (2 IPs, 183 credits left)", scanStatus.String())
+	assert.Equal(t, "[id=BOMA59VSGWX8QJR9 count=2 credits_left=183]", scanStatus.LogValue().String())
+}
+
+func newScanBatchHandler(failAt int) (http.HandlerFunc, *[][]string) {
+	var submitted [][]string
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		ips := strings.Split(r.FormValue("ips"), ",")
+		submitted = append(submitted, ips)
+
+		if len(submitted) == failAt {
+			http.Error(w, `{"error": "Insufficient scan credits"}`, http.StatusForbidden)
+			return
+		}
+
+		fmt.Fprintf(w, `{"id": "SCAN%d", "count": %d, "credits_left": %d}`, len(submitted), len(ips), 1000-len(ips))
+	}, &submitted
+}
+
+func testIPs(n int) []string {
+	ips := make([]string, n)
+	for i := range ips {
+		ips[i] = "10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256)
+	}
+
+	return ips
+}
+
+func TestClient_ScanBatches(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handler, submitted := newScanBatchHandler(0)
+	mux.HandleFunc(scanPath, handler)
+
+	ips := testIPs(250)
+	result, err := client.ScanBatches(context.Background(), ips, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SCAN1", "SCAN2", "SCAN3"}, result.IDs())
+	assert.Equal(t, 250, result.Credits)
+	assert.Equal(t, [][]string{ips[:100], ips[100:200], ips[200:]}, *submitted)
+
+	for i, batch := range result.Batches {
+		assert.Equal(t, (*submitted)[i], batch.IPs)
+		assert.Equal(t, len(batch.IPs), batch.Status.Count)
+	}
+}
+
+func TestClient_ScanBatches_batchSize(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handler, submitted := newScanBatchHandler(0)
+	mux.HandleFunc(scanPath, handler)
+
+	result, err := client.ScanBatches(context.Background(), testIPs(5), &ScanBatchOptions{BatchSize: 2})
+
+	assert.Nil(t, err)
+	assert.Len(t, result.Batches, 3)
+	assert.Len(t, (*submitted)[2], 1)
+
+	result, err = client.ScanBatches(context.Background(), nil, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Batches)
+}
+
+func TestClient_ScanBatches_failure(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handler, submitted := newScanBatchHandler(2)
+	mux.HandleFunc(scanPath, handler)
+
+	ips := testIPs(5)
+	result, err := client.ScanBatches(context.Background(), ips, &ScanBatchOptions{BatchSize: 2})
+
+	assert.Equal(t, &APIError{
+		StatusCode: http.StatusForbidden,
+		Message:    "Insufficient scan credits",
+		Body:       []byte(`{"error": "Insufficient scan credits"}` + "\n"),
+		Method:     "POST",
+		Endpoint:   "/shodan/scan",
+		Path:       "/shodan/scan",
+	}, err)
+	assert.Len(t, *submitted, 2)
+	assert.Equal(t, []string{"SCAN1"}, result.IDs())
+	assert.Equal(t, ips[:2], result.Batches[0].IPs)
+	assert.Equal(t, 2, result.Credits)
+}
+
+func TestClient_ScanBatches_precheck(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+
+	handler, submitted := newScanBatchHandler(0)
+	mux.HandleFunc(scanPath, handler)
+
+	result, err := client.ScanBatches(context.Background(), testIPs(300), &ScanBatchOptions{Precheck: true})
+
+	var creditsErr *InsufficientCreditsError
+	assert.True(t, errors.As(err, &creditsErr))
+	assert.Equal(t, CreditEstimate{ScanCredits: 46}, creditsErr.Shortfall())
+	assert.Nil(t, result)
+	assert.Empty(t, *submitted)
+
+	result, err = client.ScanBatches(context.Background(), testIPs(254), &ScanBatchOptions{Precheck: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 254, result.Credits)
+}
+
+func TestClient_ScanBatches_rateLimit(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handler, _ := newScanBatchHandler(0)
+	mux.HandleFunc(scanPath, handler)
+
+	client.SetRateLimit(1, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	result, err := client.ScanBatches(ctx, testIPs(3), &ScanBatchOptions{BatchSize: 1})
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, []string{"SCAN1"}, result.IDs())
+}
@@ -1,16 +1,18 @@
 package shodan
 
+import "context"
+
 const (
 	servicesPath = "/shodan/services"
 )
 
 // GetServices returns an object containing all the services that the Shodan crawlers look at
 // It can also be used as a quick and practical way to resolve a port number to the name of a service
-func (c *Client) GetServices() (map[string]string, error) {
+func (c *Client) GetServices(ctx context.Context) (map[string]string, error) {
 	url := c.buildBaseURL(servicesPath, nil)
 
 	var services map[string]string
-	err := c.executeRequest("GET", url, &services, nil)
+	err := c.executeRequest(ctx, "GET", url, &services, nil)
 
 	return services, err
 }
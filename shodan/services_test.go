@@ -1,6 +1,7 @@
 package shodan
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -21,7 +22,7 @@ func TestClient_GetServices(t *testing.T) {
 		"8181": "GlassFish Server",
 		"53":   "DNS",
 	}
-	services, err := client.GetServices()
+	services, err := client.GetServices(context.Background())
 
 	assert.Nil(t, err)
 	assert.Len(t, services, len(servicesExpected))
@@ -2,38 +2,62 @@ package shodan
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"io/ioutil"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"fmt"
 	"github.com/google/go-querystring/query"
 )
 
+// DefaultExploitPathPrefix is the path the Exploits API is served under in production.
+const DefaultExploitPathPrefix = "/api"
+
 const (
 	baseURL        = "https://api.shodan.io"
-	exploitBaseURL = "https://exploits.shodan.io/api"
+	exploitBaseURL = "https://exploits.shodan.io" + DefaultExploitPathPrefix
 	streamBaseURL  = "https://stream.shodan.io"
 )
 
-func getErrorFromResponse(r *http.Response) error {
+// errorBodyLimit is the maximum number of bytes read from an error response.
+const errorBodyLimit = 64 << 10
+
+func (c *Client) getErrorFromResponse(r *http.Response) error {
 	errorResponse := new(struct {
 		Error string `json:"error"`
 	})
-	message, err := ioutil.ReadAll(r.Body)
-	if err == nil {
-		if err := json.Unmarshal(message, errorResponse); err == nil {
-			return errors.New(errorResponse.Error)
-		}
+	message, err := ioutil.ReadAll(io.LimitReader(r.Body, errorBodyLimit))
+	if err != nil {
+		return ErrBodyRead
+	}
 
-		return errors.New(strings.TrimSpace(string(message)))
+	apiErr := &APIError{
+		StatusCode: r.StatusCode,
+		Message:    strings.TrimSpace(string(message)),
+		Body:       message,
+		RetryAfter: parseRetryAfter(r.Header.Get("Retry-After")),
+	}
+	if err := json.Unmarshal(message, errorResponse); err == nil {
+		apiErr.Message = errorResponse.Error
+	}
+
+	if r.Request != nil {
+		apiErr.Method = r.Request.Method
+		apiErr.Path = r.Request.URL.Path
+		apiErr.Endpoint = endpointTemplate(c.endpointPath(r.Request.URL.String()))
 	}
 
-	return ErrBodyRead
+	return apiErr
 }
 
 // Client represents Shodan HTTP client
@@ -45,22 +69,63 @@ type Client struct {
 	StreamChan     chan HostData
 
 	Client *http.Client
+
+	exploitPathPrefix *string
+
+	limiter *rateLimiter
+	flights *flightGroup
+
+	streamClient   *http.Client
+	streamDialer   *pinnedDialer
+	streamCounters streamCounters
+
+	fastDecoder bool
+
+	metrics Metrics
+	tracer  Tracer
+	logger  *slog.Logger
+
+	vars     *clientVars
+	varsName string
+
+	clientTrace   func(ctx context.Context) *httptrace.ClientTrace
+	requestHooks  []func(req *http.Request)
+	responseHooks []func(res *http.Response, elapsed time.Duration, err error)
+
+	triggerRules *triggerRulesCache
+	resolver     *net.Resolver
+
+	transportSelector func(req *http.Request) http.RoundTripper
+	retryPolicy       *RetryPolicy
+	streamErrors      chan<- error
 }
 
+// ClientOption configures the client created by NewClient.
+type ClientOption func(*Client)
+
 // NewClient creates new Shodan client
-func NewClient(client *http.Client, token string) *Client {
+func NewClient(client *http.Client, token string, options ...ClientOption) *Client {
 	if client == nil {
 		client = http.DefaultClient
 	}
 
-	return &Client{
+	c := &Client{
 		Token:          token,
 		BaseURL:        baseURL,
 		ExploitBaseURL: exploitBaseURL,
 		StreamBaseURL:  streamBaseURL,
 		StreamChan:     make(chan HostData),
 		Client:         client,
+		fastDecoder:    fastDecoderDefault,
+
+		triggerRules: &triggerRulesCache{ttl: DefaultTriggerRulesTTL},
+	}
+
+	for _, option := range options {
+		option(c)
 	}
+
+	return c
 }
 
 func (c *Client) buildURL(base, path string, params interface{}) string {
@@ -85,33 +150,125 @@ func (c *Client) buildBaseURL(path string, params interface{}) string {
 	return c.buildURL(c.BaseURL, path, params)
 }
 
+// WithExploitPathPrefix changes the path the Exploits API is served under, i.e. "/api/v2". The prefix
+// replaces the path of ExploitBaseURL, an empty prefix makes the endpoints relative to its host directly.
+// Without the option ExploitBaseURL is used as is.
+func WithExploitPathPrefix(prefix string) ClientOption {
+	return func(c *Client) {
+		c.exploitPathPrefix = &prefix
+	}
+}
+
 func (c *Client) buildExploitBaseURL(path string, params interface{}) string {
-	return c.buildURL(c.ExploitBaseURL, path, params)
+	return c.buildURL(c.exploitBaseURL(), path, params)
+}
+
+// exploitBaseURL returns ExploitBaseURL with the path prefix applied.
+func (c *Client) exploitBaseURL() string {
+	base := c.ExploitBaseURL
+	if c.exploitPathPrefix != nil {
+		if parsed, err := url.Parse(base); err == nil {
+			parsed.Path, parsed.RawPath = "", ""
+			base = strings.TrimRight(parsed.String(), "/")
+		}
+
+		if prefix := strings.Trim(*c.exploitPathPrefix, "/"); prefix != "" {
+			base += "/" + prefix
+		}
+	}
+
+	return base
 }
 
 func (c *Client) buildStreamBaseURL(path string, params interface{}) string {
 	return c.buildURL(c.StreamBaseURL, path, params)
 }
 
-func (c *Client) sendRequest(method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, path, body)
+// jsonBody is a request body sent with the JSON content type, the other bodies are sent as a form.
+type jsonBody struct {
+	*bytes.Reader
+}
+
+// newJSONBody encodes the payload as the body of a request.
+func newJSONBody(payload interface{}) (io.Reader, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonBody{Reader: bytes.NewReader(b)}, nil
+}
+
+// formBody is a request body sent as a URL-encoded form.
+type formBody struct {
+	*strings.Reader
+}
+
+// newFormBody encodes the values as the body of a request.
+func newFormBody(values url.Values) io.Reader {
+	return &formBody{Reader: strings.NewReader(values.Encode())}
+}
+
+func (c *Client) sendRequest(ctx context.Context, method, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	return c.sendRequestWith(ctx, c.Client, method, path, body, header)
+}
+
+// sendRequestWith sends the request with the client, the header is added to the one of the request.
+func (c *Client) sendRequestWith(ctx context.Context, client *http.Client, method, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	ctx, timings := c.traceRequest(ctx)
+
+	contentType := "application/x-www-form-urlencoded"
+	switch payload := body.(type) {
+	case *jsonBody:
+		body = payload.Reader
+		contentType = "application/json"
+	case *formBody:
+		body = payload.Reader
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
 	if err != nil {
 		return nil, err
 	}
 
 	if body != nil {
-		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add("Content-Type", contentType)
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	client, err = c.selectTransport(client, req)
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := c.Client.Do(req)
+	for _, hook := range c.requestHooks {
+		hook(req)
+	}
+
+	started := time.Now()
+
+	res, err := client.Do(req)
 	if err != nil {
+		c.callResponseHooks(nil, started, err)
 		return nil, err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, getErrorFromResponse(res)
+	storeResponse(ctx, res, timings)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+
+		err = c.getErrorFromResponse(res)
+		c.callResponseHooks(res, started, err)
+
+		return nil, err
 	}
 
+	c.callResponseHooks(res, started, nil)
+
 	return res, nil
 }
 
@@ -121,31 +278,170 @@ func (c *Client) parseResponse(destination interface{}, body io.Reader) error {
 	if w, ok := destination.(io.Writer); ok {
 		_, err = io.Copy(w, body)
 	} else {
-		decoder := json.NewDecoder(body)
+		sniffer := &errorSniffer{reader: body}
+		decoder := json.NewDecoder(sniffer)
 		err = decoder.Decode(destination)
+
+		if sniffedErr := sniffer.sniffedError(); sniffedErr != nil {
+			return sniffedErr
+		}
 	}
 
 	return err
 }
 
-func (c *Client) executeRequest(method, path string, destination interface{}, body io.Reader) error {
-	res, err := c.sendRequest(method, path, body)
+// errorSnifferLimit is the maximum size of a body that is checked for the error field.
+// Shodan's error responses are tiny, there is no point in looking into larger ones.
+const errorSnifferLimit = 512
+
+// errorSniffer keeps the beginning of the response body while it's being decoded, so a
+// successful response that is actually {"error": "..."} can be detected without buffering
+// the whole body.
+type errorSniffer struct {
+	reader io.Reader
+	head   []byte
+	size   int
+}
+
+func (s *errorSniffer) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	if s.size < errorSnifferLimit {
+		keep := n
+		if s.size+keep > errorSnifferLimit {
+			keep = errorSnifferLimit - s.size
+		}
+
+		s.head = append(s.head, p[:keep]...)
+	}
+
+	s.size += n
+
+	return n, err
+}
+
+func (s *errorSniffer) sniffedError() error {
+	if s.size > errorSnifferLimit {
+		return nil
+	}
+
+	return checkErrorField(s.head)
+}
+
+func (c *Client) executeRequest(ctx context.Context, method, path string, destination interface{}, body io.Reader) error {
+	return c.executeRequestWith(ctx, method, path, body, func(body io.Reader) error {
+		if destination == nil {
+			return nil
+		}
+
+		return c.parseResponse(destination, body)
+	})
+}
+
+// executeDecoderRequest sends the request and lets fn decode the response body on its own.
+// Whatever is left unread is drained when fn fails, so the connection can be reused.
+func (c *Client) executeDecoderRequest(ctx context.Context, method, path string, fn func(*json.Decoder) error) error {
+	return c.executeRequestWith(ctx, method, path, nil, func(body io.Reader) error {
+		if err := fn(json.NewDecoder(body)); err != nil {
+			io.Copy(ioutil.Discard, body)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// executeRequestWith sends the request and passes the response body to handle. Identical concurrent
+// GET requests share a single response when deduplication is enabled. The call options attached to
+// ctx are applied first, so only the requests with the same parameters are shared.
+func (c *Client) executeRequestWith(ctx context.Context, method, path string, body io.Reader, handle func(io.Reader) error) error {
+	path = applyCallOptions(ctx, path)
+
+	if c.flights == nil || method != "GET" || body != nil {
+		return c.performRequest(ctx, method, path, body, nil, handle)
+	}
+
+	shared, err := c.flights.do(ctx, method+" "+path, func(ctx context.Context) ([]byte, error) {
+		var buf bytes.Buffer
+		err := c.performRequest(ctx, method, path, nil, nil, func(body io.Reader) error {
+			_, err := io.Copy(&buf, body)
+			return err
+		})
+
+		return buf.Bytes(), err
+	})
+
 	if err != nil {
 		return err
 	}
 
-	defer res.Body.Close()
+	return handle(bytes.NewReader(shared))
+}
 
-	if destination == nil {
-		return nil
+// performRequest sends the request under the rate limit, retrying it as told by the retry policy, and passes
+// the response body to handle.
+func (c *Client) performRequest(ctx context.Context, method, path string, body io.Reader, header http.Header, handle func(io.Reader) error) error {
+	if err := c.waitRateLimit(ctx); err != nil {
+		return err
 	}
 
-	return c.parseResponse(destination, res.Body)
+	ctx, finish := c.startRequestSpan(ctx, method, path)
+
+	err := c.attemptRequest(ctx, method, path, body, header, 1, handle)
+
+	retries := 0
+	for ; c.shouldRetry(method, err, retries, body); retries++ {
+		if waitErr := c.waitRetry(ctx, err, retries); waitErr != nil {
+			err = waitErr
+			break
+		}
+
+		if waitErr := c.waitRateLimit(ctx); waitErr != nil {
+			err = waitErr
+			break
+		}
+
+		c.observeRetry()
+		err = c.attemptRequest(ctx, method, path, body, header, retries+2, handle)
+	}
+
+	if retries > 0 && c.retryable(method, err) {
+		err = &RetryError{Retries: retries, Err: err}
+	}
+
+	finish(err, retries)
+
+	return err
+}
+
+// attemptRequest sends the request once and passes the response body to handle.
+func (c *Client) attemptRequest(ctx context.Context, method, path string, body io.Reader, header http.Header, attempt int, handle func(io.Reader) error) error {
+	started := time.Now()
+	res, err := c.sendRequest(ctx, method, path, body, header)
+	if err != nil {
+		c.observeRequest(method, path, started, err)
+		c.logRequest(ctx, method, path, attempt, 0, started, err, c.willRetry(method, err, attempt-1, body))
+		return err
+	}
+
+	defer res.Body.Close()
+
+	err = handle(res.Body)
+	c.observeRequest(method, path, started, err)
+	c.logRequest(ctx, method, path, attempt, res.StatusCode, started, err, false)
+
+	return err
 }
 
-func (c *Client) executeStreamRequest(method, path string, ch chan []byte) error {
-	res, err := c.sendRequest(method, path, nil)
+// executeStreamRequest subscribes to the stream and sends its messages to ch, the span is ended
+// once the stream is over. The body is closed and ch is closed when the stream ends or ctx is done.
+func (c *Client) executeStreamRequest(ctx context.Context, span StreamSpan, method, path string, ch chan []byte) error {
+	path = applyCallOptions(ctx, path)
+
+	res, err := c.sendRequestWith(c.traceStream(ctx), c.streamHTTPClient(), method, path, nil, nil)
 	if err != nil {
+		c.logStreamEnd(path, err)
+		c.observeStreamEnd()
+		span.End(err)
 		return err
 	}
 
@@ -153,16 +449,78 @@ func (c *Client) executeStreamRequest(method, path string, ch chan []byte) error
 		reader := bufio.NewReader(res.Body)
 
 		for {
-			chunk, err := reader.ReadBytes('\n')
-			if err != nil {
-				res.Body.Close()
-				close(ch)
-				break
+			chunk, err := readStreamMessage(reader)
+			if chunk != nil {
+				select {
+				case ch <- chunk:
+					if err == nil {
+						continue
+					}
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
 			}
 
-			ch <- chunk
+			res.Body.Close()
+			c.logStreamEnd(path, err)
+			c.observeStreamEnd()
+			span.End(streamEnd(err))
+			close(ch)
+
+			return
 		}
 	}()
 
 	return nil
 }
+
+// streamBufferMaxSize is the capacity above which buffers are not returned to the pool,
+// so a single huge banner doesn't keep a lot of memory alive.
+const streamBufferMaxSize = 1 << 20
+
+var streamBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// readStreamMessage reads a single line from the stream. The line is assembled in a pooled
+// buffer and copied out, so the returned slice is owned by the caller. A last line without
+// the newline is returned along with io.EOF.
+func readStreamMessage(reader *bufio.Reader) ([]byte, error) {
+	buf := streamBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	defer func() {
+		if buf.Cap() <= streamBufferMaxSize {
+			streamBufferPool.Put(buf)
+		}
+	}()
+
+	for {
+		line, err := reader.ReadSlice('\n')
+		buf.Write(line)
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err == io.EOF && buf.Len() > 0 {
+			message := make([]byte, buf.Len())
+			copy(message, buf.Bytes())
+
+			return message, err
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		break
+	}
+
+	message := make([]byte, buf.Len())
+	copy(message, buf.Bytes())
+
+	return message, nil
+}
@@ -1,19 +1,29 @@
 package shodan
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"time"
 )
 
 const (
 	testClientToken = "TEST_TOKEN"
-	stubsDir        = "stubs"
+	stubsDir        = "shodantest/fixtures"
 )
 
 var (
@@ -31,7 +41,7 @@ func setUpTestServe() {
 	client.StreamBaseURL = server.URL
 }
 
-func getStub(t *testing.T, stubName string) []byte {
+func getStub(t testing.TB, stubName string) []byte {
 	stubPath := fmt.Sprintf("%s/%s.json", stubsDir, stubName)
 	content, err := ioutil.ReadFile(stubPath)
 	if err != nil {
@@ -106,6 +116,36 @@ func TestClient_buildExploitBaseURL(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestClient_buildExploitBaseURL_prefix(t *testing.T) {
+	tests := []struct {
+		base     string
+		prefix   string
+		expected string
+	}{
+		{"https://exploits.shodan.io/api", "/api/v2", "https://exploits.shodan.io/api/v2/search"},
+		{"https://exploits.shodan.io/", "/api/v2/", "https://exploits.shodan.io/api/v2/search"},
+		{"https://exploits.shodan.io/api", "v2", "https://exploits.shodan.io/v2/search"},
+		{"https://exploits.shodan.io/api/", "", "https://exploits.shodan.io/search"},
+		{"https://exploits.shodan.io", "/", "https://exploits.shodan.io/search"},
+	}
+
+	for _, test := range tests {
+		client := NewClient(nil, testClientToken, WithExploitPathPrefix(test.prefix))
+		client.ExploitBaseURL = test.base
+
+		expected := test.expected + "?key=" + testClientToken
+		assert.Equal(t, expected, client.buildExploitBaseURL(exploitSearchPath, nil), "prefix %q", test.prefix)
+	}
+}
+
+func TestClient_buildExploitBaseURL_legacy(t *testing.T) {
+	client := NewClient(nil, testClientToken)
+	assert.Equal(t, "https://exploits.shodan.io/api/search?key="+testClientToken, client.buildExploitBaseURL(exploitSearchPath, nil))
+
+	client.ExploitBaseURL = "https://proxy.example.com/shodan/api"
+	assert.Equal(t, "https://proxy.example.com/shodan/api/search?key="+testClientToken, client.buildExploitBaseURL(exploitSearchPath, nil))
+}
+
 func TestClient_buildStreamBaseURL(t *testing.T) {
 	client := NewClient(nil, testClientToken)
 	expected := client.StreamBaseURL + "/test-stream-url-building/?key=" + testClientToken
@@ -116,7 +156,7 @@ func TestClient_buildStreamBaseURL(t *testing.T) {
 
 func TestClient_sendRequest_invalidURL(t *testing.T) {
 	client := NewClient(nil, testClientToken)
-	_, err := client.sendRequest("GET", ":/1232.22", nil)
+	_, err := client.sendRequest(context.Background(), "GET", ":/1232.22", nil, nil)
 	assert.NotNil(t, err)
 }
 
@@ -136,9 +176,15 @@ func TestClient_executeRequest_textUnauthorized(t *testing.T) {
 	})
 
 	url := client.buildBaseURL(unauthorizedPath, nil)
-	err := client.executeRequest("GET", url, nil, nil)
-
-	assert.NotNil(t, err)
+	err := client.executeRequest(context.Background(), "GET", url, nil, nil)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, strings.TrimSpace(errorText), apiErr.Message)
+	assert.Equal(t, []byte(errorText+"\n"), apiErr.Body)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.NotErrorIs(t, err, ErrNotFound)
 }
 
 func TestClient_executeRequest_jsonNotFound(t *testing.T) {
@@ -152,9 +198,90 @@ func TestClient_executeRequest_jsonNotFound(t *testing.T) {
 	})
 
 	url := client.buildBaseURL(notFoundPath, nil)
-	err := client.executeRequest("GET", url, nil, nil)
+	err := client.executeRequest(context.Background(), "GET", url, nil, nil)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "No information available for that IP.", apiErr.Message)
+	assert.Equal(t, []byte(`{"error": "No information available for that IP."}`+"\n"), apiErr.Body)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+}
 
-	assert.NotNil(t, err)
+func TestClient_executeRequest_successStatus(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/created", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "CREATED"}`)
+	})
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := client.executeRequest(context.Background(), "POST", client.buildBaseURL("/created", nil), &created, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "CREATED", created.ID)
+}
+
+func TestClient_executeRequest_errorMessage(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(hostPath+"/1.1.1.1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+	})
+
+	_, err := client.GetServicesForHost(context.Background(), "1.1.1.1", nil)
+
+	assert.Equal(t, "shodan: GET /shodan/host/{ip} -> 404: No information available for that IP.", err.Error())
+	assert.NotContains(t, err.Error(), testClientToken)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "/shodan/host/1.1.1.1", apiErr.Path)
+	assert.Equal(t, "GET", apiErr.Method)
+}
+
+func TestClient_executeRequest_noCredits(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/http-error/403", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getStub(t, "errors/no_credits"))
+	})
+
+	url := client.buildBaseURL("/http-error/403", nil)
+	err := client.executeRequest(context.Background(), "GET", url, nil, nil)
+
+	assert.ErrorIs(t, err, ErrInsufficientCredits)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestAPIError_Is_insufficientCredits(t *testing.T) {
+	testCases := []struct {
+		err      *APIError
+		expected bool
+	}{
+		{&APIError{StatusCode: http.StatusPaymentRequired, Message: "Payment required"}, true},
+		{&APIError{StatusCode: http.StatusUnauthorized, Message: "Insufficient query credits"}, true},
+		{&APIError{StatusCode: http.StatusForbidden, Message: "Access denied"}, false},
+		{&APIError{StatusCode: http.StatusTooManyRequests, Message: "Out of credits"}, false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, errors.Is(tc.err, ErrInsufficientCredits), tc.err.Error())
+	}
+}
+
+func TestAPIError_Error_noRequest(t *testing.T) {
+	err := &APIError{StatusCode: http.StatusForbidden, Message: "Access denied"}
+
+	assert.Equal(t, "shodan: 403: Access denied", err.Error())
 }
 
 func TestClient_executeStreamRequest_success(t *testing.T) {
@@ -183,7 +310,7 @@ func TestClient_executeStreamRequest_success(t *testing.T) {
 	url := client.buildStreamBaseURL(streamPath, nil)
 
 	bytesChan := make(chan []byte)
-	err := client.executeStreamRequest("GET", url, bytesChan)
+	err := client.executeStreamRequest(context.Background(), noopStreamSpan{}, "GET", url, bytesChan)
 	assert.Nil(t, err)
 
 	receivedChunks := 0
@@ -205,7 +332,379 @@ func TestClient_executeStreamRequest_errorRequest(t *testing.T) {
 	url := client.buildStreamBaseURL("/stream/error", nil)
 
 	bytesChan := make(chan []byte)
-	err := client.executeStreamRequest("GET", url, bytesChan)
+	err := client.executeStreamRequest(context.Background(), noopStreamSpan{}, "GET", url, bytesChan)
+
+	assert.NotNil(t, err)
+}
+
+// handleEndlessStream streams a banner every 10ms until the request is cancelled, the returned
+// channel is closed then.
+func handleEndlessStream(path string) <-chan struct{} {
+	cancelled := make(chan struct{})
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		defer close(cancelled)
+
+		for {
+			fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 80}`)
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
+
+	return cancelled
+}
+
+func TestClient_executeStreamRequest_cancel(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	cancelled := handleEndlessStream("/stream/endless")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bytesChan := make(chan []byte)
+	err := client.executeStreamRequest(ctx, noopStreamSpan{}, "GET", client.buildStreamBaseURL("/stream/endless", nil), bytesChan)
+	assert.Nil(t, err)
+
+	<-bytesChan
+	<-bytesChan
+	cancel()
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the response body hasn't been closed")
+	}
+
+	timeout := time.After(5 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-bytesChan:
+		case <-timeout:
+			t.Fatal("the channel hasn't been closed")
+		}
+	}
+}
+
+func TestClient_GetBanners_cancel(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	cancelled := handleEndlessStream(bannersPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client.GetBanners(ctx)
+
+	banner := <-client.StreamChan
+	assert.Equal(t, "1.1.1.1", banner.IP)
+	cancel()
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the response body hasn't been closed")
+	}
+
+	timeout := time.After(5 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-client.StreamChan:
+		case <-timeout:
+			t.Fatal("the channel hasn't been closed")
+		}
+	}
+}
+
+func TestClient_GetBanners_deadline(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(bannersPath, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client.GetBanners(ctx)
+
+	select {
+	case _, open := <-client.StreamChan:
+		assert.False(t, open)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel hasn't been closed")
+	}
+}
 
+func TestClient_executeRequest_deadline(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := client.executeRequest(ctx, "GET", client.buildBaseURL("/slow", nil), nil, nil)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
+
+func TestClient_executeRequest_errorWithSuccessStatus(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	errorPath := "/http-error/200"
+
+	mux.HandleFunc(errorPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"error": "Invalid IP"}`)
+	})
+
+	url := client.buildBaseURL(errorPath, nil)
+
+	var host Host
+	err := client.executeRequest(context.Background(), "GET", url, &host, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "Invalid IP", err.Error())
+
+	var ports []int
+	err = client.executeRequest(context.Background(), "GET", url, &ports, nil)
 	assert.NotNil(t, err)
+	assert.Equal(t, "Invalid IP", err.Error())
+}
+
+func TestClient_executeRequest_largeBodyWithErrorField(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	largePath := "/large"
+	padding := strings.Repeat("a", errorSnifferLimit)
+
+	mux.HandleFunc(largePath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"error": "", "padding": "%s"}`, padding)
+	})
+
+	url := client.buildBaseURL(largePath, nil)
+
+	var destination map[string]string
+	err := client.executeRequest(context.Background(), "GET", url, &destination, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, padding, destination["padding"])
+}
+
+// newLargeSearchBody builds a host search response of roughly the given size.
+func newLargeSearchBody(size int) []byte {
+	banner := `{"ip_str": "1.1.1.1", "port": 80, "transport": "tcp", "data": "` + strings.Repeat("x", 4096) + `"}`
+	count := size / len(banner)
+
+	body := bytes.NewBufferString(`{"total": ` + strconv.Itoa(count) + `, "matches": [`)
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+
+		body.WriteString(banner)
+	}
+
+	body.WriteString("]}")
+
+	return body.Bytes()
+}
+
+func BenchmarkClient_parseResponse_buffered(b *testing.B) {
+	body := newLargeSearchBody(10 << 20)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		content, err := ioutil.ReadAll(bytes.NewReader(body))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var found HostMatch
+		if err := json.Unmarshal(content, &found); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkClient_parseResponse(b *testing.B) {
+	client := NewClient(nil, testClientToken)
+	body := newLargeSearchBody(10 << 20)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var found HostMatch
+		if err := client.parseResponse(&found, bytes.NewReader(body)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkClient_decodeHostMatches(b *testing.B) {
+	body := newLargeSearchBody(10 << 20)
+	c := NewClient(nil, testClientToken)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var found HostMatch
+		_, err := c.decodeHostMatches(json.NewDecoder(bytes.NewReader(body)), func(banner *HostData) error {
+			found.Matches = append(found.Matches, banner)
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestReadStreamMessage(t *testing.T) {
+	long := strings.Repeat("x", 3*4096+17)
+	reader := bufio.NewReader(strings.NewReader("short\n" + long + "\n" + "incomplete"))
+
+	message, err := readStreamMessage(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "short\n", string(message))
+
+	message, err = readStreamMessage(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, long+"\n", string(message))
+
+	_, err = readStreamMessage(reader)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestClient_executeStreamRequest_concurrentConsumers(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	streamPath := "/stream/concurrent"
+	messages := 500
+
+	mux.HandleFunc(streamPath, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < messages; i++ {
+			fmt.Fprintf(w, "%d:%s\n", i, strings.Repeat(strconv.Itoa(i%10), 5000+i))
+		}
+	})
+
+	url := client.buildStreamBaseURL(streamPath, nil)
+
+	bytesChan := make(chan []byte)
+	err := client.executeStreamRequest(context.Background(), noopStreamSpan{}, "GET", url, bytesChan)
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+
+	for consumer := 0; consumer < 4; consumer++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for msg := range bytesChan {
+				parts := strings.SplitN(strings.TrimSuffix(string(msg), "\n"), ":", 2)
+				i, err := strconv.Atoi(parts[0])
+				assert.Nil(t, err)
+				assert.Equal(t, strings.Repeat(strconv.Itoa(i%10), 5000+i), parts[1])
+
+				mu.Lock()
+				seen[i] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Len(t, seen, messages)
+}
+
+func newStreamBody(messages, size int) []byte {
+	line := strings.Repeat("x", size-1) + "\n"
+	return []byte(strings.Repeat(line, messages))
+}
+
+func BenchmarkReadBytes(b *testing.B) {
+	body := newStreamBody(100, 16<<10)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader := bufio.NewReader(bytes.NewReader(body))
+		for {
+			if _, err := reader.ReadBytes('\n'); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkReadStreamMessage(b *testing.B) {
+	body := newStreamBody(100, 16<<10)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader := bufio.NewReader(bytes.NewReader(body))
+		for {
+			if _, err := readStreamMessage(reader); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func TestClient_executeRequest_largeErrorBody(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	errorPath := "/http-error/large"
+	chunk := bytes.Repeat([]byte("proxy error "), 1024)
+
+	mux.HandleFunc(errorPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		for written := 0; written < 50<<20; written += len(chunk) {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	})
+
+	url := client.buildBaseURL(errorPath, nil)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	err := client.executeRequest(context.Background(), "GET", url, nil, nil)
+
+	runtime.ReadMemStats(&after)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.True(t, len(apiErr.Message) <= errorBodyLimit)
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 8<<20, "allocated %d bytes", after.TotalAlloc-before.TotalAlloc)
 }
@@ -0,0 +1,92 @@
+// Package shodanotel traces the calls of the shodan client with OpenTelemetry.
+package shodanotel
+
+import (
+	"context"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by the package.
+const instrumentationName = "github.com/ns3777k/go-shodan/shodan"
+
+// The attributes set on the spans.
+const (
+	EndpointKey   = attribute.Key("shodan.endpoint")
+	CreditsKey    = attribute.Key("shodan.credits")
+	RetriesKey    = attribute.Key("shodan.retries")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+)
+
+// Tracer implements shodan.Tracer with OpenTelemetry. Pass it to the client with WithTracer.
+// Every REST call gets a client span named after the operation, i.e. "shodan.host.search", and
+// every stream subscription a span lasting until the stream is over.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ shodan.Tracer = (*Tracer)(nil)
+
+// New creates a tracer using the provider, the global one is used when it's nil.
+func New(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+// TraceRequest implements shodan.Tracer.
+func (t *Tracer) TraceRequest(ctx context.Context, span shodan.RequestSpan) (context.Context, func(shodan.RequestResult)) {
+	ctx, s := t.tracer.Start(ctx, span.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			EndpointKey.String(span.Endpoint),
+			MethodKey.String(span.Method),
+			CreditsKey.Int(span.Credits),
+		),
+	)
+
+	return ctx, func(result shodan.RequestResult) {
+		s.SetAttributes(RetriesKey.Int(result.Retries))
+		if result.StatusCode != 0 {
+			s.SetAttributes(StatusCodeKey.Int(result.StatusCode))
+		}
+
+		if result.Err != nil {
+			s.RecordError(result.Err)
+			s.SetStatus(codes.Error, result.Err.Error())
+		}
+
+		s.End()
+	}
+}
+
+// TraceStream implements shodan.Tracer.
+func (t *Tracer) TraceStream(ctx context.Context, operation string) (context.Context, shodan.StreamSpan) {
+	ctx, s := t.tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+
+	return ctx, streamSpan{span: s}
+}
+
+type streamSpan struct {
+	span trace.Span
+}
+
+func (s streamSpan) Event(name string) {
+	s.span.AddEvent(name)
+}
+
+func (s streamSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+
+	s.span.End()
+}
@@ -0,0 +1,110 @@
+package shodanotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+
+	return values
+}
+
+func TestTracer_requests(t *testing.T) {
+	recorder, provider := newRecorder()
+
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client(shodan.WithTracer(New(provider)))
+
+	_, err := client.GetHostsForQuery(context.Background(), &shodan.HostQueryOptions{Query: "port:22", Page: 2})
+	assert.Nil(t, err)
+	_, err = client.GetServicesForHost(context.Background(), "1.1.1.1", nil)
+	assert.NotNil(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	assert.Equal(t, "shodan.host.search", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	search := attributes(spans[0])
+	assert.Equal(t, "/shodan/host/search", search[EndpointKey].AsString())
+	assert.Equal(t, "GET", search[MethodKey].AsString())
+	assert.Equal(t, int64(200), search[StatusCodeKey].AsInt64())
+	assert.Equal(t, int64(1), search[CreditsKey].AsInt64())
+	assert.Equal(t, int64(0), search[RetriesKey].AsInt64())
+
+	assert.Equal(t, "shodan.host.get", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, int64(404), attributes(spans[1])[StatusCodeKey].AsInt64())
+	assert.Len(t, spans[1].Events(), 1)
+}
+
+func TestTracer_stream(t *testing.T) {
+	recorder, provider := newRecorder()
+
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client(shodan.WithTracer(New(provider)))
+
+	for i := 0; i < 2; i++ {
+		client.StreamChan = make(chan shodan.HostData)
+		client.GetBanners(context.Background())
+		for range client.StreamChan {
+		}
+	}
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	for _, span := range spans {
+		assert.Equal(t, "shodan.stream.banners", span.Name())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Len(t, span.Events(), 1)
+		assert.Equal(t, "connect", span.Events()[0].Name)
+	}
+}
+
+func TestTracer_parentSpan(t *testing.T) {
+	recorder, provider := newRecorder()
+
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client(shodan.WithTracer(New(provider)))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, err := client.SearchHostsFunc(ctx, &shodan.HostQueryOptions{Query: "nginx"}, func(*shodan.HostData) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "shodan.host.search", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, int64(0), attributes(spans[0])[CreditsKey].AsInt64())
+}
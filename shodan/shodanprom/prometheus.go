@@ -0,0 +1,101 @@
+// Package shodanprom reports the metrics of the shodan client to Prometheus.
+package shodanprom
+
+import (
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements shodan.Metrics with Prometheus collectors. Pass it to the client with WithMetrics.
+type Metrics struct {
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+	gauges     map[string]*prometheus.GaugeVec
+}
+
+var _ shodan.Metrics = (*Metrics)(nil)
+
+// New creates the collectors and registers them with the registerer.
+func New(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		counters: map[string]*prometheus.CounterVec{
+			shodan.MetricRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: shodan.MetricRequests,
+				Help: "Number of requests sent to the Shodan API.",
+			}, []string{"endpoint", "method", "code"}),
+			shodan.MetricStreamMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: shodan.MetricStreamMessages,
+				Help: "Number of banners received from the Shodan streams.",
+			}, []string{"stream"}),
+			shodan.MetricStreamReconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: shodan.MetricStreamReconnects,
+				Help: "Number of times the Shodan streams were connected again.",
+			}, []string{"stream"}),
+		},
+		histograms: map[string]*prometheus.HistogramVec{
+			shodan.MetricRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:    shodan.MetricRequestDuration,
+				Help:    "Duration of the requests sent to the Shodan API.",
+				Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+			}, []string{"endpoint", "method"}),
+		},
+		gauges: map[string]*prometheus.GaugeVec{
+			shodan.MetricCredits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name: shodan.MetricCredits,
+				Help: "Number of Shodan API credits left.",
+			}, []string{"kind"}),
+		},
+	}
+
+	for _, collector := range m.collectors() {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	var collectors []prometheus.Collector
+	for _, counter := range m.counters {
+		collectors = append(collectors, counter)
+	}
+
+	for _, histogram := range m.histograms {
+		collectors = append(collectors, histogram)
+	}
+
+	for _, gauge := range m.gauges {
+		collectors = append(collectors, gauge)
+	}
+
+	return collectors
+}
+
+// IncCounter implements shodan.Metrics. Unknown metrics and labels are ignored.
+func (m *Metrics) IncCounter(name string, labels map[string]string) {
+	if vec, ok := m.counters[name]; ok {
+		if counter, err := vec.GetMetricWith(labels); err == nil {
+			counter.Inc()
+		}
+	}
+}
+
+// Observe implements shodan.Metrics. Unknown metrics and labels are ignored.
+func (m *Metrics) Observe(name string, value float64, labels map[string]string) {
+	if vec, ok := m.histograms[name]; ok {
+		if histogram, err := vec.GetMetricWith(labels); err == nil {
+			histogram.Observe(value)
+		}
+	}
+}
+
+// SetGauge implements shodan.Metrics. Unknown metrics and labels are ignored.
+func (m *Metrics) SetGauge(name string, value float64, labels map[string]string) {
+	if vec, ok := m.gauges[name]; ok {
+		if gauge, err := vec.GetMetricWith(labels); err == nil {
+			gauge.Set(value)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package shodanprom
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, err := New(registry)
+	assert.Nil(t, err)
+
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client(shodan.WithMetrics(metrics))
+
+	_, err = client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", shodantest.HostIP} {
+		client.GetServicesForHost(context.Background(), ip, nil)
+	}
+
+	requests := metrics.counters[shodan.MetricRequests]
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.WithLabelValues("/api-info", "GET", "2xx")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests.WithLabelValues("/shodan/host/{ip}", "GET", "4xx")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.WithLabelValues("/shodan/host/{ip}", "GET", "2xx")))
+	assert.Equal(t, 3, testutil.CollectAndCount(requests))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.histograms[shodan.MetricRequestDuration]))
+	assert.Equal(t, float64(2341), testutil.ToFloat64(metrics.gauges[shodan.MetricCredits].WithLabelValues("query")))
+
+	client.GetBanners(context.Background())
+	for range client.StreamChan {
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.counters[shodan.MetricStreamMessages].WithLabelValues("/shodan/banners")))
+}
+
+func TestMetrics_ignoresUnknown(t *testing.T) {
+	metrics, err := New(prometheus.NewRegistry())
+	assert.Nil(t, err)
+
+	metrics.IncCounter("unknown", nil)
+	metrics.IncCounter(shodan.MetricRequests, map[string]string{"path": "/"})
+	metrics.Observe(shodan.MetricRequestDuration, 1, nil)
+	metrics.SetGauge(shodan.MetricCredits, 1, map[string]string{"kind": "query", "extra": "x"})
+
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.counters[shodan.MetricRequests]))
+}
+
+func TestNew_alreadyRegistered(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := New(registry)
+	assert.Nil(t, err)
+
+	_, err = New(registry)
+	assert.IsType(t, prometheus.AlreadyRegisteredError{}, err)
+}
@@ -0,0 +1,492 @@
+package shodantest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+)
+
+// DefaultTimestamp is the timestamp of the built banners unless they are given another one.
+var DefaultTimestamp = time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+// BannerBuilder builds banners with sensible defaults for everything that is left unspecified,
+// the defaults describe an nginx web server on port 80. Every method returns the builder, so the
+// calls can be chained:
+//
+//	banner := shodantest.NewBanner().IP("1.2.3.4").Port(443).Product("nginx").
+//		WithSSL(shodan.HostSSL{}).WithVuln("CVE-2021-44228", 9.8).Build()
+type BannerBuilder struct {
+	banner shodan.HostData
+}
+
+// NewBanner creates a banner builder.
+func NewBanner() *BannerBuilder {
+	return &BannerBuilder{banner: shodan.HostData{
+		Product:      "nginx",
+		Hostnames:    []string{"www.example.com"},
+		Version:      "1.18.0",
+		Title:        "Welcome to nginx!",
+		IP:           "192.0.2.1",
+		OS:           "Linux",
+		Organization: "Example Org",
+		ISP:          "Example ISP",
+		CPE:          []string{"cpe:/a:igor_sysoev:nginx:1.18.0"},
+		Data:         "HTTP/1.1 200 OK\r\nServer: nginx/1.18.0\r\n\r\n",
+		ASN:          "AS64496",
+		Port:         80,
+		HTML:         "<html><head><title>Welcome to nginx!</title></head></html>",
+		Transport:    "tcp",
+		Domains:      []string{"example.com"},
+		Timestamp:    shodan.Time{Time: DefaultTimestamp},
+		Location: &shodan.HostLocation{
+			City:         "Los Angeles",
+			RegionCode:   "CA",
+			Latitude:     34.0522,
+			Longitude:    -118.2437,
+			Country:      "United States",
+			CountryCode:  "US",
+			CountryCode3: "USA",
+			Postal:       "90001",
+		},
+		ShodanData: map[string]interface{}{
+			"module":  "http",
+			"crawler": "0123456789abcdef0123456789abcdef01234567",
+			"id":      "00000000-0000-4000-8000-000000000000",
+		},
+		Opts: make(map[string]interface{}),
+	}}
+}
+
+// IP sets the IP address, the numeric IP is derived from it.
+func (b *BannerBuilder) IP(ip string) *BannerBuilder {
+	b.banner.IP = ip
+	return b
+}
+
+// Port sets the port.
+func (b *BannerBuilder) Port(port int) *BannerBuilder {
+	b.banner.Port = port
+	return b
+}
+
+// Transport sets the transport, either "tcp" or "udp".
+func (b *BannerBuilder) Transport(transport string) *BannerBuilder {
+	b.banner.Transport = transport
+	return b
+}
+
+// Product sets the product.
+func (b *BannerBuilder) Product(product string) *BannerBuilder {
+	b.banner.Product = product
+	return b
+}
+
+// Version sets the product version.
+func (b *BannerBuilder) Version(version string) *BannerBuilder {
+	b.banner.Version = shodan.Version(version)
+	return b
+}
+
+// Hostnames sets the hostnames.
+func (b *BannerBuilder) Hostnames(hostnames ...string) *BannerBuilder {
+	b.banner.Hostnames = hostnames
+	return b
+}
+
+// Domains sets the domains.
+func (b *BannerBuilder) Domains(domains ...string) *BannerBuilder {
+	b.banner.Domains = domains
+	return b
+}
+
+// Tags sets the tags, i.e. "database".
+func (b *BannerBuilder) Tags(tags ...string) *BannerBuilder {
+	b.banner.Tags = tags
+	return b
+}
+
+// Org sets the organization.
+func (b *BannerBuilder) Org(org string) *BannerBuilder {
+	b.banner.Organization = org
+	return b
+}
+
+// ISP sets the ISP.
+func (b *BannerBuilder) ISP(isp string) *BannerBuilder {
+	b.banner.ISP = isp
+	return b
+}
+
+// ASN sets the ASN, i.e. "AS13335".
+func (b *BannerBuilder) ASN(asn string) *BannerBuilder {
+	b.banner.ASN = asn
+	return b
+}
+
+// OS sets the operating system.
+func (b *BannerBuilder) OS(os string) *BannerBuilder {
+	b.banner.OS = os
+	return b
+}
+
+// Data sets the raw banner.
+func (b *BannerBuilder) Data(data string) *BannerBuilder {
+	b.banner.Data = data
+	return b
+}
+
+// Module sets the name of the module that grabbed the banner, i.e. "https".
+func (b *BannerBuilder) Module(module string) *BannerBuilder {
+	b.banner.ShodanData["module"] = module
+	return b
+}
+
+// Timestamp sets the time the banner was grabbed. It's kept with the precision Shodan uses.
+func (b *BannerBuilder) Timestamp(timestamp time.Time) *BannerBuilder {
+	b.banner.Timestamp = shodan.Time{Time: timestamp.UTC().Truncate(time.Microsecond)}
+	return b
+}
+
+// Country sets the country of the location, the city is cleared.
+func (b *BannerBuilder) Country(code, name string) *BannerBuilder {
+	b.banner.Location = &shodan.HostLocation{Country: name, CountryCode: code}
+	return b
+}
+
+// WithSSL adds the SSL information, the versions, the cipher and the certificate left empty get
+// defaults. The default certificate is issued for the first hostname.
+func (b *BannerBuilder) WithSSL(ssl shodan.HostSSL) *BannerBuilder {
+	if ssl.Versions == nil {
+		ssl.Versions = []string{"TLSv1.2", "TLSv1.3"}
+	}
+
+	if ssl.Cipher == nil {
+		ssl.Cipher = &shodan.SSLCipher{Version: "TLSv1.3", Bits: 256, Name: "TLS_AES_256_GCM_SHA384"}
+	}
+
+	if ssl.Cert == nil {
+		commonName := "localhost"
+		if len(b.banner.Hostnames) > 0 {
+			commonName = b.banner.Hostnames[0]
+		}
+
+		sha1Sum := sha1.Sum([]byte(commonName))
+		sha256Sum := sha256.Sum256([]byte(commonName))
+
+		ssl.Cert = &shodan.SSLCert{
+			Subject: map[string]string{"CN": commonName},
+			Issuer:  map[string]string{"CN": "Example CA", "O": "Example"},
+			Issued:  shodan.Time{Time: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			Expires: shodan.Time{Time: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			SigAlg:  "sha256WithRSAEncryption",
+			Fingerprint: shodan.SSLFingerprint{
+				SHA1:   hex.EncodeToString(sha1Sum[:]),
+				SHA256: hex.EncodeToString(sha256Sum[:]),
+			},
+		}
+	}
+
+	if ssl.Chain == nil {
+		ssl.Chain = []string{"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"}
+	}
+
+	b.banner.SSL = &ssl
+	b.banner.ShodanData["module"] = "https"
+
+	return b
+}
+
+// WithHTTP adds the HTTP information, the status, the title, the host and the server left empty get
+// defaults from the banner.
+func (b *BannerBuilder) WithHTTP(info shodan.HostHTTP) *BannerBuilder {
+	if info.Status == 0 {
+		info.Status = 200
+	}
+
+	if info.Title == "" {
+		info.Title = b.banner.Title
+	}
+
+	if info.Host == "" && len(b.banner.Hostnames) > 0 {
+		info.Host = b.banner.Hostnames[0]
+	}
+
+	if info.Location == "" {
+		info.Location = "/"
+	}
+
+	if info.Server == "" {
+		info.Server = strings.TrimSpace(b.banner.Product + "/" + b.banner.Version.String())
+	}
+
+	b.banner.HTTP = &info
+
+	return b
+}
+
+// WithSSH adds the SSH information, the key type, the key, the fingerprint and the algorithms left
+// empty get defaults. It also makes the banner come from the ssh module.
+func (b *BannerBuilder) WithSSH(info shodan.HostSSH) *BannerBuilder {
+	if info.Type == "" {
+		info.Type = "ssh-ed25519"
+	}
+
+	if info.Key == "" {
+		info.Key = "AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+	}
+
+	if info.Fingerprint == "" {
+		sum := md5.Sum([]byte(info.Key))
+		pairs := make([]string, len(sum))
+		for i, c := range sum {
+			pairs[i] = hex.EncodeToString([]byte{c})
+		}
+
+		info.Fingerprint = strings.Join(pairs, ":")
+	}
+
+	if info.MAC == "" {
+		info.MAC = "hmac-sha2-256"
+	}
+
+	if info.Cipher == "" {
+		info.Cipher = "aes128-ctr"
+	}
+
+	if info.Kex == nil {
+		info.Kex = &shodan.SSHKex{
+			KexAlgorithms:           []string{"curve25519-sha256"},
+			ServerHostKeyAlgorithms: []string{info.Type},
+			EncryptionAlgorithms:    []string{info.Cipher},
+			MACAlgorithms:           []string{info.MAC},
+			CompressionAlgorithms:   []string{"none"},
+		}
+	}
+
+	b.banner.SSH = &info
+	b.banner.ShodanData["module"] = "ssh"
+
+	return b
+}
+
+// WithVuln adds a vulnerability with the CVSS score.
+func (b *BannerBuilder) WithVuln(cve string, cvss float64) *BannerBuilder {
+	if b.banner.Vulns == nil {
+		b.banner.Vulns = make(map[string]*shodan.HostVuln)
+	}
+
+	b.banner.Vulns[cve] = &shodan.HostVuln{
+		CVSS:       cvss,
+		Summary:    cve + " affects " + b.banner.Product + ".",
+		References: []string{"https://nvd.nist.gov/vuln/detail/" + cve},
+	}
+
+	return b
+}
+
+// Build returns the banner, the builder can be used for building more banners afterwards.
+func (b *BannerBuilder) Build() *shodan.HostData {
+	banner := clone(&b.banner)
+	if parsed := net.ParseIP(banner.IP).To4(); parsed != nil {
+		banner.IPLong = int(parsed[0])<<24 | int(parsed[1])<<16 | int(parsed[2])<<8 | int(parsed[3])
+	}
+
+	return banner
+}
+
+// clone deep copies the banner through the JSON codec, so the built banners don't share the maps
+// and the slices of the builder.
+func clone(banner *shodan.HostData) *shodan.HostData {
+	content, err := json.Marshal(banner)
+	if err != nil {
+		panic("shodantest: can't encode the banner: " + err.Error())
+	}
+
+	cloned := new(shodan.HostData)
+	if err := json.Unmarshal(content, cloned); err != nil {
+		panic("shodantest: can't decode the banner: " + err.Error())
+	}
+
+	return cloned
+}
+
+// HostBuilder builds hosts out of banners, the host information is derived from them.
+type HostBuilder struct {
+	ip      string
+	banners []*shodan.HostData
+}
+
+// NewHost creates a host builder, the IP is the one of the default banner.
+func NewHost() *HostBuilder {
+	return &HostBuilder{ip: NewBanner().banner.IP}
+}
+
+// IP sets the IP address of the host and all its banners.
+func (b *HostBuilder) IP(ip string) *HostBuilder {
+	b.ip = ip
+	return b
+}
+
+// WithBanner adds the banners, a single default banner is used when none is added.
+func (b *HostBuilder) WithBanner(banners ...*shodan.HostData) *HostBuilder {
+	b.banners = append(b.banners, banners...)
+	return b
+}
+
+// Build returns the host. The ports, the hostnames, the domains, the tags and the vulnerabilities
+// are collected from all the banners, the rest of the information comes from the most recent one.
+func (b *HostBuilder) Build() *shodan.Host {
+	banners := b.banners
+	if len(banners) == 0 {
+		banners = []*shodan.HostData{NewBanner().Build()}
+	}
+
+	host := &shodan.Host{
+		IP:              b.ip,
+		Ports:           []int{},
+		Hostnames:       []string{},
+		Vulnerabilities: []string{},
+		Tags:            []string{},
+		Domains:         []string{},
+	}
+
+	seenPorts := make(map[int]bool)
+	seenHostnames := make(map[string]bool)
+	seenTags := make(map[string]bool)
+	seenDomains := make(map[string]bool)
+	seenVulns := make(map[string]bool)
+
+	var latest *shodan.HostData
+	for _, banner := range banners {
+		banner = NewBanner().from(banner).IP(b.ip).Build()
+		host.Data = append(host.Data, banner)
+
+		if latest == nil || banner.Timestamp.After(latest.Timestamp.Time) {
+			latest = banner
+		}
+
+		if !seenPorts[banner.Port] {
+			seenPorts[banner.Port] = true
+			host.Ports = append(host.Ports, banner.Port)
+		}
+
+		for _, hostname := range banner.Hostnames {
+			if !seenHostnames[hostname] {
+				seenHostnames[hostname] = true
+				host.Hostnames = append(host.Hostnames, hostname)
+			}
+		}
+
+		for _, tag := range banner.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				host.Tags = append(host.Tags, tag)
+			}
+		}
+
+		for _, domain := range banner.Domains {
+			if !seenDomains[domain] {
+				seenDomains[domain] = true
+				host.Domains = append(host.Domains, domain)
+			}
+		}
+
+		for cve := range banner.Vulns {
+			if !seenVulns[cve] {
+				seenVulns[cve] = true
+				host.Vulnerabilities = append(host.Vulnerabilities, cve)
+			}
+		}
+	}
+
+	sort.Ints(host.Ports)
+	sort.Strings(host.Vulnerabilities)
+
+	host.IPLong = latest.IPLong
+	host.OS = latest.OS
+	host.ISP = latest.ISP
+	host.Organization = latest.Organization
+	host.ASN = latest.ASN
+	host.LastUpdate = latest.Timestamp
+	if latest.Location != nil {
+		host.HostLocation = *latest.Location
+	}
+
+	return host
+}
+
+// from makes the builder start from a copy of the banner.
+func (b *BannerBuilder) from(banner *shodan.HostData) *BannerBuilder {
+	b.banner = *clone(banner)
+	if b.banner.ShodanData == nil {
+		b.banner.ShodanData = make(map[string]interface{})
+	}
+
+	return b
+}
+
+// HostMatchBuilder builds search results.
+type HostMatchBuilder struct {
+	match shodan.HostMatch
+	total int
+}
+
+// NewHostMatch creates a search results builder.
+func NewHostMatch() *HostMatchBuilder {
+	return &HostMatchBuilder{total: -1, match: shodan.HostMatch{
+		Facets:  make(map[string][]*shodan.Facet),
+		Matches: []*shodan.HostData{},
+	}}
+}
+
+// WithBanner adds the banners to the matches.
+func (b *HostMatchBuilder) WithBanner(banners ...*shodan.HostData) *HostMatchBuilder {
+	b.match.Matches = append(b.match.Matches, banners...)
+	return b
+}
+
+// Total sets the total number of results, it's the number of matches by default.
+func (b *HostMatchBuilder) Total(total int) *HostMatchBuilder {
+	b.total = total
+	return b
+}
+
+// Facet adds a bucket to the facet.
+func (b *HostMatchBuilder) Facet(name, value string, count int) *HostMatchBuilder {
+	b.match.Facets[name] = append(b.match.Facets[name], &shodan.Facet{Value: value, Count: count})
+	return b
+}
+
+// Build returns the search results.
+func (b *HostMatchBuilder) Build() *shodan.HostMatch {
+	match := &shodan.HostMatch{
+		Total:   b.total,
+		Facets:  make(map[string][]*shodan.Facet, len(b.match.Facets)),
+		Matches: make([]*shodan.HostData, 0, len(b.match.Matches)),
+	}
+
+	if match.Total < 0 {
+		match.Total = len(b.match.Matches)
+	}
+
+	for name, buckets := range b.match.Facets {
+		for _, bucket := range buckets {
+			copied := *bucket
+			match.Facets[name] = append(match.Facets[name], &copied)
+		}
+	}
+
+	for _, banner := range b.match.Matches {
+		match.Matches = append(match.Matches, clone(banner))
+	}
+
+	return match
+}
@@ -0,0 +1,145 @@
+package shodantest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTrip encodes the value and decodes it into a new one of the same type.
+func roundTrip(t *testing.T, value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	assert.Nil(t, err)
+
+	decoded := reflect.New(reflect.TypeOf(value).Elem()).Interface()
+	assert.Nil(t, json.Unmarshal(content, decoded))
+
+	return decoded
+}
+
+// assertPopulated fails for every field of the struct left empty, except the skipped ones. It
+// makes the builders fail the tests once a field is added to the structs and not to the builders.
+func assertPopulated(t *testing.T, value interface{}, skipped ...string) {
+	v := reflect.ValueOf(value).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if contains(skipped, name) {
+			continue
+		}
+
+		assert.False(t, v.Field(i).IsZero(), "%s.%s is empty", v.Type().Name(), name)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fullBanner() *shodan.HostData {
+	return NewBanner().IP("1.2.3.4").Port(443).Product("nginx").Tags("cloud").
+		WithSSL(shodan.HostSSL{}).WithHTTP(shodan.HostHTTP{}).WithVuln("CVE-2021-44228", 9.8).Build()
+}
+
+func TestBannerBuilder(t *testing.T) {
+	banner := fullBanner()
+
+	assert.Equal(t, "1.2.3.4", banner.IP)
+	assert.Equal(t, 16909060, banner.IPLong)
+	assert.Equal(t, 443, banner.Port)
+	assert.Equal(t, "https", banner.ShodanData["module"])
+	assert.Equal(t, "www.example.com", banner.SSL.Cert.Subject["CN"])
+	assert.Len(t, banner.SSL.Cert.Fingerprint.SHA256, 64)
+	assert.Equal(t, 9.8, banner.Vulns["CVE-2021-44228"].CVSS)
+	assert.True(t, DefaultTimestamp.Equal(banner.Timestamp.Time))
+	assert.Equal(t, "nginx/1.18.0", banner.HTTP.Server)
+	assert.Equal(t, "www.example.com", banner.HTTP.Host)
+
+	assertPopulated(t, banner, "DeviceType", "Banner", "Link", "CurrentPTR", "SSH")
+}
+
+func TestBannerBuilder_roundTrip(t *testing.T) {
+	banners := []*shodan.HostData{
+		NewBanner().Build(),
+		fullBanner(),
+		NewBanner().IP("2001:db8::1").Transport("udp").Port(53).Module("dns-udp").Hostnames().Domains().Build(),
+		NewBanner().Country("DE", "Germany").Version("2").Timestamp(time.Now()).Build(),
+	}
+
+	for _, banner := range banners {
+		assert.Equal(t, banner, roundTrip(t, banner))
+	}
+}
+
+func TestBannerBuilder_independentBuilds(t *testing.T) {
+	builder := NewBanner()
+	first := builder.Build()
+	second := builder.Module("ssh").Build()
+
+	first.Hostnames[0] = "changed"
+
+	assert.Equal(t, "http", first.ShodanData["module"])
+	assert.Equal(t, "ssh", second.ShodanData["module"])
+	assert.Equal(t, "www.example.com", second.Hostnames[0])
+}
+
+func TestHostBuilder(t *testing.T) {
+	host := NewHost().IP("1.2.3.4").WithBanner(
+		NewBanner().Port(80).Timestamp(DefaultTimestamp.Add(-time.Hour)).Build(),
+		fullBanner(),
+		NewBanner().Port(22).Product("OpenSSH").Hostnames("ssh.example.com").Tags("ssh").
+			WithSSH(shodan.HostSSH{}).WithVuln("CVE-2023-48795", 5.9).Build(),
+	).Build()
+
+	assert.Equal(t, "1.2.3.4", host.IP)
+	assert.Equal(t, []int{22, 80, 443}, host.Ports)
+	assert.Equal(t, []string{"www.example.com", "ssh.example.com"}, host.Hostnames)
+	assert.Equal(t, []string{"CVE-2021-44228", "CVE-2023-48795"}, host.Vulnerabilities)
+	assert.Equal(t, []string{"cloud", "ssh"}, host.Tags)
+	assert.Equal(t, []string{"example.com"}, host.Domains)
+	assert.Equal(t, "ssh", host.Data[2].ShodanData["module"])
+	assert.Len(t, strings.Split(host.Data[2].SSH.Fingerprint, ":"), 16)
+	assert.True(t, DefaultTimestamp.Equal(host.LastUpdate.Time))
+	assert.Len(t, host.Data, 3)
+
+	for _, banner := range host.Data {
+		assert.Equal(t, "1.2.3.4", banner.IP)
+	}
+
+	assertPopulated(t, host)
+	assert.Equal(t, host, roundTrip(t, host))
+}
+
+func TestHostBuilder_defaults(t *testing.T) {
+	host := NewHost().Build()
+
+	assert.Equal(t, []int{80}, host.Ports)
+	assert.Len(t, host.Data, 1)
+	assert.Equal(t, host, roundTrip(t, host))
+}
+
+func TestHostMatchBuilder(t *testing.T) {
+	match := NewHostMatch().
+		WithBanner(NewBanner().Build(), fullBanner()).
+		Facet("country", "US", 2).
+		Facet("country", "DE", 1).
+		Build()
+
+	assert.Equal(t, 2, match.Total)
+	assert.Len(t, match.Matches, 2)
+	assert.Equal(t, []*shodan.Facet{{Value: "US", Count: 2}, {Value: "DE", Count: 1}}, match.Facets["country"])
+	assert.Equal(t, match, roundTrip(t, match))
+
+	assert.Equal(t, 1000, NewHostMatch().Total(1000).Build().Total)
+	assert.Equal(t, NewHostMatch().Build(), roundTrip(t, NewHostMatch().Build()))
+}
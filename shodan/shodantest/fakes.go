@@ -0,0 +1,469 @@
+package shodantest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ns3777k/go-shodan/shodan"
+)
+
+// ErrNotConfigured is returned by the fakes when the function of the called method is not set.
+var ErrNotConfigured = errors.New("shodantest: fake method is not configured")
+
+var (
+	_ shodan.HostSearcher = (*FakeHostSearcher)(nil)
+	_ shodan.ScanAPI      = (*FakeScanAPI)(nil)
+	_ shodan.AlertAPI     = (*FakeAlertAPI)(nil)
+	_ shodan.DNSAPI       = (*FakeDNSAPI)(nil)
+	_ shodan.Streamer     = (*FakeStreamer)(nil)
+	_ shodan.AccountAPI   = (*FakeAccountAPI)(nil)
+	_ shodan.NotifierAPI  = (*FakeNotifierAPI)(nil)
+)
+
+// FakeHostSearcher implements shodan.HostSearcher by calling the configured functions.
+type FakeHostSearcher struct {
+	GetServicesForHostFunc    func(ctx context.Context, ip string, options *shodan.HostServicesOptions) (*shodan.Host, error)
+	GetHostsCountForQueryFunc func(ctx context.Context, options *shodan.HostQueryOptions) (*shodan.HostMatch, error)
+	GetHostsForQueryFunc      func(ctx context.Context, options *shodan.HostQueryOptions) (*shodan.HostMatch, error)
+	SearchHostsFuncFunc       func(ctx context.Context, options *shodan.HostQueryOptions, fn func(*shodan.HostData) error) (*shodan.SearchSummary, error)
+	BreakQueryIntoTokensFunc  func(ctx context.Context, query string) (*shodan.HostQueryTokens, error)
+}
+
+// GetServicesForHost calls GetServicesForHostFunc.
+func (f *FakeHostSearcher) GetServicesForHost(ctx context.Context, ip string, options *shodan.HostServicesOptions) (*shodan.Host, error) {
+	if f.GetServicesForHostFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.GetServicesForHostFunc(ctx, ip, options)
+}
+
+// GetHostsCountForQuery calls GetHostsCountForQueryFunc.
+func (f *FakeHostSearcher) GetHostsCountForQuery(ctx context.Context, options *shodan.HostQueryOptions) (*shodan.HostMatch, error) {
+	if f.GetHostsCountForQueryFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.GetHostsCountForQueryFunc(ctx, options)
+}
+
+// GetHostsForQuery calls GetHostsForQueryFunc.
+func (f *FakeHostSearcher) GetHostsForQuery(ctx context.Context, options *shodan.HostQueryOptions) (*shodan.HostMatch, error) {
+	if f.GetHostsForQueryFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.GetHostsForQueryFunc(ctx, options)
+}
+
+// SearchHostsFunc calls SearchHostsFuncFunc. When it's not set but GetHostsForQueryFunc is, the matches
+// returned by the latter are handed to fn.
+func (f *FakeHostSearcher) SearchHostsFunc(ctx context.Context, options *shodan.HostQueryOptions, fn func(*shodan.HostData) error) (*shodan.SearchSummary, error) {
+	if f.SearchHostsFuncFunc != nil {
+		return f.SearchHostsFuncFunc(ctx, options, fn)
+	}
+
+	found, err := f.GetHostsForQuery(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range found.Matches {
+		if err := fn(match); err != nil {
+			return nil, err
+		}
+	}
+
+	return &shodan.SearchSummary{Total: found.Total, Facets: found.Facets}, nil
+}
+
+// BreakQueryIntoTokens calls BreakQueryIntoTokensFunc.
+func (f *FakeHostSearcher) BreakQueryIntoTokens(ctx context.Context, query string) (*shodan.HostQueryTokens, error) {
+	if f.BreakQueryIntoTokensFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.BreakQueryIntoTokensFunc(ctx, query)
+}
+
+// FakeScanAPI implements shodan.ScanAPI by calling the configured functions.
+type FakeScanAPI struct {
+	ScanFunc          func(ctx context.Context, ip []string) (*shodan.CrawlScanStatus, error)
+	ScanInternetFunc  func(ctx context.Context, port int, protocol string) (string, error)
+	GetScanStatusFunc func(ctx context.Context, id string) (*shodan.ScanStatus, error)
+}
+
+// Scan calls ScanFunc.
+func (f *FakeScanAPI) Scan(ctx context.Context, ip []string) (*shodan.CrawlScanStatus, error) {
+	if f.ScanFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.ScanFunc(ctx, ip)
+}
+
+// ScanInternet calls ScanInternetFunc.
+func (f *FakeScanAPI) ScanInternet(ctx context.Context, port int, protocol string) (string, error) {
+	if f.ScanInternetFunc == nil {
+		return "", ErrNotConfigured
+	}
+
+	return f.ScanInternetFunc(ctx, port, protocol)
+}
+
+// GetScanStatus calls GetScanStatusFunc.
+func (f *FakeScanAPI) GetScanStatus(ctx context.Context, id string) (*shodan.ScanStatus, error) {
+	if f.GetScanStatusFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.GetScanStatusFunc(ctx, id)
+}
+
+// FakeAlertAPI implements shodan.AlertAPI keeping the alerts in memory. The zero value is ready to use.
+type FakeAlertAPI struct {
+	mu     sync.Mutex
+	alerts []*shodan.Alert
+	nextID int
+}
+
+// CreateAlert stores a new alert.
+func (f *FakeAlertAPI) CreateAlert(ctx context.Context, name string, ip []string, expires int) (*shodan.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	alert := &shodan.Alert{
+		ID:      fmt.Sprintf("FAKEALERT%07d", f.nextID),
+		Name:    name,
+		Expires: expires,
+		Filters: &shodan.AlertFilters{IP: append([]string(nil), ip...)},
+	}
+	f.alerts = append(f.alerts, alert)
+
+	copied := *alert
+
+	return &copied, nil
+}
+
+// GetAlerts returns the stored alerts.
+func (f *FakeAlertAPI) GetAlerts(ctx context.Context) ([]*shodan.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	alerts := make([]*shodan.Alert, 0, len(f.alerts))
+	for _, alert := range f.alerts {
+		copied := *alert
+		alerts = append(alerts, &copied)
+	}
+
+	return alerts, nil
+}
+
+// GetAlert returns the stored alert, a 404 APIError is returned for unknown ones.
+func (f *FakeAlertAPI) GetAlert(ctx context.Context, id string) (*shodan.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, alert := range f.alerts {
+		if alert.ID == id {
+			copied := *alert
+			return &copied, nil
+		}
+	}
+
+	return nil, notFound("GET", "/shodan/alert/{id}/info", "/shodan/alert/"+id+"/info", "Invalid Alert ID")
+}
+
+// DeleteAlert removes the stored alert, a 404 APIError is returned for unknown ones.
+func (f *FakeAlertAPI) DeleteAlert(ctx context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, alert := range f.alerts {
+		if alert.ID == id {
+			f.alerts = append(f.alerts[:i], f.alerts[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, notFound("DELETE", "/shodan/alert/{id}", "/shodan/alert/"+id, "Invalid Alert ID")
+}
+
+// FakeDNSAPI implements shodan.DNSAPI with fixed records. The hostnames and addresses missing
+// from the records are resolved to nil just like Shodan does.
+type FakeDNSAPI struct {
+	Hosts    map[string]string
+	Reverses map[string][]string
+	Domains  map[string]*shodan.DomainInfo
+}
+
+// GetDNSResolve looks the hostnames up in Hosts.
+func (f *FakeDNSAPI) GetDNSResolve(ctx context.Context, hostnames []string) (map[string]*net.IP, error) {
+	resolved := make(map[string]*net.IP, len(hostnames))
+	for _, hostname := range hostnames {
+		resolved[hostname] = nil
+		if address, ok := f.Hosts[hostname]; ok {
+			if ip := net.ParseIP(address); ip != nil {
+				resolved[hostname] = &ip
+			}
+		}
+	}
+
+	return resolved, nil
+}
+
+// GetDNSReverse looks the addresses up in Reverses.
+func (f *FakeDNSAPI) GetDNSReverse(ctx context.Context, ips []net.IP) (map[string]*[]string, error) {
+	reversed := make(map[string]*[]string, len(ips))
+	for _, ip := range ips {
+		address := ip.String()
+		if hostnames, ok := f.Reverses[address]; ok {
+			copied := append([]string(nil), hostnames...)
+			reversed[address] = &copied
+		} else {
+			reversed[address] = nil
+		}
+	}
+
+	return reversed, nil
+}
+
+// GetDomain returns the domain from Domains, a 404 APIError is returned for unknown ones. The
+// options are ignored.
+func (f *FakeDNSAPI) GetDomain(ctx context.Context, domain string, options *shodan.DomainOptions) (*shodan.DomainInfo, error) {
+	info, ok := f.Domains[domain]
+	if !ok {
+		return nil, notFound("GET", "/dns/domain/{domain}", "/dns/domain/"+domain, "No information available for that domain.")
+	}
+
+	return info, nil
+}
+
+// FakeAccountAPI implements shodan.AccountAPI with a fixed profile and API information, the ones
+// left nil are decoded from the "profile" and the "info" fixtures. The zero value is ready to use.
+type FakeAccountAPI struct {
+	Profile *shodan.Profile
+	Info    *shodan.APIInfo
+}
+
+// GetAccountProfile returns a copy of Profile.
+func (f *FakeAccountAPI) GetAccountProfile(ctx context.Context) (*shodan.Profile, error) {
+	var profile shodan.Profile
+	if f.Profile != nil {
+		profile = *f.Profile
+	} else if err := FixtureJSON("profile", &profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// GetAPIInfo returns a copy of Info.
+func (f *FakeAccountAPI) GetAPIInfo(ctx context.Context) (*shodan.APIInfo, error) {
+	var info shodan.APIInfo
+	if f.Info != nil {
+		info = *f.Info
+	} else if err := FixtureJSON("info", &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// FakeNotifierAPI implements shodan.NotifierAPI by calling the configured functions.
+type FakeNotifierAPI struct {
+	ListNotifiersFunc         func(ctx context.Context) ([]*shodan.Notifier, error)
+	ListNotifierProvidersFunc func(ctx context.Context) (map[string]*shodan.NotifierProvider, error)
+	GetNotifierFunc           func(ctx context.Context, id string) (*shodan.Notifier, error)
+	CreateNotifierFunc        func(ctx context.Context, provider, description string, args map[string]string) (*shodan.Notifier, error)
+	UpdateNotifierFunc        func(ctx context.Context, id string, args map[string]string) error
+	DeleteNotifierFunc        func(ctx context.Context, id string) error
+	AddAlertNotifierFunc      func(ctx context.Context, alertID, notifierID string) error
+	RemoveAlertNotifierFunc   func(ctx context.Context, alertID, notifierID string) error
+}
+
+// ListNotifiers calls ListNotifiersFunc.
+func (f *FakeNotifierAPI) ListNotifiers(ctx context.Context) ([]*shodan.Notifier, error) {
+	if f.ListNotifiersFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.ListNotifiersFunc(ctx)
+}
+
+// ListNotifierProviders calls ListNotifierProvidersFunc.
+func (f *FakeNotifierAPI) ListNotifierProviders(ctx context.Context) (map[string]*shodan.NotifierProvider, error) {
+	if f.ListNotifierProvidersFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.ListNotifierProvidersFunc(ctx)
+}
+
+// GetNotifier calls GetNotifierFunc.
+func (f *FakeNotifierAPI) GetNotifier(ctx context.Context, id string) (*shodan.Notifier, error) {
+	if f.GetNotifierFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.GetNotifierFunc(ctx, id)
+}
+
+// CreateNotifier calls CreateNotifierFunc.
+func (f *FakeNotifierAPI) CreateNotifier(ctx context.Context, provider, description string, args map[string]string) (*shodan.Notifier, error) {
+	if f.CreateNotifierFunc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return f.CreateNotifierFunc(ctx, provider, description, args)
+}
+
+// UpdateNotifier calls UpdateNotifierFunc.
+func (f *FakeNotifierAPI) UpdateNotifier(ctx context.Context, id string, args map[string]string) error {
+	if f.UpdateNotifierFunc == nil {
+		return ErrNotConfigured
+	}
+
+	return f.UpdateNotifierFunc(ctx, id, args)
+}
+
+// DeleteNotifier calls DeleteNotifierFunc.
+func (f *FakeNotifierAPI) DeleteNotifier(ctx context.Context, id string) error {
+	if f.DeleteNotifierFunc == nil {
+		return ErrNotConfigured
+	}
+
+	return f.DeleteNotifierFunc(ctx, id)
+}
+
+// AddAlertNotifier calls AddAlertNotifierFunc.
+func (f *FakeNotifierAPI) AddAlertNotifier(ctx context.Context, alertID, notifierID string) error {
+	if f.AddAlertNotifierFunc == nil {
+		return ErrNotConfigured
+	}
+
+	return f.AddAlertNotifierFunc(ctx, alertID, notifierID)
+}
+
+// RemoveAlertNotifier calls RemoveAlertNotifierFunc.
+func (f *FakeNotifierAPI) RemoveAlertNotifier(ctx context.Context, alertID, notifierID string) error {
+	if f.RemoveAlertNotifierFunc == nil {
+		return ErrNotConfigured
+	}
+
+	return f.RemoveAlertNotifierFunc(ctx, alertID, notifierID)
+}
+
+// FakeStreamer implements shodan.Streamer. Every started stream sends Banners to the channel and closes it,
+// just like the client does when the connection ends or the context is done. Only one stream can be started,
+// and the zero value is ready to use.
+type FakeStreamer struct {
+	Banners []shodan.HostData
+
+	once sync.Once
+	ch   chan shodan.HostData
+
+	mu      sync.Mutex
+	streams []string
+}
+
+func (f *FakeStreamer) start(ctx context.Context, stream string) {
+	f.mu.Lock()
+	f.streams = append(f.streams, stream)
+	f.mu.Unlock()
+
+	ch := f.channel()
+	go func() {
+		defer close(ch)
+
+		for _, banner := range f.Banners {
+			select {
+			case ch <- banner:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (f *FakeStreamer) channel() chan shodan.HostData {
+	f.once.Do(func() {
+		f.ch = make(chan shodan.HostData)
+	})
+
+	return f.ch
+}
+
+// Streams returns the names of the started streams, i.e. "banners" or "ports:22,80".
+func (f *FakeStreamer) Streams() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.streams...)
+}
+
+// GetBanners starts the "banners" stream.
+func (f *FakeStreamer) GetBanners(ctx context.Context) {
+	f.start(ctx, "banners")
+}
+
+// GetBannersByPorts starts the "ports:<ports>" stream.
+func (f *FakeStreamer) GetBannersByPorts(ctx context.Context, ports []int) {
+	stream := "ports:"
+	for i, port := range ports {
+		if i > 0 {
+			stream += ","
+		}
+
+		stream += strconv.Itoa(port)
+	}
+
+	f.start(ctx, stream)
+}
+
+// GetBannersByASN starts the "asn:<asns>" stream.
+func (f *FakeStreamer) GetBannersByASN(ctx context.Context, asns []string) {
+	f.start(ctx, "asn:"+strings.Join(asns, ","))
+}
+
+// GetBannersByCountries starts the "countries:<countries>" stream.
+func (f *FakeStreamer) GetBannersByCountries(ctx context.Context, countries []string) {
+	f.start(ctx, "countries:"+strings.Join(countries, ","))
+}
+
+// GetBannersByAlert starts the "alert:<id>" stream.
+func (f *FakeStreamer) GetBannersByAlert(ctx context.Context, id string) {
+	f.start(ctx, "alert:"+id)
+}
+
+// GetBannersByAlerts starts the "alerts" stream.
+func (f *FakeStreamer) GetBannersByAlerts(ctx context.Context) {
+	f.start(ctx, "alerts")
+}
+
+// BannerStream returns the channel the banners are sent to.
+func (f *FakeStreamer) BannerStream() <-chan shodan.HostData {
+	return f.channel()
+}
+
+// notFound returns the error the client would return for a 404 response of the endpoint.
+func notFound(method, endpoint, path, message string) *shodan.APIError {
+	body, _ := json.Marshal(map[string]string{"error": message})
+
+	return &shodan.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    message,
+		Body:       body,
+		Method:     method,
+		Endpoint:   endpoint,
+		Path:       path,
+	}
+}
@@ -0,0 +1,152 @@
+package shodantest
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeHostSearcher(t *testing.T) {
+	var searcher shodan.HostSearcher = &FakeHostSearcher{
+		GetHostsForQueryFunc: func(ctx context.Context, options *shodan.HostQueryOptions) (*shodan.HostMatch, error) {
+			assert.Equal(t, "nginx", options.Query)
+			return &shodan.HostMatch{Total: 2, Matches: []*shodan.HostData{{IP: "1.1.1.1"}, {IP: "8.8.8.8"}}}, nil
+		},
+	}
+
+	var ips []string
+	summary, err := searcher.SearchHostsFunc(context.Background(), &shodan.HostQueryOptions{Query: "nginx"}, func(banner *shodan.HostData) error {
+		ips = append(ips, banner.IP)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, []string{"1.1.1.1", "8.8.8.8"}, ips)
+
+	_, err = searcher.GetServicesForHost(context.Background(), "8.8.8.8", nil)
+	assert.Equal(t, ErrNotConfigured, err)
+}
+
+func TestFakeScanAPI(t *testing.T) {
+	var scanner shodan.ScanAPI = &FakeScanAPI{
+		ScanFunc: func(ctx context.Context, ip []string) (*shodan.CrawlScanStatus, error) {
+			return &shodan.CrawlScanStatus{ID: "SCAN", Count: len(ip)}, nil
+		},
+	}
+
+	status, err := scanner.Scan(context.Background(), []string{"1.1.1.1", "8.8.8.8"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, status.Count)
+
+	_, err = scanner.ScanInternet(context.Background(), 80, "http")
+	assert.Equal(t, ErrNotConfigured, err)
+
+	_, err = scanner.GetScanStatus(context.Background(), status.ID)
+	assert.Equal(t, ErrNotConfigured, err)
+}
+
+func TestFakeAlertAPI(t *testing.T) {
+	var alerts shodan.AlertAPI = new(FakeAlertAPI)
+
+	created, err := alerts.CreateAlert(context.Background(), "edge", []string{"198.20.88.0/24"}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "FAKEALERT0000001", created.ID)
+
+	alert, err := alerts.GetAlert(context.Background(), created.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, created, alert)
+
+	all, _ := alerts.GetAlerts(context.Background())
+	assert.Len(t, all, 1)
+
+	deleted, err := alerts.DeleteAlert(context.Background(), created.ID)
+	assert.True(t, deleted)
+	assert.Nil(t, err)
+
+	_, err = alerts.GetAlert(context.Background(), created.ID)
+	assert.Equal(t, "shodan: GET /shodan/alert/{id}/info -> 404: Invalid Alert ID", err.Error())
+}
+
+func TestFakeDNSAPI(t *testing.T) {
+	var dns shodan.DNSAPI = &FakeDNSAPI{
+		Hosts:    map[string]string{"google.com": "74.125.227.163"},
+		Reverses: map[string][]string{"8.8.8.8": {"dns.google"}},
+		Domains:  map[string]*shodan.DomainInfo{"google.com": {Domain: "google.com"}},
+	}
+
+	resolved, err := dns.GetDNSResolve(context.Background(), []string{"google.com", "idonotexist.local"})
+	assert.Nil(t, err)
+	assert.Equal(t, net.ParseIP("74.125.227.163"), *resolved["google.com"])
+	assert.Nil(t, resolved["idonotexist.local"])
+
+	reversed, err := dns.GetDNSReverse(context.Background(), []net.IP{net.ParseIP("8.8.8.8")})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dns.google"}, *reversed["8.8.8.8"])
+
+	domain, err := dns.GetDomain(context.Background(), "google.com", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "google.com", domain.Domain)
+
+	_, err = dns.GetDomain(context.Background(), "idonotexist.local", nil)
+	assert.NotNil(t, err)
+}
+
+func TestFakeAccountAPI(t *testing.T) {
+	var account shodan.AccountAPI = new(FakeAccountAPI)
+
+	info, err := account.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "basic", info.Plan)
+	assert.Equal(t, 2341, info.QueryCredits)
+
+	profile, err := account.GetAccountProfile(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 40, profile.Credits)
+
+	account = &FakeAccountAPI{Info: &shodan.APIInfo{Plan: "enterprise", QueryCredits: 10}}
+
+	info, err = account.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "enterprise", info.Plan)
+	assert.True(t, info.SupportsFirehose())
+}
+
+func TestFakeNotifierAPI(t *testing.T) {
+	var notifiers shodan.NotifierAPI = &FakeNotifierAPI{
+		DeleteNotifierFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+	}
+
+	assert.Nil(t, notifiers.DeleteNotifier(context.Background(), "default"))
+	assert.Equal(t, ErrNotConfigured, notifiers.AddAlertNotifier(context.Background(), "ZZ4TDUUORVE1DIIP", "default"))
+
+	_, err := notifiers.ListNotifiers(context.Background())
+	assert.Equal(t, ErrNotConfigured, err)
+}
+
+func TestFakeStreamer(t *testing.T) {
+	fake := &FakeStreamer{Banners: []shodan.HostData{{IP: "1.1.1.1", Port: 22}, {IP: "8.8.8.8", Port: 80}}}
+
+	var streamer shodan.Streamer = fake
+	streamer.GetBannersByPorts(context.Background(), []int{22, 80})
+
+	var banners []shodan.HostData
+	for banner := range streamer.BannerStream() {
+		banners = append(banners, banner)
+	}
+
+	assert.Equal(t, fake.Banners, banners)
+	assert.Equal(t, []string{"ports:22,80"}, fake.Streams())
+
+	countries := new(FakeStreamer)
+	countries.GetBannersByCountries(context.Background(), []string{"US", "DE"})
+	for range countries.BannerStream() {
+	}
+
+	assert.Equal(t, []string{"countries:US,DE"}, countries.Streams())
+}
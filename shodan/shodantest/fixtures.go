@@ -0,0 +1,92 @@
+package shodantest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// fixtures are realistic responses of the API, they are served by Server too.
+//
+//go:embed fixtures
+var fixtures embed.FS
+
+// Fixture returns the content of the fixture, it panics when there's no fixture with the name. The
+// fixtures are realistic responses of the API, their names are stable and part of the API of the
+// package:
+//
+//	info                               the API plan information, GetAPIInfo
+//	profile                            the account profile, GetAccountProfile
+//	host/host                          a host lookup of 8.8.8.8, GetServicesForHost
+//	host/ipv6                          a host lookup of an IPv6-only host with SSL information
+//	host/search                        a page of search results with facets, GetHostsForQuery
+//	host/search_minified               a page of minified search results
+//	host/version                       search results with numeric and string versions
+//	host/count                         a count with facets, GetHostsCountForQuery
+//	stream/banners                     a list of banners as sent by the streams, one message each
+//	alert/alert                        a single network alert, GetAlert
+//	alert/alerts                       a list of network alerts, GetAlerts
+//	alert/alert_triggers               a network alert with enabled triggers and a notifier
+//	alert/create_alert                 a newly created network alert, CreateAlert
+//	alert/triggers                     the triggers of the network alerts, GetAlertTriggers
+//	notifier/notifiers                 the notifiers of the account, ListNotifiers
+//	notifier/notifier                  a single Slack notifier, GetNotifier
+//	notifier/providers                 the notifier providers, ListNotifierProviders
+//	scan                               a submitted scan, Scan
+//	scan_status                        a finished scan, GetScanStatus
+//	dns_resolve                        the resolved hostnames, GetDNSResolve
+//	dns_domain                         the subdomains and the live records of example.com, GetDomain
+//	dns_domain_history                 the records of example.com with the history included
+//	dns_reverse                        the hostnames of the IPs, GetDNSReverse
+//	ports                              the crawled ports, GetPorts
+//	protocols                          the protocols on-demand scans support, GetProtocols
+//	services                           the services Shodan detects, GetServices
+//	headers                            the HTTP headers of the request, GetHTTPHeaders
+//	query_search_results               a page of the saved search queries, GetQueries
+//	query_tags                         the popular query tags, GetQueryTags
+//	exploits/exploits_search           a page of exploits, SearchExploits
+//	exploits/exploits_count_facets     an exploits count with facets, CountExploits
+//	exploits/exploits_count_no_facets  an exploits count without facets
+//	data/datasets                      the bulk datasets, GetDatasets
+//	data/dataset                       the files of a bulk dataset, GetDatasetFiles
+//	errors/unauthorized                the response to an invalid API key
+//	errors/not_found                   the response to a host lookup of an unknown IP
+//	errors/invalid_query               the response to a malformed search query
+//	errors/no_credits                  the response to a call the account can't afford
+func Fixture(name string) []byte {
+	content, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		panic(fmt.Sprintf("shodantest: missing fixture %s: %s", name, err))
+	}
+
+	return content
+}
+
+// FixtureJSON decodes the fixture into v, it panics when there's no fixture with the name.
+func FixtureJSON(name string, v interface{}) error {
+	if err := json.Unmarshal(Fixture(name), v); err != nil {
+		return fmt.Errorf("shodantest: can't decode fixture %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// FixtureNames returns the names of all the fixtures in alphabetical order.
+func FixtureNames() []string {
+	var names []string
+	fs.WalkDir(fixtures, "fixtures", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && strings.HasSuffix(name, ".json") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(name, "fixtures/"), ".json"))
+		}
+
+		return err
+	})
+
+	sort.Strings(names)
+
+	return names
+}
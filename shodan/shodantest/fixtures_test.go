@@ -0,0 +1,104 @@
+package shodantest
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/stretchr/testify/assert"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// fixtureTypes are the types the fixtures decode into, every fixture must be listed.
+var fixtureTypes = map[string]interface{}{
+	"info":                              shodan.APIInfo{},
+	"profile":                           shodan.Profile{},
+	"host/host":                         shodan.Host{},
+	"host/ipv6":                         shodan.Host{},
+	"host/search":                       shodan.HostMatch{},
+	"host/search_minified":              shodan.HostMatch{},
+	"host/version":                      shodan.HostMatch{},
+	"host/count":                        shodan.HostMatch{},
+	"stream/banners":                    []*shodan.HostData{},
+	"alert/alert":                       shodan.Alert{},
+	"alert/alerts":                      []*shodan.Alert{},
+	"alert/alert_triggers":              shodan.Alert{},
+	"alert/create_alert":                shodan.Alert{},
+	"alert/triggers":                    []*shodan.AlertTrigger{},
+	"notifier/notifiers":                struct{ Matches []*shodan.Notifier }{},
+	"notifier/notifier":                 shodan.Notifier{},
+	"notifier/providers":                map[string]*shodan.NotifierProvider{},
+	"scan":                              shodan.CrawlScanStatus{},
+	"scan_status":                       shodan.ScanStatus{},
+	"dns_resolve":                       map[string]*net.IP{},
+	"dns_domain":                        shodan.DomainInfo{},
+	"dns_domain_history":                shodan.DomainInfo{},
+	"dns_reverse":                       map[string]*[]string{},
+	"ports":                             []int{},
+	"protocols":                         map[string]string{},
+	"services":                          map[string]string{},
+	"headers":                           map[string]string{},
+	"query_search_results":              shodan.QuerySearch{},
+	"query_tags":                        shodan.QueryTags{},
+	"exploits/exploits_search":          shodan.ExploitSearch{},
+	"exploits/exploits_count_facets":    shodan.ExploitSearch{},
+	"exploits/exploits_count_no_facets": shodan.ExploitSearch{},
+	"data/datasets":                     []*shodan.Dataset{},
+	"data/dataset":                      []*shodan.DatasetFile{},
+	"errors/unauthorized":               errorResponse{},
+	"errors/not_found":                  errorResponse{},
+	"errors/invalid_query":              errorResponse{},
+	"errors/no_credits":                 errorResponse{},
+}
+
+func TestFixtures_decode(t *testing.T) {
+	names := FixtureNames()
+	assert.Len(t, names, len(fixtureTypes))
+
+	for _, name := range names {
+		typ, ok := fixtureTypes[name]
+		if !assert.True(t, ok, "fixture %s has no type", name) {
+			continue
+		}
+
+		value := reflect.New(reflect.TypeOf(typ))
+		assert.Nil(t, FixtureJSON(name, value.Interface()), name)
+		assert.False(t, value.Elem().IsZero(), "fixture %s decodes to an empty value", name)
+	}
+}
+
+func TestFixtures_edgeCases(t *testing.T) {
+	var host shodan.Host
+	assert.Nil(t, FixtureJSON("host/ipv6", &host))
+	assert.Equal(t, "2001:db8::1", host.IP)
+	assert.Equal(t, 0, host.IPLong)
+	assert.Len(t, host.Data, 2)
+	assert.Equal(t, "R3", host.Data[1].SSL.Cert.Issuer["CN"])
+
+	var minified shodan.HostMatch
+	assert.Nil(t, FixtureJSON("host/search_minified", &minified))
+	assert.NotEmpty(t, minified.Matches)
+	for _, match := range minified.Matches {
+		assert.Empty(t, match.Data)
+	}
+
+	var apiErr errorResponse
+	assert.Nil(t, FixtureJSON("errors/unauthorized", &apiErr))
+	assert.Equal(t, "Please provide a valid API key", apiErr.Error)
+}
+
+func TestFixture_missing(t *testing.T) {
+	assert.Panics(t, func() { Fixture("nope") })
+
+	var v int
+	err := FixtureJSON("info", &v)
+	assert.NotNil(t, err)
+
+	var typeErr *json.UnmarshalTypeError
+	assert.ErrorAs(t, err, &typeErr)
+}
@@ -0,0 +1,299 @@
+package shodantest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mode tells the recorder whether to record or to replay the interactions.
+type Mode int
+
+const (
+	// ModeReplay serves the responses stored in the cassette, nothing is sent over the network.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests to the real API and stores the responses in the cassette.
+	ModeRecord
+)
+
+// redacted replaces the API key and the account identifiers in the cassettes.
+const redacted = "REDACTED"
+
+// redactedFields are the fields of the JSON responses identifying the account.
+var redactedFields = map[string]bool{
+	"display_name": true,
+	"email":        true,
+	"username":     true,
+}
+
+// skippedHeaders are the response headers that are not stored, they are either sensitive or
+// would not describe the replayed response correctly.
+var skippedHeaders = map[string]bool{
+	"Set-Cookie":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Date":              true,
+}
+
+// Interaction is a single request and its response stored in a cassette.
+type Interaction struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// Body holds JSON bodies, so the cassettes stay readable.
+	Body json.RawMessage `json:"body,omitempty"`
+	// Text holds the bodies that are not JSON, i.e. the streams.
+	Text string `json:"text,omitempty"`
+}
+
+func (i *Interaction) matches(r *http.Request) bool {
+	return i.Method == r.Method && i.Path == r.URL.Path && i.Query == normalizeQuery(r)
+}
+
+// Recorder is an http.RoundTripper recording the interactions with the real API to a JSON cassette
+// and replaying them later, so the tests can run offline and without an API key. In replay mode the
+// requests are matched on the method, the path and the query, every unmatched request fails the test.
+// Identical requests are answered in the recorded order, the last answer is repeated when they run out.
+type Recorder struct {
+	// Transport sends the requests in record mode, http.DefaultTransport is used when it's nil.
+	Transport http.RoundTripper
+
+	t        testing.TB
+	mode     Mode
+	cassette string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         map[*Interaction]bool
+}
+
+// NewRecorder creates a recorder using the cassette file. In replay mode the cassette is loaded right
+// away, in record mode it's written when the test finishes.
+func NewRecorder(t testing.TB, cassette string, mode Mode) *Recorder {
+	t.Helper()
+
+	r := &Recorder{t: t, mode: mode, cassette: cassette, used: make(map[*Interaction]bool)}
+
+	if mode == ModeReplay {
+		content, err := os.ReadFile(cassette)
+		if err != nil {
+			t.Fatalf("shodantest: can't load cassette: %s", err)
+		}
+
+		if err := json.Unmarshal(content, &r.interactions); err != nil {
+			t.Fatalf("shodantest: malformed cassette %s: %s", cassette, err)
+		}
+	} else {
+		t.Cleanup(func() {
+			if err := r.save(); err != nil {
+				t.Errorf("shodantest: can't save cassette: %s", err)
+			}
+		})
+	}
+
+	return r
+}
+
+// Client returns an HTTP client using the recorder, it's meant to be passed to shodan.NewClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+
+	return r.replay(req)
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found *Interaction
+	for _, interaction := range r.interactions {
+		if !interaction.matches(req) {
+			continue
+		}
+
+		found = interaction
+		if !r.used[interaction] {
+			break
+		}
+	}
+
+	if found == nil {
+		err := fmt.Errorf("shodantest: no interaction recorded for %s %s?%s in %s",
+			req.Method, req.URL.Path, normalizeQuery(req), r.cassette)
+		r.t.Errorf("%s", err)
+
+		return nil, err
+	}
+
+	r.used[found] = true
+
+	body := []byte(found.Text)
+	if found.Body != nil {
+		body = found.Body
+	}
+
+	header := found.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", found.Status, http.StatusText(found.Status)),
+		StatusCode:    found.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	key := req.URL.Query().Get("key")
+	interaction := &Interaction{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  normalizeQuery(req),
+		Status: res.StatusCode,
+		Header: make(http.Header),
+	}
+
+	for name, values := range res.Header {
+		if skippedHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+
+		for _, value := range values {
+			interaction.Header.Add(name, redact(value, key))
+		}
+	}
+
+	scrubbed := []byte(redact(string(body), key))
+	if json.Valid(scrubbed) {
+		interaction.Body = redactJSON(scrubbed)
+	} else {
+		interaction.Text = string(scrubbed)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+func (r *Recorder) save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.cassette), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.cassette, append(content, '\n'), 0644)
+}
+
+// normalizeQuery returns the query without the API key and with the parameters sorted.
+func normalizeQuery(req *http.Request) string {
+	query := req.URL.Query()
+	query.Del("key")
+
+	return query.Encode()
+}
+
+func redact(s, key string) string {
+	if key == "" {
+		return s
+	}
+
+	return strings.ReplaceAll(s, key, redacted)
+}
+
+// redactJSON replaces the values of the account identifying fields at any depth.
+func redactJSON(body []byte) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	if !redactValue(value) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+
+	return redactedBody
+}
+
+func redactValue(value interface{}) bool {
+	changed := false
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if redactedFields[name] {
+				if s, ok := child.(string); ok && s != "" {
+					v[name] = redacted
+					changed = true
+				}
+
+				continue
+			}
+
+			changed = redactValue(child) || changed
+		}
+	case []interface{}:
+		for _, child := range v {
+			changed = redactValue(child) || changed
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,125 @@
+package shodantest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordCassette(t *testing.T) string {
+	cassette := filepath.Join(t.TempDir(), "cassettes", "client.json")
+
+	server := NewServer()
+	defer server.Close()
+
+	server.Handle("/account/profile", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"member": true, "credits": 20, "display_name": "jdoe", "created": "2015-09-03T12:44:29.278000", "key": %q}`, Token)
+	})
+
+	t.Run("record", func(t *testing.T) {
+		recorder := NewRecorder(t, cassette, ModeRecord)
+
+		client := server.Client()
+		client.Client = recorder.Client()
+
+		_, err := client.GetAccountProfile(context.Background())
+		assert.Nil(t, err)
+
+		_, err = client.GetDNSResolve(context.Background(), []string{"google.com", "bing.com"})
+		assert.Nil(t, err)
+
+		_, err = client.GetServicesForHost(context.Background(), "127.0.0.1", nil)
+		assert.NotNil(t, err)
+
+		client.GetBanners(context.Background())
+		for range client.StreamChan {
+		}
+	})
+
+	return cassette
+}
+
+func TestRecorder_record(t *testing.T) {
+	cassette := recordCassette(t)
+
+	content, err := os.ReadFile(cassette)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(content), Token)
+	assert.NotContains(t, string(content), "jdoe")
+	assert.Contains(t, string(content), `"query": "hostnames=google.com%2Cbing.com"`)
+	assert.Equal(t, 4, strings.Count(string(content), `"method": "GET"`))
+}
+
+func TestRecorder_replay(t *testing.T) {
+	cassette := recordCassette(t)
+
+	recorder := NewRecorder(t, cassette, ModeReplay)
+	client := shodan.NewClient(recorder.Client(), "ANOTHER_TOKEN")
+	client.BaseURL = "http://127.0.0.1:1"
+	client.StreamBaseURL = "http://127.0.0.1:1"
+
+	profile, err := client.GetAccountProfile(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &shodan.Profile{Member: true, Credits: 20, Name: "REDACTED", Created: shodan.Time{Time: time.Date(2015, time.September, 3, 12, 44, 29, 278000000, time.UTC)}}, profile)
+
+	for i := 0; i < 2; i++ {
+		resolved, err := client.GetDNSResolve(context.Background(), []string{"google.com", "bing.com"})
+		assert.Nil(t, err)
+		assert.Equal(t, "74.125.227.163", resolved["google.com"].String())
+	}
+
+	_, err = client.GetServicesForHost(context.Background(), "127.0.0.1", nil)
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    "No information available for that IP.",
+		Body:       []byte("{\n      \"error\": \"No information available for that IP.\"\n    }"),
+		Method:     "GET",
+		Endpoint:   "/shodan/host/{ip}",
+		Path:       "/shodan/host/127.0.0.1",
+	}, err)
+
+	client.GetBanners(context.Background())
+
+	var banners []shodan.HostData
+	for banner := range client.StreamChan {
+		banners = append(banners, banner)
+	}
+
+	assert.Len(t, banners, 2)
+}
+
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder_replayUnmatched(t *testing.T) {
+	cassette := recordCassette(t)
+
+	recorder := NewRecorder(t, cassette, ModeReplay)
+	failures := &recordingT{TB: t}
+	recorder.t = failures
+
+	client := shodan.NewClient(recorder.Client(), "ANOTHER_TOKEN")
+	client.BaseURL = "http://127.0.0.1:1"
+
+	_, err := client.GetDNSResolve(context.Background(), []string{"example.com"})
+
+	assert.NotNil(t, err)
+	assert.Len(t, failures.errors, 1)
+	assert.Contains(t, failures.errors[0], "no interaction recorded for GET /dns/resolve?hostnames=example.com")
+}
@@ -0,0 +1,727 @@
+// Package shodantest provides a fake Shodan API server for testing code that uses the shodan package.
+// The server answers the major endpoints with canned responses and can be told to fail, slow down or
+// rate limit requests.
+package shodantest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+)
+
+// Token is the only API key accepted by the server, any other one gets 401.
+const Token = "TEST_TOKEN"
+
+// HostIP is the IP address the host lookup knows about, the other ones get 404.
+const HostIP = "8.8.8.8"
+
+type fault struct {
+	status  int
+	message string
+}
+
+// Server is a fake Shodan API, exploits and streaming server. It's safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+
+	server *httptest.Server
+
+	mu         sync.Mutex
+	latency    time.Duration
+	faults     map[string]fault
+	rateLimits map[string]int
+	handlers   map[string]http.HandlerFunc
+	requests   map[string]int
+	alerts     []*shodan.Alert
+	notifiers  []*shodan.Notifier
+	scans      map[string]*shodan.ScanStatus
+	banners    [][]byte
+}
+
+// NewServer starts a new server, it has to be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		faults:     make(map[string]fault),
+		rateLimits: make(map[string]int),
+		handlers:   make(map[string]http.HandlerFunc),
+		requests:   make(map[string]int),
+		scans:      make(map[string]*shodan.ScanStatus),
+	}
+
+	if err := json.Unmarshal(Fixture("alert/alerts"), &s.alerts); err != nil {
+		panic(err)
+	}
+
+	var notifiers struct {
+		Matches []*shodan.Notifier `json:"matches"`
+	}
+	if err := json.Unmarshal(Fixture("notifier/notifiers"), &notifiers); err != nil {
+		panic(err)
+	}
+
+	s.notifiers = notifiers.Matches
+
+	var scan shodan.ScanStatus
+	if err := json.Unmarshal(Fixture("scan_status"), &scan); err != nil {
+		panic(err)
+	}
+
+	s.scans[scan.ID] = &scan
+
+	var search struct {
+		Matches []json.RawMessage `json:"matches"`
+	}
+	if err := json.Unmarshal(Fixture("host/search"), &search); err != nil {
+		panic(err)
+	}
+
+	for _, match := range search.Matches {
+		s.banners = append(s.banners, compact(match))
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a client using the server for the regular, exploits and streaming APIs.
+func (s *Server) Client(options ...shodan.ClientOption) *shodan.Client {
+	client := shodan.NewClient(s.server.Client(), Token, options...)
+	client.BaseURL = s.URL
+	client.ExploitBaseURL = s.URL + shodan.DefaultExploitPathPrefix
+	client.StreamBaseURL = s.URL
+
+	return client
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// InjectError makes the requests to the path fail with the status and the message until ClearErrors
+// is called. An empty path makes every request fail.
+func (s *Server) InjectError(path string, status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[path] = fault{status: status, message: message}
+}
+
+// ClearErrors removes the errors injected with InjectError.
+func (s *Server) ClearErrors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = make(map[string]fault)
+}
+
+// RateLimit answers the next n requests to the path with 429. An empty path applies to every request.
+func (s *Server) RateLimit(path string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rateLimits[path] = n
+}
+
+// Handle replaces the canned response of the path with the handler.
+func (s *Server) Handle(path string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[path] = handler
+}
+
+// SetBanners replaces the banners sent by the streaming endpoints, one per line.
+// By default the matches of the canned search are sent.
+func (s *Server) SetBanners(banners ...[]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.banners = nil
+	for _, banner := range banners {
+		s.banners = append(s.banners, compact(banner))
+	}
+}
+
+// Requests returns the number of requests the server received for the path, failed ones included.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+func compact(b []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return b
+	}
+
+	return buf.Bytes()
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeValue(w http.ResponseWriter, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	writeJSON(w, status, body)
+}
+
+// intercept applies the configured latency and failures. It returns false when the request
+// has been answered already.
+func (s *Server) intercept(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	latency := s.latency
+
+	limited := false
+	for _, key := range []string{r.URL.Path, ""} {
+		if s.rateLimits[key] > 0 {
+			s.rateLimits[key]--
+			limited = true
+			break
+		}
+	}
+
+	f, failed := s.faults[r.URL.Path]
+	if !failed {
+		f, failed = s.faults[""]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return false
+		}
+	}
+
+	switch {
+	case r.URL.Query().Get("key") != Token:
+		writeError(w, http.StatusUnauthorized, "Please provide a valid API key")
+	case limited:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "Request rate limit reached (1/second). Please wait a second before trying again and slow down your API calls.")
+	case failed:
+		writeError(w, f.status, f.message)
+	default:
+		return true
+	}
+
+	return false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.intercept(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	handler, ok := s.handlers[r.URL.Path]
+	s.mu.Unlock()
+
+	if ok {
+		handler(w, r)
+		return
+	}
+
+	p := r.URL.Path
+	switch {
+	case p == "/api-info":
+		writeJSON(w, http.StatusOK, Fixture("info"))
+	case p == "/dns/resolve":
+		s.serveDNS(w, r, "hostnames", "dns_resolve")
+	case p == "/dns/reverse":
+		s.serveDNS(w, r, "ips", "dns_reverse")
+	case strings.HasPrefix(p, "/dns/domain/"):
+		s.serveDomain(w, r, strings.TrimPrefix(p, "/dns/domain/"))
+	case p == "/shodan/host/search":
+		s.serveSearch(w, r)
+	case p == "/shodan/host/count":
+		s.serveCount(w, r)
+	case strings.HasPrefix(p, "/shodan/host/"):
+		s.serveHost(w, r)
+	case p == "/shodan/alert" && r.Method == "POST":
+		s.createAlert(w, r)
+	case p == "/shodan/alert/info":
+		s.listAlerts(w)
+	case p == "/shodan/alert/triggers":
+		writeJSON(w, http.StatusOK, Fixture("alert/triggers"))
+	case strings.HasPrefix(p, "/shodan/alert/") && strings.Contains(p, "/notifier/"):
+		s.serveAlertNotifier(w, r, strings.TrimPrefix(p, "/shodan/alert/"))
+	case strings.HasPrefix(p, "/shodan/alert/") && strings.HasSuffix(p, "/info"):
+		s.getAlert(w, strings.TrimSuffix(strings.TrimPrefix(p, "/shodan/alert/"), "/info"))
+	case strings.HasPrefix(p, "/shodan/alert/") && r.Method == "DELETE":
+		s.deleteAlert(w, strings.TrimPrefix(p, "/shodan/alert/"))
+	case p == "/notifier" && r.Method == "POST":
+		s.createNotifier(w, r)
+	case p == "/notifier":
+		s.listNotifiers(w)
+	case p == "/notifier/provider":
+		writeJSON(w, http.StatusOK, Fixture("notifier/providers"))
+	case strings.HasPrefix(p, "/notifier/"):
+		s.serveNotifier(w, r, strings.TrimPrefix(p, "/notifier/"))
+	case p == "/shodan/scan" && r.Method == "POST":
+		s.submitScan(w, r)
+	case strings.HasPrefix(p, "/shodan/scan/") && r.Method == "GET":
+		s.getScan(w, strings.TrimPrefix(p, "/shodan/scan/"))
+	case p == "/shodan/banners", p == "/shodan/alert", strings.HasPrefix(p, "/shodan/alert/"), strings.HasPrefix(p, "/shodan/ports/"),
+		strings.HasPrefix(p, "/shodan/asn/"), strings.HasPrefix(p, "/shodan/countries/"):
+		s.serveStream(w, r)
+	case strings.HasSuffix(p, "/search"), strings.HasSuffix(p, "/count"):
+		s.serveExploits(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) serveDNS(w http.ResponseWriter, r *http.Request, param, fixtureName string) {
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(Fixture(fixtureName), &known); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make(map[string]json.RawMessage)
+	for _, name := range strings.Split(r.URL.Query().Get(param), ",") {
+		if name == "" {
+			continue
+		}
+
+		if value, ok := known[name]; ok {
+			result[name] = value
+		} else {
+			result[name] = json.RawMessage("null")
+		}
+	}
+
+	writeValue(w, result)
+}
+
+// serveDomain answers with the records of example.com, the history is included when asked for.
+func (s *Server) serveDomain(w http.ResponseWriter, r *http.Request, domain string) {
+	if domain != "example.com" {
+		writeError(w, http.StatusNotFound, "No information available for that domain.")
+		return
+	}
+
+	if r.URL.Query().Get("history") == "true" {
+		writeJSON(w, http.StatusOK, Fixture("dns_domain_history"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Fixture("dns_domain"))
+}
+
+// serveExploits answers the Exploits API under any path prefix, so clients using
+// shodan.WithExploitPathPrefix can be tested too.
+func (s *Server) serveExploits(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("query") == "" {
+		writeError(w, http.StatusBadRequest, "Empty search query")
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/search"):
+		writeJSON(w, http.StatusOK, Fixture("exploits/exploits_search"))
+	case r.URL.Query().Get("facets") != "":
+		writeJSON(w, http.StatusOK, Fixture("exploits/exploits_count_facets"))
+	default:
+		writeJSON(w, http.StatusOK, Fixture("exploits/exploits_count_no_facets"))
+	}
+}
+
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("query") == "" {
+		writeError(w, http.StatusBadRequest, "Empty search query")
+		return
+	}
+
+	if r.URL.Query().Get("minify") == "true" {
+		writeJSON(w, http.StatusOK, Fixture("host/search_minified"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Fixture("host/search"))
+}
+
+func (s *Server) serveCount(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("query") == "" {
+		writeError(w, http.StatusBadRequest, "Empty search query")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Fixture("host/count"))
+}
+
+func (s *Server) serveHost(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimPrefix(r.URL.Path, "/shodan/host/") != HostIP {
+		writeError(w, http.StatusNotFound, "No information available for that IP.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Fixture("host/host"))
+}
+
+func (s *Server) createAlert(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name    string               `json:"name"`
+		Expires int                  `json:"expires"`
+		Filters *shodan.AlertFilters `json:"filters"`
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		writeError(w, http.StatusBadRequest, "Alert must be sent as JSON")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Filters == nil || len(request.Filters.IP) == 0 {
+		writeError(w, http.StatusBadRequest, "Invalid alert filters")
+		return
+	}
+
+	size := 0
+	for _, ip := range request.Filters.IP {
+		n, err := networkSize(ip)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		size += n
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	created := time.Now().UTC().Truncate(time.Microsecond)
+	alert := &shodan.Alert{
+		ID:      strings.ToUpper(hex.EncodeToString(id)),
+		Name:    request.Name,
+		Created: shodan.Time{Time: created},
+		Expires: request.Expires,
+		Size:    size,
+		Filters: request.Filters,
+	}
+
+	if request.Expires > 0 {
+		alert.Expiration = shodan.Time{Time: created.Add(time.Duration(request.Expires) * time.Second)}
+	}
+
+	s.mu.Lock()
+	s.alerts = append(s.alerts, alert)
+	s.mu.Unlock()
+
+	writeValue(w, alert)
+}
+
+func networkSize(ip string) (int, error) {
+	if !strings.Contains(ip, "/") {
+		if net.ParseIP(ip) == nil {
+			return 0, fmt.Errorf("Invalid IP: %s", ip)
+		}
+
+		return 1, nil
+	}
+
+	_, network, err := net.ParseCIDR(ip)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid network: %s", ip)
+	}
+
+	ones, bits := network.Mask.Size()
+
+	return 1 << uint(bits-ones), nil
+}
+
+// scanCredits is the number of scan credits the account of the server has, as told by the info fixture.
+const scanCredits = 254
+
+// submitScan registers a scan of the IPs that is done right away.
+func (s *Server) submitScan(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		writeError(w, http.StatusBadRequest, "Scan must be sent as a form")
+		return
+	}
+
+	count := 0
+	for _, ip := range strings.Split(r.FormValue("ips"), ",") {
+		n, err := networkSize(strings.TrimSpace(ip))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		count += n
+	}
+
+	if count > scanCredits {
+		writeError(w, http.StatusForbidden, "Insufficient scan credits, please help support Shodan and upgrade your API plan.")
+		return
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	scan := &shodan.ScanStatus{
+		ID:      strings.ToUpper(hex.EncodeToString(id)),
+		Count:   count,
+		Status:  shodan.ScanStatusDone,
+		Created: shodan.Time{Time: time.Now().UTC().Truncate(time.Microsecond)},
+	}
+
+	s.mu.Lock()
+	s.scans[scan.ID] = scan
+	s.mu.Unlock()
+
+	writeValue(w, &shodan.CrawlScanStatus{ID: scan.ID, Count: count, CreditsLeft: scanCredits - count})
+}
+
+func (s *Server) getScan(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	scan, ok := s.scans[id]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Scan not found")
+		return
+	}
+
+	writeValue(w, scan)
+}
+
+func (s *Server) listAlerts(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeValue(w, s.alerts)
+}
+
+func (s *Server) getAlert(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, alert := range s.alerts {
+		if alert.ID == id {
+			writeValue(w, alert)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Invalid Alert ID")
+}
+
+func (s *Server) deleteAlert(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, alert := range s.alerts {
+		if alert.ID == id {
+			s.alerts = append(s.alerts[:i], s.alerts[i+1:]...)
+			writeJSON(w, http.StatusOK, []byte("{}"))
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Invalid Alert ID")
+}
+
+func (s *Server) listNotifiers(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeValue(w, map[string]interface{}{"matches": s.notifiers, "total": len(s.notifiers)})
+}
+
+// createNotifier registers a notifier of one of the providers of the fixture, its args are the other
+// fields of the form.
+func (s *Server) createNotifier(w http.ResponseWriter, r *http.Request) {
+	var providers map[string]*shodan.NotifierProvider
+	if err := json.Unmarshal(Fixture("notifier/providers"), &providers); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" || r.ParseForm() != nil {
+		writeError(w, http.StatusBadRequest, "Notifier must be sent as a form")
+		return
+	}
+
+	provider, ok := providers[r.PostForm.Get("provider")]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid provider")
+		return
+	}
+
+	notifier := &shodan.Notifier{
+		Provider:    r.PostForm.Get("provider"),
+		Description: r.PostForm.Get("description"),
+		Args:        notifierArgs(r),
+	}
+
+	for _, arg := range provider.Required {
+		if notifier.Args[arg] == "" {
+			writeError(w, http.StatusBadRequest, "Missing required argument: "+arg)
+			return
+		}
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+	notifier.ID = hex.EncodeToString(id)
+
+	s.mu.Lock()
+	s.notifiers = append(s.notifiers, notifier)
+	s.mu.Unlock()
+
+	writeValue(w, map[string]interface{}{"success": true, "id": notifier.ID})
+}
+
+// serveNotifier answers the requests about a single notifier, by method.
+func (s *Server) serveNotifier(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, notifier := range s.notifiers {
+		if notifier.ID != id {
+			continue
+		}
+
+		switch r.Method {
+		case "GET":
+			writeValue(w, notifier)
+		case "PUT":
+			if r.ParseForm() != nil {
+				writeError(w, http.StatusBadRequest, "Notifier must be sent as a form")
+				return
+			}
+
+			notifier.Args = notifierArgs(r)
+			writeJSON(w, http.StatusOK, []byte(`{"success": true}`))
+		case "DELETE":
+			s.notifiers = append(s.notifiers[:i], s.notifiers[i+1:]...)
+			writeJSON(w, http.StatusOK, []byte(`{"success": true}`))
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "Unable to find notifier")
+}
+
+// notifierArgs returns the fields of the form but the provider and the description.
+func notifierArgs(r *http.Request) map[string]string {
+	args := make(map[string]string)
+	for name := range r.PostForm {
+		if name != "provider" && name != "description" {
+			args[name] = r.PostForm.Get(name)
+		}
+	}
+
+	return args
+}
+
+// serveAlertNotifier adds the notifier to the alert with PUT and removes it with DELETE, path is
+// "{id}/notifier/{notifier_id}".
+func (s *Server) serveAlertNotifier(w http.ResponseWriter, r *http.Request, path string) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || (r.Method != "PUT" && r.Method != "DELETE") {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var alert *shodan.Alert
+	for _, candidate := range s.alerts {
+		if candidate.ID == parts[0] {
+			alert = candidate
+		}
+	}
+
+	if alert == nil {
+		writeError(w, http.StatusNotFound, "Invalid Alert ID")
+		return
+	}
+
+	for _, notifier := range s.notifiers {
+		if notifier.ID != parts[2] {
+			continue
+		}
+
+		notifiers := make([]*shodan.Notifier, 0, len(alert.Notifiers)+1)
+		for _, added := range alert.Notifiers {
+			if added.ID != notifier.ID {
+				notifiers = append(notifiers, added)
+			}
+		}
+
+		if r.Method == "PUT" {
+			notifiers = append(notifiers, notifier)
+		}
+
+		alert.Notifiers = notifiers
+		writeJSON(w, http.StatusOK, []byte(`{"success": true}`))
+
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "Unable to find notifier")
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	banners := s.banners
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	flusher, _ := w.(http.Flusher)
+
+	for _, banner := range banners {
+		w.Write(banner)
+		w.Write([]byte("\n"))
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package shodantest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_host(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	host, err := client.GetServicesForHost(context.Background(), HostIP, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, HostIP, host.IP)
+	assert.Len(t, host.Data, 2)
+
+	_, err = client.GetServicesForHost(context.Background(), "127.0.0.1", nil)
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    "No information available for that IP.",
+		Body:       []byte(`{"error":"No information available for that IP."}`),
+		Method:     "GET",
+		Endpoint:   "/shodan/host/{ip}",
+		Path:       "/shodan/host/127.0.0.1",
+	}, err)
+}
+
+func TestServer_search(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	found, err := client.GetHostsForQuery(context.Background(), &shodan.HostQueryOptions{Query: "port:443"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, found.Total)
+	assert.Equal(t, "nginx", found.Matches[0].Product)
+
+	minified, err := client.GetHostsForQuery(context.Background(), &shodan.HostQueryOptions{Query: "port:443", Minify: true})
+	assert.Nil(t, err)
+	assert.Len(t, minified.Matches, 2)
+	assert.Equal(t, "", minified.Matches[0].Product)
+
+	_, err = client.GetHostsForQuery(context.Background(), &shodan.HostQueryOptions{})
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "Empty search query",
+		Body:       []byte(`{"error":"Empty search query"}`),
+		Method:     "GET",
+		Endpoint:   "/shodan/host/search",
+		Path:       "/shodan/host/search",
+	}, err)
+
+	count, err := client.GetHostsCountForQuery(context.Background(), &shodan.HostQueryOptions{Query: "port:443", Facets: "country"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count.Total)
+	assert.Empty(t, count.Matches)
+	assert.Len(t, count.Facets["country"], 2)
+}
+
+func TestServer_exploits(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	found, err := client.SearchExploits(context.Background(), &shodan.ExploitSearchOptions{Query: "exim"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, found.Total)
+	assert.Equal(t, []string{"CVE-2010-4344"}, found.Matches[0].CVE)
+	assert.Equal(t, 1, server.Requests("/api/search"))
+
+	count, err := client.CountExploits(context.Background(), &shodan.ExploitSearchOptions{Query: "port:22", Facets: "platform"})
+	assert.Nil(t, err)
+	assert.Equal(t, 40, count.Total)
+	assert.Len(t, count.Facets["platform"], 2)
+	assert.Equal(t, 1, server.Requests("/api/count"))
+}
+
+func TestServer_scan(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	submitted, err := client.Scan(context.Background(), []string{"8.8.8.8", "198.20.22.0/30"})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, submitted.Count)
+	assert.Equal(t, 249, submitted.CreditsLeft)
+
+	status, err := client.GetScanStatus(context.Background(), submitted.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, submitted.ID, status.ID)
+	assert.Equal(t, 5, status.Count)
+	assert.True(t, status.Done())
+
+	status, err = client.GetScanStatus(context.Background(), "BOMA59VSGWX8QJR9")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, status.Count)
+
+	_, err = client.GetScanStatus(context.Background(), "UNKNOWN")
+	assert.ErrorIs(t, err, shodan.ErrNotFound)
+
+	_, err = client.Scan(context.Background(), []string{"198.20.0.0/16"})
+	var apiErr *shodan.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+}
+
+func TestServer_notifiers(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	ctx := context.Background()
+
+	notifiers, err := client.ListNotifiers(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, notifiers, 2)
+
+	providers, err := client.ListNotifierProviders(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"to"}, providers["email"].Required)
+
+	created, err := client.CreateNotifier(ctx, "email", "Security team", map[string]string{"to": "security@example.com"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, created.ID)
+
+	_, err = client.CreateNotifier(ctx, "slack", "Missing webhook", nil)
+	var apiErr *shodan.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	assert.Nil(t, client.UpdateNotifier(ctx, created.ID, map[string]string{"to": "soc@example.com"}))
+
+	notifier, err := client.GetNotifier(ctx, created.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, &shodan.Notifier{
+		ID:          created.ID,
+		Provider:    "email",
+		Description: "Security team",
+		Args:        map[string]string{"to": "soc@example.com"},
+	}, notifier)
+
+	assert.Nil(t, client.AddAlertNotifier(ctx, "ZZ4TDUUORVE1DIIP", created.ID))
+	alert, err := client.GetAlert(ctx, "ZZ4TDUUORVE1DIIP")
+	assert.Nil(t, err)
+	assert.Equal(t, []*shodan.Notifier{notifier}, alert.Notifiers)
+
+	assert.Nil(t, client.RemoveAlertNotifier(ctx, "ZZ4TDUUORVE1DIIP", created.ID))
+	alert, err = client.GetAlert(ctx, "ZZ4TDUUORVE1DIIP")
+	assert.Nil(t, err)
+	assert.Empty(t, alert.Notifiers)
+	assert.ErrorIs(t, client.AddAlertNotifier(ctx, "UNKNOWN", created.ID), shodan.ErrNotFound)
+	assert.ErrorIs(t, client.RemoveAlertNotifier(ctx, "ZZ4TDUUORVE1DIIP", "unknown"), shodan.ErrNotFound)
+
+	assert.Nil(t, client.DeleteNotifier(ctx, created.ID))
+	_, err = client.GetNotifier(ctx, created.ID)
+	assert.ErrorIs(t, err, shodan.ErrNotFound)
+
+	alerts, err := client.GetAlerts(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 2)
+}
+
+func TestServer_exploitsPathPrefix(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client(shodan.WithExploitPathPrefix("/api/v2/"))
+
+	_, err := client.SearchExploits(context.Background(), &shodan.ExploitSearchOptions{Query: "exim"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.Requests("/api/v2/search"))
+	assert.Equal(t, 0, server.Requests("/api/search"))
+}
+
+func TestServer_stream(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	client.GetBanners(context.Background())
+
+	var banners []shodan.HostData
+	for banner := range client.StreamChan {
+		banners = append(banners, banner)
+	}
+
+	assert.Len(t, banners, 2)
+	assert.Equal(t, "1.1.1.1", banners[0].IP)
+
+	server.SetBanners([]byte(`{"ip_str": "9.9.9.9", "port": 53}`))
+
+	client = server.Client()
+	client.GetBannersByPorts(context.Background(), []int{53})
+
+	banner, ok := <-client.StreamChan
+	assert.True(t, ok)
+	assert.Equal(t, "9.9.9.9", banner.IP)
+
+	_, ok = <-client.StreamChan
+	assert.False(t, ok)
+	assert.Equal(t, 1, server.Requests("/shodan/ports/53"))
+}
+
+func TestServer_invalidToken(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	client.Token = "INVALID"
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusUnauthorized,
+		Message:    "Please provide a valid API key",
+		Body:       []byte(`{"error":"Please provide a valid API key"}`),
+		Method:     "GET",
+		Endpoint:   "/api-info",
+		Path:       "/api-info",
+	}, err)
+}
+
+func TestServer_InjectError(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	server.InjectError("/api-info", http.StatusInternalServerError, "Internal error")
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "Internal error",
+		Body:       []byte(`{"error":"Internal error"}`),
+		Method:     "GET",
+		Endpoint:   "/api-info",
+		Path:       "/api-info",
+	}, err)
+
+	_, err = client.GetDNSResolve(context.Background(), []string{"google.com"})
+	assert.Nil(t, err)
+
+	server.InjectError("", http.StatusServiceUnavailable, "Unavailable")
+
+	_, err = client.GetDNSResolve(context.Background(), []string{"google.com"})
+	assert.Equal(t, &shodan.APIError{
+		StatusCode: http.StatusServiceUnavailable,
+		Message:    "Unavailable",
+		Body:       []byte(`{"error":"Unavailable"}`),
+		Method:     "GET",
+		Endpoint:   "/dns/resolve",
+		Path:       "/dns/resolve",
+	}, err)
+
+	server.ClearErrors()
+
+	_, err = client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, server.Requests("/api-info"))
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := server.Client()
+	server.RateLimit("/api-info", 2)
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetAPIInfo(context.Background())
+		assert.Equal(t, http.StatusTooManyRequests, err.(*shodan.APIError).StatusCode)
+	}
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+}
+
+func TestServer_SetLatency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.SetLatency(50 * time.Millisecond)
+
+	started := time.Now()
+	_, err := server.Client().GetAPIInfo(context.Background())
+
+	assert.Nil(t, err)
+	assert.True(t, time.Since(started) >= 50*time.Millisecond)
+
+	server.SetLatency(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = server.Client().PrecheckCredits(ctx, shodan.CreditEstimate{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServer_Handle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.Handle("/api-info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"plan": "oss", "query_credits": 1}`))
+	})
+
+	info, err := server.Client().GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "oss", info.Plan)
+}
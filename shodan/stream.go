@@ -1,10 +1,10 @@
 package shodan
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -12,65 +12,220 @@ const (
 	bannersAlertPath  = "/shodan/alert/%s"
 	bannersAlertsPath = "/shodan/alert"
 	bannersPortsPath  = "/shodan/ports/%s"
+	bannersASNPath    = "/shodan/asn/%s"
+
+	bannersCountriesPath = "/shodan/countries/%s"
 )
 
-func (c *Client) readBannersResponse(rawChan chan []byte) {
-	for {
-		var banner HostData
-		res, ok := <-rawChan
+// Streamer is the part of the client subscribing to the streaming API. The banners of the
+// started stream are delivered to BannerStream.
+type Streamer interface {
+	GetBanners(ctx context.Context)
+	GetBannersByPorts(ctx context.Context, ports []int)
+	GetBannersByASN(ctx context.Context, asns []string)
+	GetBannersByCountries(ctx context.Context, countries []string)
+	GetBannersByAlert(ctx context.Context, id string)
+	GetBannersByAlerts(ctx context.Context)
+	BannerStream() <-chan HostData
+}
+
+var _ Streamer = (*Client)(nil)
+
+// BannerStream returns the channel the streamed banners are delivered to, it's the same as StreamChan.
+// It's a part of Streamer.
+func (c *Client) BannerStream() <-chan HostData {
+	return c.StreamChan
+}
 
-		if !ok {
-			close(c.StreamChan)
-			break
+// WithStreamErrors makes the streams delivering to StreamChan skip the banners that can't be decoded
+// and send a MalformedBannerError to ch instead. Without it a malformed banner ends the stream. The
+// stream waits for ch to be read, see StreamOptions.Errors for the streams with their own channel.
+func WithStreamErrors(ch chan<- error) ClientOption {
+	return func(c *Client) {
+		c.streamErrors = ch
+	}
+}
+
+// reportMalformedBanner sends the decoding error to errs, false is returned when the stream has to end
+// instead because there's no errs or ctx is done.
+func reportMalformedBanner(ctx context.Context, errs chan<- error, stream string, message []byte, err error) bool {
+	if errs == nil {
+		return false
+	}
+
+	select {
+	case errs <- &MalformedBannerError{Stream: stream, Message: message, Err: err}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// readBannersResponse delivers the banners to StreamChan until the stream ends or ctx is done, the
+// channel is closed then. The stream is cancelled on a malformed banner unless it can be reported
+// and drained until it ends, so its reader isn't left blocked.
+func (c *Client) readBannersResponse(ctx context.Context, cancel context.CancelFunc, stream string, rawChan chan []byte) {
+	defer close(c.StreamChan)
+	defer func() {
+		cancel()
+		for range rawChan {
 		}
+	}()
+
+	for res := range rawChan {
+		var banner HostData
+		if err := c.decodeBannerBytes(res, &banner); err != nil {
+			if reportMalformedBanner(ctx, c.streamErrors, stream, res, err) {
+				continue
+			}
 
-		buf := bytes.NewBuffer(res)
-		if err := c.parseResponse(&banner, buf); err != nil {
-			close(c.StreamChan)
-			break
+			return
 		}
 
-		c.StreamChan <- banner
+		c.observeStreamMessage(stream)
+
+		select {
+		case c.StreamChan <- banner:
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
-func (c *Client) beginStreaming(path string) {
+func (c *Client) beginStreaming(ctx context.Context, path string) {
+	url := c.buildStreamBaseURL(path, nil)
+	rawChan := make(chan []byte)
+
+	stream := endpointTemplate(path)
+	ctx, cancel := context.WithCancel(ctx)
+	ctx, span := c.startStreamSpan(ctx, path)
+	c.observeStreamConnect(stream, false)
+	c.logStreamConnect(stream, false)
+	span.Event("connect")
+
+	go c.readBannersResponse(ctx, cancel, stream, rawChan)
+	go func() {
+		if err := c.executeStreamRequest(ctx, span, "GET", url, rawChan); err != nil {
+			close(rawChan)
+		}
+	}()
+}
+
+// StreamOptions is options for the streams delivering to their own channel.
+type StreamOptions struct {
+	// Buffer is the capacity of the banners channel, 0 makes it unbuffered.
+	Buffer int
+
+	// MinBackoff and MaxBackoff bound the wait before a subscription reconnects, it's doubled after
+	// every failed attempt. They default to DefaultStreamMinBackoff and DefaultStreamMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Errors receives a MalformedBannerError for every banner that can't be decoded, the banner is
+	// skipped and the stream goes on. The stream waits for Errors to be read. Without it a malformed
+	// banner ends the stream, a subscription reconnects then.
+	Errors chan<- error
+}
+
+// openStream subscribes to the stream and delivers its banners to the returned channel until ctx
+// is done or the stream ends, the channel is closed then. Unlike beginStreaming it waits for the
+// subscription, so an error is returned if it fails. The connect or the reconnect event is recorded on
+// the span, reconnect tells a subscription is connecting again.
+func (c *Client) openStream(ctx context.Context, span StreamSpan, path string, options *StreamOptions, reconnect bool) (<-chan *HostData, error) {
+	if options == nil {
+		options = new(StreamOptions)
+	}
+
 	url := c.buildStreamBaseURL(path, nil)
 	rawChan := make(chan []byte)
 
-	go c.readBannersResponse(rawChan)
-	go c.executeStreamRequest("GET", url, rawChan)
+	stream := endpointTemplate(path)
+	ctx, cancel := context.WithCancel(ctx)
+	c.observeStreamConnect(stream, reconnect)
+	c.logStreamConnect(stream, reconnect)
+	if reconnect {
+		span.Event("reconnect")
+	} else {
+		span.Event("connect")
+	}
+
+	if err := c.executeStreamRequest(ctx, span, "GET", url, rawChan); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	banners := make(chan *HostData, options.Buffer)
+	go func() {
+		defer close(banners)
+		defer cancel()
+
+		// the stream is cancelled on a malformed banner that can't be reported and drained until
+		// it ends, so its reader isn't left blocked
+		for message := range rawChan {
+			if ctx.Err() != nil {
+				continue
+			}
+
+			banner := new(HostData)
+			if err := c.decodeBannerBytes(message, banner); err != nil {
+				if !reportMalformedBanner(ctx, options.Errors, stream, message, err) {
+					cancel()
+				}
+
+				continue
+			}
+
+			c.observeStreamMessage(stream)
+
+			select {
+			case banners <- banner:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return banners, nil
 }
 
 // GetBannersByPorts returns only banner data for the list of specified hosts.
 // This stream provides a filtered, bandwidth-saving view of the Banners stream
 // in case you are only interested in a specific list of ports.
-func (c *Client) GetBannersByPorts(ports []int) {
-	stringifiedPorts := make([]string, 0)
-	for _, port := range ports {
-		stringifiedPorts = append(stringifiedPorts, strconv.Itoa(port))
-	}
+// It's a part of Streamer.
+func (c *Client) GetBannersByPorts(ctx context.Context, ports []int) {
+	c.beginStreaming(ctx, portsStreamPath(ports))
+}
+
+// GetBannersByASN subscribes to banners discovered on the autonomous systems, i.e. "AS15169".
+// It's a part of Streamer.
+func (c *Client) GetBannersByASN(ctx context.Context, asns []string) {
+	c.beginStreaming(ctx, fmt.Sprintf(bannersASNPath, strings.Join(asns, ",")))
+}
 
-	path := fmt.Sprintf(bannersPortsPath, strings.Join(stringifiedPorts, ","))
-	c.beginStreaming(path)
+// GetBannersByCountries subscribes to banners discovered in the countries, i.e. "US" or "DE".
+// It's a part of Streamer.
+func (c *Client) GetBannersByCountries(ctx context.Context, countries []string) {
+	c.beginStreaming(ctx, fmt.Sprintf(bannersCountriesPath, strings.Join(countries, ",")))
 }
 
 // GetBannersByAlert subscribes to banners discovered on the IP range defined
 // in a specific network alert.
-func (c *Client) GetBannersByAlert(id string) {
+// It's a part of Streamer.
+func (c *Client) GetBannersByAlert(ctx context.Context, id string) {
 	path := fmt.Sprintf(bannersAlertPath, id)
-	c.beginStreaming(path)
+	c.beginStreaming(ctx, path)
 }
 
 // GetBannersByAlerts subscribes to banners discovered on all IP ranges described
 // in the network alerts.
-func (c *Client) GetBannersByAlerts() {
-	c.beginStreaming(bannersAlertsPath)
+// It's a part of Streamer.
+func (c *Client) GetBannersByAlerts(ctx context.Context) {
+	c.beginStreaming(ctx, bannersAlertsPath)
 }
 
 // GetBanners provides ALL of the data that Shodan collects. Use this stream
 // if you need access to everything and / or want to store your own Shodan database
 // locally. If you only care about specific ports, please use the Ports stream.
-func (c *Client) GetBanners() {
-	c.beginStreaming(bannersPath)
+// It's a part of Streamer.
+func (c *Client) GetBanners(ctx context.Context) {
+	c.beginStreaming(ctx, bannersPath)
 }
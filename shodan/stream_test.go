@@ -0,0 +1,83 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func handleMalformedStream(path string) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "198.51.100.1", "port": 80}`)
+		fmt.Fprintln(w, `{"ip_str": "198.51.100.2", "port": "eighty"}`)
+		fmt.Fprintln(w, `{"ip_str": "198.51.100.3", "port": 443}`)
+	})
+}
+
+func TestClient_GetBannersByCountries_streamErrors(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handleMalformedStream("/shodan/countries/US,DE")
+
+	errs := make(chan error, 1)
+	WithStreamErrors(errs)(client)
+	client.GetBannersByCountries(context.Background(), []string{"US", "DE"})
+
+	var received []string
+	for banner := range client.StreamChan {
+		received = append(received, banner.IP)
+	}
+
+	assert.Equal(t, []string{"198.51.100.1", "198.51.100.3"}, received)
+
+	err := <-errs
+	assert.True(t, errors.Is(err, ErrMalformedBanner))
+
+	var bannerErr *MalformedBannerError
+	assert.True(t, errors.As(err, &bannerErr))
+	assert.Equal(t, "/shodan/countries/{countries}", bannerErr.Stream)
+	assert.Equal(t, `{"ip_str": "198.51.100.2", "port": "eighty"}`+"\n", string(bannerErr.Message))
+}
+
+func TestClient_GetBanners_malformed(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handleMalformedStream(bannersPath)
+
+	client.GetBanners(context.Background())
+
+	var received []string
+	for banner := range client.StreamChan {
+		received = append(received, banner.IP)
+	}
+
+	assert.Equal(t, []string{"198.51.100.1"}, received)
+}
+
+func TestClient_SubscribeBannersByCountries_streamErrors(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handleMalformedStream("/shodan/countries/NL")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error)
+	options := &StreamOptions{Errors: errs, MinBackoff: fastReconnect.MinBackoff, MaxBackoff: fastReconnect.MaxBackoff}
+	subscription, err := client.SubscribeBannersByCountries(ctx, []string{"NL"}, options)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "198.51.100.1", (<-subscription.Banners).IP)
+	assert.True(t, errors.Is(<-errs, ErrMalformedBanner))
+	assert.Equal(t, "198.51.100.3", (<-subscription.Banners).IP)
+
+	subscription.Close()
+	assert.Nil(t, subscription.Err())
+}
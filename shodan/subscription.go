@@ -0,0 +1,186 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultStreamMinBackoff is the wait before the first reconnect of a subscription.
+	DefaultStreamMinBackoff = time.Second
+
+	// DefaultStreamMaxBackoff is the longest wait between the reconnects of a subscription.
+	DefaultStreamMaxBackoff = time.Minute
+)
+
+// Subscription is a stream of banners that reconnects whenever the connection ends, created by the
+// Subscribe methods. Banners is closed once the context is done, Close is called or the stream fails
+// permanently.
+type Subscription struct {
+	// Banners delivers the banners of the stream.
+	Banners <-chan *HostData
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Err returns the permanent failure that ended the subscription, i.e. an APIError with the 401 status
+// code once the key has been revoked. It's nil while Banners is open and when the subscription has been
+// stopped by its context or by Close.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the subscription and waits until Banners is closed. It's safe to call more than once.
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// SubscribeBanners subscribes to all the banners Shodan collects, see GetBanners.
+func (c *Client) SubscribeBanners(ctx context.Context, options *StreamOptions) (*Subscription, error) {
+	return c.subscribe(ctx, bannersPath, options)
+}
+
+// SubscribeBannersByPorts subscribes to the banners of the ports, see GetBannersByPorts.
+func (c *Client) SubscribeBannersByPorts(ctx context.Context, ports []int, options *StreamOptions) (*Subscription, error) {
+	return c.subscribe(ctx, portsStreamPath(ports), options)
+}
+
+// SubscribeBannersByASN subscribes to the banners of the autonomous systems, i.e. "AS15169", see
+// GetBannersByASN.
+func (c *Client) SubscribeBannersByASN(ctx context.Context, asns []string, options *StreamOptions) (*Subscription, error) {
+	return c.subscribe(ctx, fmt.Sprintf(bannersASNPath, strings.Join(asns, ",")), options)
+}
+
+// SubscribeBannersByCountries subscribes to the banners of the countries, see GetBannersByCountries.
+func (c *Client) SubscribeBannersByCountries(ctx context.Context, countries []string, options *StreamOptions) (*Subscription, error) {
+	return c.subscribe(ctx, fmt.Sprintf(bannersCountriesPath, strings.Join(countries, ",")), options)
+}
+
+// SubscribeBannersByAlert subscribes to the banners of the network alert, see GetBannersByAlert. The
+// subscription ends with a 404 APIError once the alert is deleted.
+func (c *Client) SubscribeBannersByAlert(ctx context.Context, id string, options *StreamOptions) (*Subscription, error) {
+	return c.subscribe(ctx, fmt.Sprintf(bannersAlertPath, id), options)
+}
+
+// SubscribeBannersByAlerts subscribes to the banners of all the network alerts, see GetBannersByAlerts.
+func (c *Client) SubscribeBannersByAlerts(ctx context.Context, options *StreamOptions) (*Subscription, error) {
+	return c.subscribe(ctx, bannersAlertsPath, options)
+}
+
+// subscribe opens the stream and keeps reconnecting it with an exponential backoff. The first connection
+// has to succeed, the error is returned otherwise. Later the errors Shodan won't recover from end the
+// subscription, the others are retried.
+func (c *Client) subscribe(ctx context.Context, path string, options *StreamOptions) (*Subscription, error) {
+	if options == nil {
+		options = new(StreamOptions)
+	}
+
+	minBackoff, maxBackoff := options.MinBackoff, options.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultStreamMinBackoff
+	}
+
+	if maxBackoff < minBackoff {
+		maxBackoff = DefaultStreamMaxBackoff
+		if maxBackoff < minBackoff {
+			maxBackoff = minBackoff
+		}
+	}
+
+	streamOptions := &StreamOptions{Errors: options.Errors}
+	ctx, cancel := context.WithCancel(ctx)
+
+	// A single span covers all the connections of the subscription.
+	ctx, span := c.startStreamSpan(ctx, path)
+	streamSpan := subscriptionSpan{StreamSpan: span}
+
+	stream, err := c.openStream(ctx, streamSpan, path, streamOptions, false)
+	if err != nil {
+		cancel()
+		span.End(err)
+		return nil, err
+	}
+
+	banners := make(chan *HostData, options.Buffer)
+	s := &Subscription{Banners: banners, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+		defer close(banners)
+		defer cancel()
+		defer func() { span.End(s.err) }()
+
+		backoff := minBackoff
+		for {
+			for banner := range stream {
+				backoff = minBackoff
+
+				select {
+				case banners <- banner:
+				case <-ctx.Done():
+				}
+			}
+
+			for {
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return
+				}
+
+				if backoff *= 2; backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+
+				if stream, err = c.openStream(ctx, streamSpan, path, streamOptions, true); err == nil {
+					break
+				}
+
+				if ctx.Err() != nil {
+					return
+				}
+
+				if permanentStreamError(err) {
+					s.err = err
+					return
+				}
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// permanentStreamError reports whether reconnecting won't help, that's the case for the client errors
+// except for the rate limit.
+func permanentStreamError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError &&
+		apiErr.StatusCode != http.StatusTooManyRequests
+}
+
+func portsStreamPath(ports []int) string {
+	stringifiedPorts := make([]string, 0, len(ports))
+	for _, port := range ports {
+		stringifiedPorts = append(stringifiedPorts, strconv.Itoa(port))
+	}
+
+	return fmt.Sprintf(bannersPortsPath, strings.Join(stringifiedPorts, ","))
+}
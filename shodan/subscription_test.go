@@ -0,0 +1,182 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fastReconnect = &StreamOptions{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+// handleDroppingStream sends two banners on every connection and drops it, the connections after
+// the given number are answered with the status.
+func handleDroppingStream(path string, connections int64, status int) *int64 {
+	connected := new(int64)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(connected, 1)
+		if n > connections {
+			http.Error(w, `{"error": "Rejected"}`, status)
+			return
+		}
+
+		fmt.Fprintf(w, "{\"ip_str\": \"198.51.100.%d\", \"port\": 80}\n", n)
+		fmt.Fprintf(w, "{\"ip_str\": \"198.51.100.%d\", \"port\": 443}", n)
+	})
+
+	return connected
+}
+
+func TestClient_SubscribeBanners_reconnect(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	connected := handleDroppingStream(bannersPath, 3, http.StatusUnauthorized)
+
+	subscription, err := client.SubscribeBanners(context.Background(), fastReconnect)
+	assert.Nil(t, err)
+
+	received := make([]string, 0)
+	for banner := range subscription.Banners {
+		received = append(received, fmt.Sprintf("%s:%d", banner.IP, banner.Port))
+	}
+
+	assert.Equal(t, []string{
+		"198.51.100.1:80", "198.51.100.1:443",
+		"198.51.100.2:80", "198.51.100.2:443",
+		"198.51.100.3:80", "198.51.100.3:443",
+	}, received)
+	assert.Equal(t, int64(4), atomic.LoadInt64(connected))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(subscription.Err(), &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClient_SubscribeBannersByPorts_transientErrors(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	connected := handleDroppingStream("/shodan/ports/22,80", 1, http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscription, err := client.SubscribeBannersByPorts(ctx, []int{22, 80}, fastReconnect)
+	assert.Nil(t, err)
+
+	<-subscription.Banners
+	<-subscription.Banners
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(connected) >= 4
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+
+	_, open := <-subscription.Banners
+	assert.False(t, open)
+	assert.Nil(t, subscription.Err())
+}
+
+func TestClient_SubscribeBannersByASN_failure(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handleDroppingStream("/shodan/asn/AS15169,AS13335", 0, http.StatusForbidden)
+
+	_, err := client.SubscribeBannersByASN(context.Background(), []string{"AS15169", "AS13335"}, nil)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "/shodan/asn/{asn}", apiErr.Endpoint)
+}
+
+func TestSubscription_Close(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	handleDroppingStream(bannersPath, 100, http.StatusUnauthorized)
+
+	subscription, err := client.SubscribeBanners(context.Background(), fastReconnect)
+	assert.Nil(t, err)
+
+	subscription.Close()
+	subscription.Close()
+
+	for range subscription.Banners {
+	}
+
+	assert.Nil(t, subscription.Err())
+}
+
+func TestClient_SubscribeBanners_cancel(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	cancelled := handleEndlessStream(bannersPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscription, err := client.SubscribeBanners(ctx, fastReconnect)
+	assert.Nil(t, err)
+
+	<-subscription.Banners
+	cancel()
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection hasn't been closed")
+	}
+
+	for range subscription.Banners {
+	}
+
+	assert.Nil(t, subscription.Err())
+}
+
+func TestClient_SubscribeBannersByAlert_deleted(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	connected := handleDroppingStream("/shodan/alert/OYPRB8IR9Z35AZPR", 1, http.StatusNotFound)
+
+	subscription, err := client.SubscribeBannersByAlert(context.Background(), "OYPRB8IR9Z35AZPR", fastReconnect)
+	assert.Nil(t, err)
+
+	received := 0
+	for range subscription.Banners {
+		received++
+	}
+
+	assert.Equal(t, 2, received)
+	assert.Equal(t, int64(2), atomic.LoadInt64(connected))
+	assert.True(t, errors.Is(subscription.Err(), ErrNotFound))
+}
+
+func TestClient_SubscribeBannersByAlerts(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	connected := handleDroppingStream(bannersAlertsPath, 2, http.StatusUnauthorized)
+
+	subscription, err := client.SubscribeBannersByAlerts(context.Background(), fastReconnect)
+	assert.Nil(t, err)
+
+	received := 0
+	for range subscription.Banners {
+		received++
+	}
+
+	assert.Equal(t, 4, received)
+	assert.Equal(t, int64(3), atomic.LoadInt64(connected))
+	assert.True(t, errors.Is(subscription.Err(), ErrUnauthorized))
+}
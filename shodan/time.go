@@ -0,0 +1,70 @@
+package shodan
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// timeLayout is the layout Shodan uses for most of the timestamps.
+const timeLayout = "2006-01-02T15:04:05.000000"
+
+// timeLayouts are the timestamp layouts Shodan is known to emit, the most common one goes first.
+var timeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"20060102150405Z0700",
+}
+
+// Time is a timestamp returned by Shodan. Shodan doesn't stick to RFC3339, so
+// the standard time.Time decoding can't be used directly. Timestamps without
+// a zone are treated as UTC.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Shodan timestamp. Null and empty string result in the
+// zero time.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	return t.parse(s)
+}
+
+// parse parses the unquoted timestamp trying all the known layouts.
+func (t *Time) parse(s string) error {
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	var err error
+	for _, layout := range timeLayouts {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+
+	return err
+}
+
+// MarshalJSON encodes the timestamp in UTC using the layout Shodan uses for banners.
+// The zero time is encoded as null.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(t.UTC().Format(timeLayout))
+}
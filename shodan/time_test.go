@@ -0,0 +1,71 @@
+package shodan
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTime_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{`"2017-09-09T14:03:08.722893"`, time.Date(2017, 9, 9, 14, 3, 8, 722893000, time.UTC)},
+		{`"2010-03-07T15:47:13"`, time.Date(2010, 3, 7, 15, 47, 13, 0, time.UTC)},
+		{`"2015-10-18T06:34:47.621Z"`, time.Date(2015, 10, 18, 6, 34, 47, 621000000, time.UTC)},
+		{`"2015-10-18T09:34:47+03:00"`, time.Date(2015, 10, 18, 6, 34, 47, 0, time.UTC)},
+		{`"2014-01-15 05:49:56.283713"`, time.Date(2014, 1, 15, 5, 49, 56, 283713000, time.UTC)},
+		{`"20240401000000Z"`, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{`""`, time.Time{}},
+		{`null`, time.Time{}},
+	}
+
+	for _, testCase := range testCases {
+		decoded := Time{time.Now()}
+		err := json.Unmarshal([]byte(testCase.value), &decoded)
+
+		assert.Nil(t, err, testCase.value)
+		assert.True(t, testCase.expected.Equal(decoded.Time), testCase.value)
+	}
+}
+
+func TestTime_UnmarshalJSON_invalid(t *testing.T) {
+	for _, value := range []string{`"yesterday"`, `"2015-13-45T00:00:00"`, `1445150087`} {
+		var decoded Time
+		assert.NotNil(t, json.Unmarshal([]byte(value), &decoded), value)
+	}
+}
+
+func TestTime_MarshalJSON(t *testing.T) {
+	testCases := []struct {
+		value    Time
+		expected string
+	}{
+		{Time{}, `null`},
+		{Time{time.Date(2017, 9, 9, 14, 3, 8, 722893000, time.UTC)}, `"2017-09-09T14:03:08.722893"`},
+		{Time{time.Date(2015, 10, 18, 6, 34, 47, 0, time.UTC)}, `"2015-10-18T06:34:47.000000"`},
+		{Time{time.Date(2015, 10, 18, 9, 34, 47, 0, time.FixedZone("MSK", 3*3600))}, `"2015-10-18T06:34:47.000000"`},
+	}
+
+	for _, testCase := range testCases {
+		b, err := json.Marshal(testCase.value)
+
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expected, string(b))
+	}
+}
+
+func TestTime_roundTrip(t *testing.T) {
+	for _, value := range []string{`"2017-09-09T14:03:08.722893"`, `"2015-10-18T06:34:47.621Z"`, `"20240401000000Z"`, `null`} {
+		var decoded, redecoded Time
+		assert.Nil(t, json.Unmarshal([]byte(value), &decoded))
+
+		b, err := json.Marshal(decoded)
+		assert.Nil(t, err)
+		assert.Nil(t, json.Unmarshal(b, &redecoded))
+		assert.True(t, decoded.Equal(redecoded.Time), value)
+	}
+}
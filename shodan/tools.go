@@ -2,6 +2,8 @@ package shodan
 
 import (
 	"bytes"
+	"context"
+	"net"
 	"strings"
 )
 
@@ -12,22 +14,31 @@ const (
 
 // GetMyIP returns your current IP address as seen from the Internet
 // API key for this method is unnecessary
-func (c *Client) GetMyIP() (string, error) {
+func (c *Client) GetMyIP(ctx context.Context) (net.IP, error) {
 	url := c.buildBaseURL(ipPath, nil)
 
-	var ip bytes.Buffer
-	err := c.executeRequest("GET", url, &ip, nil)
+	var body bytes.Buffer
+	if err := c.executeRequest(ctx, "GET", url, &body, nil); err != nil {
+		return nil, err
+	}
 
-	return strings.Trim(ip.String(), "\""), err
+	text := strings.Trim(strings.TrimSpace(body.String()), "\"")
+
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: text}
+	}
+
+	return ip, nil
 }
 
 // GetHTTPHeaders shows the HTTP headers that your client sends
 // when connecting to a webserver.
-func (c *Client) GetHTTPHeaders() (map[string]string, error) {
+func (c *Client) GetHTTPHeaders(ctx context.Context) (map[string]string, error) {
 	url := c.buildBaseURL(headersPath, nil)
 
 	var headers map[string]string
-	err := c.executeRequest("GET", url, &headers, nil)
+	err := c.executeRequest(ctx, "GET", url, &headers, nil)
 
 	return headers, err
 }
@@ -1,7 +1,10 @@
 package shodan
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"testing"
@@ -20,10 +23,26 @@ func TestClient_GetMyIP(t *testing.T) {
 		fmt.Fprint(w, strconv.Quote(testIP))
 	})
 
-	ip, err := client.GetMyIP()
+	ip, err := client.GetMyIP(context.Background())
 
 	assert.Nil(t, err)
-	assert.Equal(t, testIP, ip)
+	assert.Equal(t, testIP, ip.String())
+}
+
+func TestClient_GetMyIP_invalid(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(ipPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `"not an ip"`)
+	})
+
+	ip, err := client.GetMyIP(context.Background())
+
+	var parseErr *net.ParseError
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, "not an ip", parseErr.Text)
+	assert.Nil(t, ip)
 }
 
 func TestClient_GetHTTPHeaders(t *testing.T) {
@@ -40,7 +59,7 @@ func TestClient_GetHTTPHeaders(t *testing.T) {
 		"Host":            "api.shodan.io",
 		"Accept-Encoding": "gzip",
 	}
-	headers, err := client.GetHTTPHeaders()
+	headers, err := client.GetHTTPHeaders(context.Background())
 
 	assert.Nil(t, err)
 	assert.Len(t, headers, len(headersExpected))
@@ -0,0 +1,124 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// RequestSpan describes a REST call about to be sent.
+type RequestSpan struct {
+	// Operation is the logical name of the call, i.e. "shodan.host.search".
+	Operation string
+	Method    string
+	// Endpoint is the endpoint template, i.e. "/shodan/host/{ip}".
+	Endpoint string
+	// Credits is the estimated amount of credits the call consumes.
+	Credits int
+}
+
+// RequestResult describes how a REST call ended.
+type RequestResult struct {
+	// StatusCode is 0 when no response has been received.
+	StatusCode int
+	Retries    int
+	Err        error
+}
+
+// StreamSpan is a long-lived span covering a stream subscription.
+type StreamSpan interface {
+	// Event records something happening on the stream, i.e. "connect" or "reconnect".
+	Event(name string)
+	// End is called once the stream is over, err is nil when it ended normally.
+	End(err error)
+}
+
+// Tracer wraps the calls of the client in spans, see the shodanotel package for an OpenTelemetry
+// implementation. The context returned by the methods is used to send the request, so the trace
+// context is propagated to the transport. The methods are called concurrently.
+type Tracer interface {
+	TraceRequest(ctx context.Context, span RequestSpan) (context.Context, func(RequestResult))
+	TraceStream(ctx context.Context, operation string) (context.Context, StreamSpan)
+}
+
+// WithTracer makes the client trace the requests and the streams with t.
+func WithTracer(t Tracer) ClientOption {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+type creditsKey struct{}
+
+// withCredits attaches the estimated credits of the call to the context, so they end up in its span.
+func withCredits(ctx context.Context, credits int) context.Context {
+	return context.WithValue(ctx, creditsKey{}, credits)
+}
+
+func creditsFromContext(ctx context.Context) int {
+	credits, _ := ctx.Value(creditsKey{}).(int)
+	return credits
+}
+
+func (c *Client) startRequestSpan(ctx context.Context, method, rawURL string) (context.Context, func(error, int)) {
+	if c.tracer == nil {
+		return ctx, func(error, int) {}
+	}
+
+	path := c.endpointPath(rawURL)
+	ctx, finish := c.tracer.TraceRequest(ctx, RequestSpan{
+		Operation: endpointOperation(method, path),
+		Method:    method,
+		Endpoint:  endpointTemplate(path),
+		Credits:   creditsFromContext(ctx),
+	})
+
+	return ctx, func(err error, retries int) {
+		result := RequestResult{Err: err, StatusCode: errorStatus(err), Retries: retries}
+		if err == nil {
+			result.StatusCode = 200
+		}
+
+		finish(result)
+	}
+}
+
+// errorStatus returns the status code of the response the error was created from, 0 if there's none.
+func errorStatus(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+
+	return 0
+}
+
+// subscriptionSpan is the span of a subscription handed to its streams, it's only ended once the
+// subscription is over rather than with every stream.
+type subscriptionSpan struct {
+	StreamSpan
+}
+
+func (subscriptionSpan) End(error) {}
+
+type noopStreamSpan struct{}
+
+func (noopStreamSpan) Event(string) {}
+func (noopStreamSpan) End(error)    {}
+
+func (c *Client) startStreamSpan(ctx context.Context, path string) (context.Context, StreamSpan) {
+	if c.tracer == nil {
+		return ctx, noopStreamSpan{}
+	}
+
+	return c.tracer.TraceStream(ctx, endpointOperation("GET", path))
+}
+
+// streamEnd turns the error the stream ended with into the one its span is ended with.
+func streamEnd(err error) error {
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,189 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedSpan struct {
+	span   RequestSpan
+	result RequestResult
+	events []string
+	err    error
+	ended  bool
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type tracerKey struct{}
+
+func (t *recordingTracer) TraceRequest(ctx context.Context, span RequestSpan) (context.Context, func(RequestResult)) {
+	recorded := t.record(span)
+
+	return context.WithValue(ctx, tracerKey{}, span.Operation), func(result RequestResult) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		recorded.result = result
+		recorded.ended = true
+	}
+}
+
+func (t *recordingTracer) TraceStream(ctx context.Context, operation string) (context.Context, StreamSpan) {
+	return ctx, &recordingStreamSpan{tracer: t, span: t.record(RequestSpan{Operation: operation})}
+}
+
+func (t *recordingTracer) record(span RequestSpan) *recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	recorded := &recordedSpan{span: span}
+	t.spans = append(t.spans, recorded)
+
+	return recorded
+}
+
+type recordingStreamSpan struct {
+	tracer *recordingTracer
+	span   *recordedSpan
+}
+
+func (s *recordingStreamSpan) Event(name string) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.events = append(s.span.events, name)
+}
+
+func (s *recordingStreamSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.err = err
+	s.span.ended = true
+}
+
+func TestClient_WithTracer(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	tracer := new(recordingTracer)
+	client = NewClient(nil, testClientToken, WithTracer(tracer))
+	client.BaseURL = server.URL
+
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "host/search"))
+	})
+	mux.HandleFunc(hostPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+	})
+	mux.HandleFunc(scanPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "scan"))
+	})
+
+	_, err := client.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "port:22"})
+	assert.Nil(t, err)
+	_, err = client.GetServicesForHost(context.Background(), "1.1.1.1", nil)
+	assert.NotNil(t, err)
+	_, err = client.Scan(context.Background(), []string{"1.1.1.1", "8.8.8.0/30"})
+	assert.Nil(t, err)
+
+	assert.Len(t, tracer.spans, 3)
+
+	search := tracer.spans[0]
+	assert.Equal(t, RequestSpan{Operation: "shodan.host.search", Method: "GET", Endpoint: hostSearchPath, Credits: 1}, search.span)
+	assert.Equal(t, RequestResult{StatusCode: 200}, search.result)
+
+	host := tracer.spans[1]
+	assert.Equal(t, "shodan.host.get", host.span.Operation)
+	assert.Equal(t, hostPath+"/{ip}", host.span.Endpoint)
+	assert.Equal(t, 404, host.result.StatusCode)
+	assert.NotNil(t, host.result.Err)
+
+	scan := tracer.spans[2]
+	assert.Equal(t, RequestSpan{Operation: "shodan.scan", Method: "POST", Endpoint: scanPath, Credits: 5}, scan.span)
+	assert.True(t, scan.ended)
+}
+
+func TestClient_WithTracer_propagatesContext(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	var operation interface{}
+	client = NewClient(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		operation = r.Context().Value(tracerKey{})
+		return http.DefaultTransport.RoundTrip(r)
+	})}, testClientToken, WithTracer(new(recordingTracer)))
+	client.BaseURL = server.URL
+
+	mux.HandleFunc(infoPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(getStub(t, "info"))
+	})
+
+	_, err := client.GetAPIInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "shodan.api_info", operation)
+}
+
+func TestClient_WithTracer_stream(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	tracer := new(recordingTracer)
+	client = NewClient(nil, testClientToken, WithTracer(tracer))
+	client.StreamBaseURL = server.URL
+
+	mux.HandleFunc(fmt.Sprintf(bannersPortsPath, "22"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 22}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		client.StreamChan = make(chan HostData)
+		client.GetBannersByPorts(context.Background(), []int{22})
+		for range client.StreamChan {
+		}
+	}
+
+	assert.Len(t, tracer.spans, 2)
+	for _, span := range tracer.spans {
+		assert.Equal(t, "shodan.stream.ports", span.span.Operation)
+		assert.Equal(t, []string{"connect"}, span.events)
+		assert.True(t, span.ended)
+		assert.Nil(t, span.err)
+	}
+}
+
+func TestClient_WithTracer_subscription(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	tracer := new(recordingTracer)
+	client = NewClient(nil, testClientToken, WithTracer(tracer))
+	client.StreamBaseURL = server.URL
+
+	handleDroppingStream(bannersPath, 3, http.StatusUnauthorized)
+
+	subscription, err := client.SubscribeBanners(context.Background(), fastReconnect)
+	assert.Nil(t, err)
+
+	for range subscription.Banners {
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	assert.Len(t, tracer.spans, 1)
+	assert.Equal(t, "shodan.stream.banners", tracer.spans[0].span.Operation)
+	assert.Equal(t, []string{"connect", "reconnect", "reconnect", "reconnect"}, tracer.spans[0].events)
+	assert.True(t, tracer.spans[0].ended)
+	assert.True(t, errors.Is(tracer.spans[0].err, ErrUnauthorized))
+}
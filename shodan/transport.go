@@ -0,0 +1,230 @@
+package shodan
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnsupportedTransport is returned when the stream host can't be pinned because
+// the HTTP client uses a custom RoundTripper.
+var ErrUnsupportedTransport = errors.New("shodan: stream host pinning requires *http.Transport")
+
+// ErrTransportSelectorPanic is returned when the transport selector panics while choosing a transport.
+var ErrTransportSelectorPanic = errors.New("shodan: transport selector panicked")
+
+// WithTransportSelector makes the client ask selector for the transport of every request, i.e. to route
+// the scans and the searches through different proxies. When it returns nil the request is sent by the
+// HTTP client as usual. A stream is sent, and then read until it's over, with the transport chosen when
+// it connects. A panic of the selector fails the request with ErrTransportSelectorPanic.
+func WithTransportSelector(selector func(req *http.Request) http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transportSelector = selector
+	}
+}
+
+// selectTransport returns the client sending req, it uses the transport chosen by the selector if any.
+func (c *Client) selectTransport(client *http.Client, req *http.Request) (selected *http.Client, err error) {
+	if c.transportSelector == nil {
+		return client, nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			selected, err = nil, fmt.Errorf("%w: %v", ErrTransportSelectorPanic, r)
+		}
+	}()
+
+	transport := c.transportSelector(req)
+	if transport == nil {
+		return client, nil
+	}
+
+	withTransport := *client
+	withTransport.Transport = transport
+
+	return &withTransport, nil
+}
+
+// StreamStats holds the connection statistics of the streaming requests.
+type StreamStats struct {
+	// Requests is the number of stream requests sent.
+	Requests int64
+	// NewConnections is the number of connections dialed for the streams.
+	NewConnections int64
+	// ReusedConnections is the number of streams served by an idle keep-alive connection.
+	ReusedConnections int64
+	// TLSHandshakes is the number of TLS handshakes performed.
+	TLSHandshakes int64
+	// DNSLookups is the number of lookups of the stream host.
+	DNSLookups int64
+}
+
+type streamCounters struct {
+	requests       int64
+	newConnections int64
+	reused         int64
+	handshakes     int64
+	lookups        int64
+}
+
+// StreamStats returns the connection statistics of the streams started by the client.
+func (c *Client) StreamStats() StreamStats {
+	return StreamStats{
+		Requests:          atomic.LoadInt64(&c.streamCounters.requests),
+		NewConnections:    atomic.LoadInt64(&c.streamCounters.newConnections),
+		ReusedConnections: atomic.LoadInt64(&c.streamCounters.reused),
+		TLSHandshakes:     atomic.LoadInt64(&c.streamCounters.handshakes),
+		DNSLookups:        atomic.LoadInt64(&c.streamCounters.lookups),
+	}
+}
+
+func (c *Client) traceStream(ctx context.Context) context.Context {
+	atomic.AddInt64(&c.streamCounters.requests, 1)
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			atomic.AddInt64(&c.streamCounters.lookups, 1)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddInt64(&c.streamCounters.reused, 1)
+			} else {
+				atomic.AddInt64(&c.streamCounters.newConnections, 1)
+			}
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			atomic.AddInt64(&c.streamCounters.handshakes, 1)
+		},
+	})
+}
+
+// streamHTTPClient returns the client used for streaming. It's the regular one unless
+// the stream host is pinned.
+func (c *Client) streamHTTPClient() *http.Client {
+	if c.streamClient != nil {
+		return c.streamClient
+	}
+
+	return c.Client
+}
+
+// PinStreamHost makes the streams resolve the stream host once and keep dialing the resolved
+// addresses across reconnects. The addresses are resolved again after refresh passes or when
+// none of them can be dialed. The streams get a dedicated transport derived from the one of
+// the HTTP client, so changes to the client made afterwards don't affect the streams.
+// A refresh of 0 disables the pinning.
+func (c *Client) PinStreamHost(refresh time.Duration) error {
+	if refresh <= 0 {
+		c.streamClient = nil
+		c.streamDialer = nil
+		return nil
+	}
+
+	var transport *http.Transport
+	switch t := c.Client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return ErrUnsupportedTransport
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+
+	dialer := &pinnedDialer{
+		dial:    dial,
+		lookup:  net.DefaultResolver.LookupIPAddr,
+		refresh: refresh,
+		now:     time.Now,
+		hosts:   make(map[string]*pinnedHost),
+	}
+
+	transport.DialContext = dialer.DialContext
+	transport.DisableKeepAlives = false
+
+	client := *c.Client
+	client.Transport = transport
+	c.streamClient = &client
+	c.streamDialer = dialer
+
+	return nil
+}
+
+type pinnedHost struct {
+	addrs    []net.IPAddr
+	resolved time.Time
+}
+
+// pinnedDialer dials the cached addresses of a host instead of resolving it on every dial.
+type pinnedDialer struct {
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+	lookup  func(ctx context.Context, host string) ([]net.IPAddr, error)
+	refresh time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*pinnedHost
+}
+
+func (d *pinnedDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dial(ctx, network, addr)
+	}
+
+	addrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ip := range addrs {
+		var conn net.Conn
+		if conn, err = d.dial(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+
+	d.mu.Lock()
+	delete(d.hosts, host)
+	d.mu.Unlock()
+
+	return nil, err
+}
+
+func (d *pinnedDialer) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	d.mu.Lock()
+	pinned, ok := d.hosts[host]
+	d.mu.Unlock()
+
+	if ok && d.now().Sub(pinned.resolved) < d.refresh {
+		return pinned.addrs, nil
+	}
+
+	// The lookup is counted in StreamStats by the DNSStart of the stream trace carried by ctx.
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	d.mu.Lock()
+	d.hosts[host] = &pinnedHost{addrs: addrs, resolved: d.now()}
+	d.mu.Unlock()
+
+	return addrs, nil
+}
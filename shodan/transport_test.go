@@ -0,0 +1,288 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStreamTLSServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 443}`)
+		fmt.Fprintln(w, `{"ip_str": "8.8.8.8", "port": 53}`)
+	}))
+}
+
+func drainStream(t *testing.T, c *Client, path string) int {
+	ch := make(chan []byte)
+	err := c.executeStreamRequest(context.Background(), noopStreamSpan{}, "GET", c.buildStreamBaseURL(path, nil), ch)
+	assert.Nil(t, err)
+
+	count := 0
+	for range ch {
+		count++
+	}
+
+	return count
+}
+
+// tracedLookup fakes a lookup of the host, reporting it to the trace of ctx like the resolver does.
+func tracedLookup(ctx context.Context, host string, addrs []net.IPAddr) []net.IPAddr {
+	if trace := httptrace.ContextClientTrace(ctx); trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+
+	return addrs
+}
+
+func TestClient_StreamStats_reusesConnection(t *testing.T) {
+	server := newStreamTLSServer()
+	defer server.Close()
+
+	c := NewClient(server.Client(), "token")
+	c.StreamBaseURL = server.URL
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 2, drainStream(t, c, bannersPath))
+	}
+
+	stats := c.StreamStats()
+	assert.Equal(t, int64(3), stats.Requests)
+	assert.Equal(t, int64(1), stats.NewConnections)
+	assert.Equal(t, int64(2), stats.ReusedConnections)
+	assert.Equal(t, int64(1), stats.TLSHandshakes)
+}
+
+func TestClient_PinStreamHost(t *testing.T) {
+	server := newStreamTLSServer()
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+
+	c := NewClient(server.Client(), "token")
+	c.StreamBaseURL = "https://example.com:" + port
+	assert.Nil(t, c.PinStreamHost(time.Minute))
+
+	now := time.Now()
+	var lookups int64
+	c.streamDialer.now = func() time.Time { return now }
+	c.streamDialer.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		atomic.AddInt64(&lookups, 1)
+		assert.Equal(t, "example.com", host)
+		return tracedLookup(ctx, host, []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 2, drainStream(t, c, bannersPath))
+		server.CloseClientConnections()
+	}
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&lookups))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 2, drainStream(t, c, bannersPath))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&lookups))
+
+	stats := c.StreamStats()
+	assert.Equal(t, int64(4), stats.Requests)
+	assert.Equal(t, int64(2), stats.DNSLookups)
+	assert.Equal(t, int64(4), stats.TLSHandshakes)
+}
+
+func TestClient_PinStreamHost_resolver(t *testing.T) {
+	server := newStreamTLSServer()
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+
+	// The certificate of the test server is issued for example.com, the stream host is localhost.
+	httpClient := server.Client()
+	httpClient.Transport.(*http.Transport).TLSClientConfig.ServerName = "example.com"
+
+	c := NewClient(httpClient, "token")
+	c.StreamBaseURL = "https://localhost:" + port
+	assert.Nil(t, c.PinStreamHost(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 2, drainStream(t, c, bannersPath))
+		server.CloseClientConnections()
+	}
+
+	stats := c.StreamStats()
+	assert.Equal(t, int64(3), stats.Requests)
+	assert.Equal(t, int64(1), stats.DNSLookups)
+}
+
+func TestClient_PinStreamHost_dialFailure(t *testing.T) {
+	server := newStreamTLSServer()
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+
+	c := NewClient(server.Client(), "token")
+	c.StreamBaseURL = "https://example.com:" + port
+	assert.Nil(t, c.PinStreamHost(time.Hour))
+
+	addrs := []net.IPAddr{{IP: net.ParseIP("127.0.0.2")}}
+	c.streamDialer.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr != serverURL.Host {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: fmt.Errorf("refused")}
+		}
+
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	c.streamDialer.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return tracedLookup(ctx, host, addrs), nil
+	}
+
+	ch := make(chan []byte)
+	assert.NotNil(t, c.executeStreamRequest(context.Background(), noopStreamSpan{}, "GET", c.buildStreamBaseURL(bannersPath, nil), ch))
+
+	addrs = []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}
+	assert.Equal(t, 2, drainStream(t, c, bannersPath))
+	assert.Equal(t, int64(2), c.StreamStats().DNSLookups)
+}
+
+func TestClient_PinStreamHost_customTransport(t *testing.T) {
+	c := NewClient(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, nil
+	})}, "token")
+
+	assert.Equal(t, ErrUnsupportedTransport, c.PinStreamHost(time.Minute))
+	assert.Nil(t, c.PinStreamHost(0))
+	assert.True(t, c.streamHTTPClient() == c.Client)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// recordingTransport records the paths of the requests it sends.
+type recordingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.paths = append(rt.paths, req.URL.Path)
+	rt.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (rt *recordingTransport) recorded() []string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	return append([]string{}, rt.paths...)
+}
+
+func TestClient_WithTransportSelector(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(scanPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "SCAN", "count": 1, "credits_left": 99}`)
+	})
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total": 1, "matches": [{"ip_str": "1.1.1.1"}]}`)
+	})
+	mux.HandleFunc(bannersPath, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ip_str": "1.1.1.1", "port": 80}`)
+	})
+
+	scans, searches := &recordingTransport{}, &recordingTransport{}
+	selected := int64(0)
+
+	c := NewClient(nil, testClientToken, WithTransportSelector(func(req *http.Request) http.RoundTripper {
+		atomic.AddInt64(&selected, 1)
+
+		switch req.URL.Path {
+		case scanPath:
+			return scans
+		case hostSearchPath:
+			return searches
+		}
+
+		return nil
+	}))
+	c.BaseURL = server.URL
+	c.StreamBaseURL = server.URL
+
+	_, err := c.Scan(context.Background(), []string{"1.1.1.1"})
+	assert.Nil(t, err)
+
+	_, err = c.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, drainStream(t, c, bannersPath))
+
+	assert.Equal(t, []string{scanPath}, scans.recorded())
+	assert.Equal(t, []string{hostSearchPath}, searches.recorded())
+	assert.Equal(t, int64(3), atomic.LoadInt64(&selected))
+}
+
+func TestClient_WithTransportSelector_stream(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(bannersPath, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "{\"ip_str\": \"1.1.1.%d\", \"port\": 80}\n", i)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	streams := &recordingTransport{}
+	selected := int64(0)
+
+	c := NewClient(nil, testClientToken, WithTransportSelector(func(req *http.Request) http.RoundTripper {
+		if atomic.AddInt64(&selected, 1) == 1 {
+			return streams
+		}
+
+		return nil
+	}))
+	c.StreamBaseURL = server.URL
+
+	assert.Equal(t, 3, drainStream(t, c, bannersPath))
+	assert.Equal(t, []string{bannersPath}, streams.recorded())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&selected))
+}
+
+func TestClient_WithTransportSelector_panic(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	requests := int64(0)
+	mux.HandleFunc(hostSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+	})
+
+	c := NewClient(nil, testClientToken, WithTransportSelector(func(req *http.Request) http.RoundTripper {
+		panic("no proxy left")
+	}))
+	c.BaseURL = server.URL
+
+	_, err := c.GetHostsForQuery(context.Background(), &HostQueryOptions{Query: "nginx"})
+
+	assert.ErrorIs(t, err, ErrTransportSelectorPanic)
+	assert.Contains(t, err.Error(), "no proxy left")
+	assert.Equal(t, int64(0), atomic.LoadInt64(&requests))
+}
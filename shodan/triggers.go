@@ -0,0 +1,191 @@
+package shodan
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const alertTriggersPath = "/shodan/alert/triggers"
+
+// DefaultTriggerRulesTTL is how long TriggerRules keeps the rules before fetching them again.
+const DefaultTriggerRulesTTL = time.Hour
+
+// AlertTrigger is a trigger that can be enabled on a network alert.
+type AlertTrigger struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Rule is the search filter behind the trigger, i.e. "tag:database".
+	Rule string `json:"rule"`
+}
+
+type triggerRulesCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	rules   map[string]string
+	fetched time.Time
+}
+
+// WithTriggerRulesTTL changes how long TriggerRules keeps the rules, 0 disables the cache.
+func WithTriggerRulesTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.triggerRules.ttl = ttl
+	}
+}
+
+// GetAlertTriggers returns the triggers that can be enabled on the network alerts.
+func (c *Client) GetAlertTriggers(ctx context.Context) ([]*AlertTrigger, error) {
+	url := c.buildBaseURL(alertTriggersPath, nil)
+
+	triggers := make([]*AlertTrigger, 0)
+	err := c.executeRequest(ctx, "GET", url, &triggers, nil)
+
+	return triggers, err
+}
+
+// TriggerRules returns the rules of the alert triggers keyed by the trigger name. They're
+// fetched once per DefaultTriggerRulesTTL unless changed with WithTriggerRulesTTL, the
+// returned map is owned by the caller.
+func (c *Client) TriggerRules(ctx context.Context) (map[string]string, error) {
+	cache := c.triggerRules
+
+	cache.mu.Lock()
+	if cache.rules != nil && time.Since(cache.fetched) < cache.ttl {
+		rules := copyRules(cache.rules)
+		cache.mu.Unlock()
+
+		return rules, nil
+	}
+	cache.mu.Unlock()
+
+	triggers, err := c.GetAlertTriggers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]string, len(triggers))
+	for _, trigger := range triggers {
+		rules[trigger.Name] = trigger.Rule
+	}
+
+	cache.mu.Lock()
+	cache.rules = rules
+	cache.fetched = time.Now()
+	cache.mu.Unlock()
+
+	return copyRules(rules), nil
+}
+
+func copyRules(rules map[string]string) map[string]string {
+	copied := make(map[string]string, len(rules))
+	for name, rule := range rules {
+		copied[name] = rule
+	}
+
+	return copied
+}
+
+// MatchesTriggerRule evaluates the rule of a trigger against the banner. The filters separated
+// by spaces all have to match, the comma-separated values of a filter match if any of them does.
+// Only the port, product, tag, vuln and has_vuln filters are supported, a RuleEvaluationError is
+// returned for anything else instead of guessing.
+func MatchesTriggerRule(banner *HostData, rule string) (bool, error) {
+	filters := splitRule(rule)
+	if len(filters) == 0 {
+		return false, &RuleEvaluationError{Rule: rule}
+	}
+
+	matched := true
+	for _, filter := range filters {
+		ok, supported := matchFilter(banner, filter)
+		if !supported {
+			return false, &RuleEvaluationError{Rule: rule, Filter: filter}
+		}
+
+		matched = matched && ok
+	}
+
+	return matched, nil
+}
+
+// splitRule splits the rule into its filters, spaces inside quotes don't split.
+func splitRule(rule string) []string {
+	var filters []string
+	var current strings.Builder
+
+	quoted := false
+	for _, r := range rule {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ' ' && !quoted:
+			if current.Len() > 0 {
+				filters = append(filters, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if current.Len() > 0 {
+		filters = append(filters, current.String())
+	}
+
+	return filters
+}
+
+// matchFilter matches a single filter, supported is false when it can't be evaluated locally.
+func matchFilter(banner *HostData, filter string) (matched, supported bool) {
+	name, value, ok := strings.Cut(filter, ":")
+	if !ok || value == "" || strings.ContainsAny(value, "*?") {
+		return false, false
+	}
+
+	values := strings.Split(strings.Trim(value, `"`), ",")
+
+	switch name {
+	case "port":
+		for _, v := range values {
+			port, err := strconv.Atoi(v)
+			if err != nil {
+				return false, false
+			}
+
+			if port == banner.Port {
+				matched = true
+			}
+		}
+	case "product":
+		for _, v := range values {
+			matched = matched || strings.EqualFold(v, banner.Product)
+		}
+	case "tag":
+		for _, v := range values {
+			for _, tag := range banner.Tags {
+				matched = matched || strings.EqualFold(v, tag)
+			}
+		}
+	case "vuln":
+		for _, v := range values {
+			for cve := range banner.Vulns {
+				matched = matched || strings.EqualFold(v, cve)
+			}
+		}
+	case "has_vuln":
+		if len(values) != 1 || (values[0] != "true" && values[0] != "false") {
+			return false, false
+		}
+
+		matched = (len(banner.Vulns) > 0) == (values[0] == "true")
+	default:
+		return false, false
+	}
+
+	return matched, true
+}
@@ -0,0 +1,103 @@
+package shodan_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ns3777k/go-shodan/shodan"
+	"github.com/ns3777k/go-shodan/shodan/shodantest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetAlertTriggers(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	triggers, err := server.Client().GetAlertTriggers(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, triggers, 6)
+	assert.Equal(t, &shodan.AlertTrigger{
+		Name:        "open_database",
+		Description: "Database service that does not require authentication",
+		Rule:        "tag:database",
+	}, triggers[3])
+}
+
+func TestClient_TriggerRules(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client()
+
+	rules, err := client.TriggerRules(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "tag:compromised,malware", rules["malware"])
+
+	rules["malware"] = "changed"
+
+	rules, err = client.TriggerRules(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "tag:compromised,malware", rules["malware"])
+	assert.Equal(t, 1, server.Requests("/shodan/alert/triggers"))
+}
+
+func TestClient_TriggerRules_noCache(t *testing.T) {
+	server := shodantest.NewServer()
+	defer server.Close()
+
+	client := server.Client(shodan.WithTriggerRulesTTL(0))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.TriggerRules(context.Background())
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, 2, server.Requests("/shodan/alert/triggers"))
+}
+
+func TestMatchesTriggerRule(t *testing.T) {
+	database := shodantest.NewBanner().Port(27017).Product("MongoDB").Tags("database").Build()
+	vulnerable := shodantest.NewBanner().Port(443).WithVuln("CVE-2021-44228", 9.8).Build()
+
+	tests := []struct {
+		banner   *shodan.HostData
+		rule     string
+		expected bool
+	}{
+		{database, "tag:database", true},
+		{database, "tag:compromised,malware", false},
+		{database, "port:9200,27017", true},
+		{database, "port:27017 tag:ics", false},
+		{database, `product:"mongodb" tag:database`, true},
+		{database, "has_vuln:true", false},
+		{database, "has_vuln:false", true},
+		{vulnerable, "has_vuln:true", true},
+		{vulnerable, "vuln:cve-2021-44228", true},
+		{vulnerable, "vuln:CVE-2014-0160", false},
+	}
+
+	for _, test := range tests {
+		matched, err := shodan.MatchesTriggerRule(test.banner, test.rule)
+
+		assert.Nil(t, err, test.rule)
+		assert.Equal(t, test.expected, matched, test.rule)
+	}
+}
+
+func TestMatchesTriggerRule_unsupported(t *testing.T) {
+	banner := shodantest.NewBanner().Build()
+
+	for _, rule := range []string{"*", "", "ssl.cert.expired:true", "-tag:database", "port:http", "nginx", "product:ngi*"} {
+		matched, err := shodan.MatchesTriggerRule(banner, rule)
+
+		assert.False(t, matched, rule)
+		assert.True(t, errors.Is(err, shodan.ErrCannotEvaluateLocally), rule)
+	}
+
+	var ruleErr *shodan.RuleEvaluationError
+	_, err := shodan.MatchesTriggerRule(banner, "port:80 ssl.cert.expired:true")
+	assert.True(t, errors.As(err, &ruleErr))
+	assert.Equal(t, "ssl.cert.expired:true", ruleErr.Filter)
+}
@@ -0,0 +1,61 @@
+package shodan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// NetworkWatch is a temporary network alert and the subscription to its stream, created by WatchNetwork.
+type NetworkWatch struct {
+	// Alert is the alert created for the networks.
+	Alert *Alert
+
+	// Banners delivers the banners of the networks, it's closed once the stream ends.
+	Banners <-chan *HostData
+
+	client *Client
+	cancel context.CancelFunc
+
+	once sync.Once
+	err  error
+}
+
+// WatchNetwork creates a network alert for the networks and subscribes to its stream. The alert is
+// deleted if the subscription fails, otherwise Close has to be called to delete it once the banners
+// are not needed anymore, even if the stream has ended already.
+func (c *Client) WatchNetwork(ctx context.Context, name string, nets []*net.IPNet, options *StreamOptions) (*NetworkWatch, error) {
+	alert, err := c.CreateAlertForNetworks(ctx, name, nets, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf(bannersAlertPath, alert.ID)
+	streamCtx, cancel := context.WithCancel(ctx)
+	streamCtx, span := c.startStreamSpan(streamCtx, path)
+	banners, err := c.openStream(streamCtx, span, path, options, false)
+	if err != nil {
+		cancel()
+
+		if deleteErr := c.deleteAlert(context.Background(), alert.ID); deleteErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("deleting alert %s: %w", alert.ID, deleteErr))
+		}
+
+		return nil, err
+	}
+
+	return &NetworkWatch{Alert: alert, Banners: banners, client: c, cancel: cancel}, nil
+}
+
+// Close stops the stream and deletes the alert. It's safe to call more than once, the error of
+// the deletion is returned every time.
+func (w *NetworkWatch) Close() error {
+	w.once.Do(func() {
+		w.cancel()
+		w.err = w.client.deleteAlert(context.Background(), w.Alert.ID)
+	})
+
+	return w.err
+}
@@ -0,0 +1,122 @@
+package shodan
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// handleWatchedAlert serves the creation, the stream and the deletion of the alert "WATCHED". The
+// stream sends the banners and is kept open if hold is set. The deletions are counted.
+func handleWatchedAlert(t *testing.T, streamStatus int, hold bool) *int64 {
+	deleted := new(int64)
+
+	mux.HandleFunc(alertCreatePath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		fmt.Fprint(w, `{"id": "WATCHED", "name": "watch", "filters": {"ip": ["198.51.100.0/24"]}}`)
+	})
+
+	mux.HandleFunc(alertCreatePath+"/WATCHED", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			atomic.AddInt64(deleted, 1)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+
+		if streamStatus != http.StatusOK {
+			http.Error(w, `{"error": "Stream unavailable"}`, streamStatus)
+			return
+		}
+
+		fmt.Fprintln(w, `{"ip_str": "198.51.100.1", "port": 80}`)
+		fmt.Fprintln(w, `{"ip_str": "198.51.100.2", "port": 443}`)
+		w.(http.Flusher).Flush()
+
+		if hold {
+			<-r.Context().Done()
+		}
+	})
+
+	return deleted
+}
+
+func watchedNetworks() []*net.IPNet {
+	_, network, _ := net.ParseCIDR("198.51.100.0/24")
+	return []*net.IPNet{network}
+}
+
+func TestClient_WatchNetwork(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	deleted := handleWatchedAlert(t, http.StatusOK, true)
+
+	watch, err := client.WatchNetwork(context.Background(), "watch", watchedNetworks(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "WATCHED", watch.Alert.ID)
+
+	assert.Equal(t, "198.51.100.1", (<-watch.Banners).IP)
+	assert.Equal(t, 443, (<-watch.Banners).Port)
+
+	assert.Nil(t, watch.Close())
+	assert.Nil(t, watch.Close())
+	assert.Equal(t, int64(1), atomic.LoadInt64(deleted))
+
+	select {
+	case _, ok := <-watch.Banners:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("the banners channel wasn't closed")
+	}
+}
+
+func TestClient_WatchNetwork_streamEnded(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	deleted := handleWatchedAlert(t, http.StatusOK, false)
+
+	watch, err := client.WatchNetwork(context.Background(), "watch", watchedNetworks(), &StreamOptions{Buffer: 2})
+	assert.Nil(t, err)
+
+	count := 0
+	for range watch.Banners {
+		count++
+	}
+
+	assert.Equal(t, 2, count)
+	assert.Nil(t, watch.Close())
+	assert.Equal(t, int64(1), atomic.LoadInt64(deleted))
+}
+
+func TestClient_WatchNetwork_streamFailed(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	deleted := handleWatchedAlert(t, http.StatusServiceUnavailable, false)
+
+	watch, err := client.WatchNetwork(context.Background(), "watch", watchedNetworks(), nil)
+
+	assert.Nil(t, watch)
+	assert.Equal(t, http.StatusServiceUnavailable, errorStatus(err))
+	assert.Equal(t, int64(1), atomic.LoadInt64(deleted))
+}
+
+func TestClient_WatchNetwork_createFailed(t *testing.T) {
+	setUpTestServe()
+	defer tearDownTestServe()
+
+	mux.HandleFunc(alertCreatePath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "Invalid alert filters"}`, http.StatusBadRequest)
+	})
+
+	_, err := client.WatchNetwork(context.Background(), "watch", watchedNetworks(), nil)
+
+	assert.Equal(t, http.StatusBadRequest, errorStatus(err))
+}